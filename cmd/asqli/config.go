@@ -3,8 +3,16 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/alessandrolattao/asqli/internal/features/cost"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai/claude"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai/openai"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai/prompt"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai/replay"
 	"github.com/alessandrolattao/asqli/internal/infrastructure/config"
 	"github.com/alessandrolattao/asqli/internal/infrastructure/database/adapters"
 )
@@ -28,6 +36,7 @@ func buildDatabaseConfig(flags *Flags) adapters.Config {
 	// Create the appropriate configuration
 	cfg := adapters.Config{
 		DriverType: dbType,
+		ReadOnly:   readOnly(flags),
 	}
 
 	// If connection string is provided, use it directly
@@ -84,6 +93,150 @@ func buildDatabaseConfig(flags *Flags) adapters.Config {
 	return cfg
 }
 
+// readOnly reports whether the session is read-only: --read-only or a profile whose
+// settings.json sets "read_only", which the command line can't turn off
+func readOnly(flags *Flags) bool {
+	settings, err := config.LoadProfileSettings(flags.Profile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	return flags.ReadOnly || settings.ReadOnly
+}
+
+// buildAIConfig creates an AI provider configuration from flags and environment variables.
+// A comma-separated --provider list (e.g. "claude,openai,ollama") builds a fallback chain;
+// --model and --base-url accept matching comma-separated lists, a single value applies to the first provider.
+func buildAIConfig(flags *Flags) ai.Config {
+	providers := strings.Split(flags.Provider, ",")
+	models := strings.Split(flags.Model, ",")
+	baseURLs := strings.Split(flags.BaseURL, ",")
+
+	prompts := loadPromptTemplates(flags.Profile)
+
+	configs := make([]ai.Config, len(providers))
+	for i, provider := range providers {
+		configs[i] = buildProviderConfig(strings.TrimSpace(provider), listItem(models, i), listItem(baseURLs, i))
+		configs[i].MaxRetries = max(flags.Retries, 0)
+		configs[i].RequestsPerMinute = max(flags.RPM, 0)
+		configs[i].Prompts = prompts
+		switch configs[i].Type {
+		case ai.ProviderReplay:
+			configs[i].Options[replay.OptionCassette] = flags.Cassette
+		case ai.ProviderClaude:
+			if flags.ThinkingBudget > 0 {
+				configs[i].Options[claude.OptionThinkingBudget] = flags.ThinkingBudget
+			}
+		case ai.ProviderOpenAI:
+			if flags.ReasoningEffort != "" {
+				configs[i].Options[openai.OptionReasoningEffort] = flags.ReasoningEffort
+			}
+		}
+	}
+
+	aiConfig := configs[0]
+	aiConfig.Fallbacks = configs[1:]
+	aiConfig.RecordTo = flags.Record
+
+	return aiConfig
+}
+
+// loadPromptTemplates loads the built-in prompt templates with the user's overrides
+// from ~/.config/asqli/prompts/ and the profile's prompts directory
+func loadPromptTemplates(profile string) *prompt.Templates {
+	dirs, err := prompt.Dirs(profile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v, using built-in prompt templates\n", err)
+		return prompt.Default()
+	}
+
+	prompts, err := prompt.Load(dirs...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	return prompts
+}
+
+// listItem returns the trimmed i-th element of a comma-separated flag value, or "" if missing
+func listItem(values []string, i int) string {
+	if i >= len(values) {
+		return ""
+	}
+	return strings.TrimSpace(values[i])
+}
+
+// buildProviderConfig creates the configuration of a single AI provider
+func buildProviderConfig(provider, model, baseURL string) ai.Config {
+	// Determine AI provider type
+	var providerType ai.ProviderType
+	var apiKeyEnvVar string
+	var baseURLEnvVar string
+
+	switch provider {
+	case "openai":
+		providerType = ai.ProviderOpenAI
+		apiKeyEnvVar = "OPENAI_API_KEY"
+		baseURLEnvVar = "OPENAI_BASE_URL"
+	case "claude":
+		providerType = ai.ProviderClaude
+		apiKeyEnvVar = "ANTHROPIC_API_KEY"
+	case "gemini":
+		providerType = ai.ProviderGemini
+		apiKeyEnvVar = "GEMINI_API_KEY"
+	case "ollama":
+		providerType = ai.ProviderOllama
+		apiKeyEnvVar = "" // Ollama doesn't require an API key
+	case "replay":
+		providerType = ai.ProviderReplay
+		apiKeyEnvVar = "" // Replays a cassette recorded with --record, offline
+	default:
+		fmt.Fprintf(os.Stderr, "Error: Unsupported AI provider '%s'. Supported providers: openai, claude, gemini, ollama, replay\n", provider)
+		os.Exit(1)
+	}
+
+	// Custom endpoint: flag takes precedence over the provider's environment variable
+	if baseURL == "" && baseURLEnvVar != "" {
+		baseURL = os.Getenv(baseURLEnvVar)
+	}
+
+	// Check AI Provider API key (skip for Ollama and for custom endpoints, e.g. local OpenAI-compatible servers)
+	var apiKey string
+	if apiKeyEnvVar != "" {
+		apiKey = os.Getenv(apiKeyEnvVar)
+		if apiKey == "" && baseURL == "" {
+			fmt.Fprintf(os.Stderr, "Error: %s environment variable is not set\n", apiKeyEnvVar)
+			os.Exit(1)
+		}
+	}
+
+	aiConfig := ai.Config{
+		Type:        providerType,
+		APIKey:      apiKey,
+		Model:       model, // Use specified model or default
+		BaseURL:     baseURL,
+		Temperature: 0.0,
+		Options:     map[string]any{},
+	}
+
+	// Azure OpenAI deployments need an explicit API version, and some endpoints a simpler response format
+	if providerType == ai.ProviderOpenAI {
+		if apiType := os.Getenv("OPENAI_API_TYPE"); apiType != "" {
+			aiConfig.Options[openai.OptionAPIType] = apiType
+		}
+		if apiVersion := os.Getenv("OPENAI_API_VERSION"); apiVersion != "" {
+			aiConfig.Options[openai.OptionAPIVersion] = apiVersion
+		}
+		if responseFormat := os.Getenv("OPENAI_RESPONSE_FORMAT"); responseFormat != "" {
+			aiConfig.Options[openai.OptionResponseFormat] = responseFormat
+		}
+	}
+
+	return aiConfig
+}
+
 // buildTimeoutConfig creates a timeout configuration from flags
 func buildTimeoutConfig(flags *Flags) config.TimeoutConfig {
 	// Start with defaults
@@ -105,3 +258,101 @@ func buildTimeoutConfig(flags *Flags) config.TimeoutConfig {
 
 	return timeouts
 }
+
+// buildGenerationConfig creates a generation configuration from flags.
+// Requests are sized for the smallest context window of the AI provider chain.
+func buildGenerationConfig(flags *Flags, aiConfig ai.Config) config.GenerationConfig {
+	// Start with defaults
+	generation := config.DefaultGeneration()
+
+	if flags.MaxRepairs >= 0 {
+		generation.MaxRepairAttempts = flags.MaxRepairs
+	}
+
+	if flags.MaxClarifications >= 0 {
+		generation.MaxClarifications = flags.MaxClarifications
+	}
+
+	generation.SchemaCheck = flags.SchemaCheck
+
+	if flags.Candidates > 0 {
+		generation.Candidates = flags.Candidates
+	}
+
+	if flags.HistoryTokens >= 0 {
+		generation.ConversationTokens = flags.HistoryTokens
+	}
+
+	if flags.ResultSamples >= 0 {
+		generation.ResultSampleRows = flags.ResultSamples
+	}
+
+	generation.Explore = flags.Explore
+	if flags.ExploreTables >= 0 {
+		generation.ExploreSchemaTables = flags.ExploreTables
+	}
+
+	if flags.RetrievalTables >= 0 {
+		generation.RetrievalTables = flags.RetrievalTables
+	}
+
+	if flags.RelevantTables >= 0 {
+		generation.RelevantTables = flags.RelevantTables
+	}
+
+	generation.EmbeddingModel = flags.EmbeddingModel
+
+	limits := ai.DefaultModels().ChainLimits(aiConfig)
+	generation.ContextWindow = limits.ContextWindow
+	generation.CharsPerToken = limits.CharsPerToken
+	if flags.ContextWindow > 0 {
+		generation.ContextWindow = flags.ContextWindow
+	}
+
+	// Claude's extended thinking shares the context window with the request
+	if thinking := thinkingBudget(aiConfig); thinking > 0 {
+		generation.ContextWindow = max(generation.ContextWindow-thinking, generation.ContextWindow/2)
+	}
+
+	return generation
+}
+
+// thinkingBudget returns the largest extended thinking budget of the AI provider chain
+func thinkingBudget(aiConfig ai.Config) int {
+	budget, _ := aiConfig.Options[claude.OptionThinkingBudget].(int)
+	for _, fallback := range aiConfig.Fallbacks {
+		budget = max(budget, thinkingBudget(fallback))
+	}
+	return budget
+}
+
+// usageLedgerFile is the name of the usage ledger in the configuration directory
+const usageLedgerFile = "usage.jsonl"
+
+// buildCostTracker creates the AI spend tracker. Built-in prices can be overridden in
+// ~/.config/asqli/prices.json and in the profile's prices.json; every AI request is
+// appended to the usage ledger (~/.config/asqli/usage.jsonl).
+func buildCostTracker(flags *Flags) *cost.Tracker {
+	dir, err := config.Dir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v, using built-in prices without a usage ledger\n", err)
+		return cost.NewTracker(cost.DefaultPrices(), flags.Budget, nil)
+	}
+
+	pricePaths := []string{filepath.Join(dir, "prices.json")}
+	if flags.Profile != "" {
+		if profileDir, err := config.ProfileDir(flags.Profile); err == nil {
+			pricePaths = append(pricePaths, filepath.Join(profileDir, "prices.json"))
+		}
+	}
+
+	prices, err := cost.LoadPrices(pricePaths...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	ledger := cost.NewLedger(filepath.Join(dir, usageLedgerFile), flags.Profile)
+
+	return cost.NewTracker(prices, max(flags.Budget, 0), ledger)
+}
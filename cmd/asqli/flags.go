@@ -5,12 +5,25 @@ import "flag"
 // Flags holds all command-line flags for the application
 type Flags struct {
 	// Command flags
-	Version bool
-	Update  bool
+	Version     bool
+	Update      bool
+	UsageReport bool
+
+	// Connection profile (per-profile settings in ~/.config/asqli/profiles/<profile>/)
+	Profile string
 
 	// AI Provider flags
 	Provider string
 	Model    string
+	BaseURL  string
+	Retries  int
+	RPM      int
+	Cassette string
+	Record   string
+
+	// Reasoning settings
+	ThinkingBudget  int
+	ReasoningEffort string
 
 	// Database type
 	DBType string
@@ -18,6 +31,9 @@ type Flags struct {
 	// Connection string
 	Connection string
 
+	// Read-only session
+	ReadOnly bool
+
 	// PostgreSQL/MySQL shared connection parameters
 	Host     string
 	Port     int
@@ -34,6 +50,23 @@ type Flags struct {
 	// SQLite specific
 	File string
 
+	// Generation settings
+	MaxRepairs        int
+	MaxClarifications int
+	SchemaCheck       bool
+	Candidates        int
+	HistoryTokens     int
+	ResultSamples     int
+	Explore           bool
+	ExploreTables     int
+	RetrievalTables   int
+	RelevantTables    int
+	EmbeddingModel    string
+	ContextWindow     int
+
+	// AI spend limit for the session in USD
+	Budget float64
+
 	// Timeout settings (in seconds)
 	TimeoutConnection int
 	TimeoutQuery      int
@@ -48,10 +81,21 @@ func ParseFlags() *Flags {
 	// Command flags
 	flag.BoolVar(&f.Version, "version", false, "Print the version of asqli")
 	flag.BoolVar(&f.Update, "update", false, "Check for updates and update to the latest version")
+	flag.BoolVar(&f.UsageReport, "usage-report", false, "Print AI usage and cost per user and model from the usage ledger")
+
+	// Connection profile
+	flag.StringVar(&f.Profile, "profile", "", "Connection profile name, enables settings such as prompt templates from ~/.config/asqli/profiles/<profile>/")
 
 	// AI Provider
-	flag.StringVar(&f.Provider, "provider", "openai", "AI provider (openai, claude, gemini, ollama)")
+	flag.StringVar(&f.Provider, "provider", "openai", "AI provider (openai, claude, gemini, ollama, replay); a comma-separated list is tried in order on rate limits, timeouts and outages")
 	flag.StringVar(&f.Model, "model", "", "AI model to use (defaults to provider's default model)")
+	flag.StringVar(&f.BaseURL, "base-url", "", "Custom AI endpoint, e.g. an OpenAI-compatible server (env: OPENAI_BASE_URL)")
+	flag.StringVar(&f.Cassette, "cassette", "", "Cassette file served by the replay provider (--provider replay)")
+	flag.StringVar(&f.Record, "record", "", "Record every AI response to this cassette file, for later use with --provider replay")
+	flag.IntVar(&f.Retries, "ai-retries", 2, "Times a rate-limited, timed out or failing AI request is retried with backoff (0 = disabled)")
+	flag.IntVar(&f.RPM, "ai-rpm", 0, "Maximum AI requests per minute sent to each provider (0 = unlimited)")
+	flag.IntVar(&f.ThinkingBudget, "thinking-budget", 0, "Claude: tokens of extended thinking before writing SQL, at least 1024 (0 = disabled)")
+	flag.StringVar(&f.ReasoningEffort, "reasoning-effort", "", "OpenAI: reasoning effort of reasoning models (minimal, low, medium, high; default: the model's own)")
 
 	// Database type
 	flag.StringVar(&f.DBType, "dbtype", "postgres", "Database type (postgres, mysql, sqlite)")
@@ -59,6 +103,9 @@ func ParseFlags() *Flags {
 	// Connection string
 	flag.StringVar(&f.Connection, "connection", "", "Database connection string (if provided, other connection params are ignored)")
 
+	// Read-only session
+	flag.BoolVar(&f.ReadOnly, "read-only", false, "Run every query in a read-only transaction so nothing can be written (also \"read_only\" in the profile's settings.json)")
+
 	// PostgreSQL/MySQL shared connection parameters
 	flag.StringVar(&f.Host, "host", "", "Database host")
 	flag.IntVar(&f.Port, "port", 5432, "Database port")
@@ -75,6 +122,21 @@ func ParseFlags() *Flags {
 	// SQLite specific
 	flag.StringVar(&f.File, "file", "", "SQLite database file path")
 
+	// Generation settings
+	flag.Float64Var(&f.Budget, "budget", 0, "AI spend limit for the session in USD; further AI requests are refused once reached (0 = unlimited)")
+	flag.IntVar(&f.MaxRepairs, "max-repairs", 2, "Times failing AI-generated SQL is sent back to the AI with the database error (0 = disabled)")
+	flag.IntVar(&f.MaxClarifications, "max-clarifications", 2, "Clarifying questions the AI may ask about an ambiguous prompt before answering with SQL (0 = never ask)")
+	flag.BoolVar(&f.SchemaCheck, "schema-check", true, "Check the tables and columns of AI-generated SQL against the schema before running it; unknown names are sent back to the AI")
+	flag.IntVar(&f.HistoryTokens, "history-tokens", 2000, "Approximate tokens of previous prompts and SQL sent as conversation turns; older turns are summarized (0 = no limit)")
+	flag.IntVar(&f.ResultSamples, "result-samples", 5, "Rows of the last result sent to the AI with its columns and row count, for follow-ups (0 = no row values)")
+	flag.BoolVar(&f.Explore, "explore", false, "Let the AI inspect the database with read-only tools (list, describe and sample tables) before writing SQL")
+	flag.IntVar(&f.ExploreTables, "explore-schema-tables", 50, "With --explore, databases with more tables only send table names instead of the full schema (0 = always send the full schema)")
+	flag.IntVar(&f.RetrievalTables, "retrieval-tables", 100, "Databases with more tables only send the tables most relevant to each prompt and their foreign-key neighbours (0 = every database)")
+	flag.IntVar(&f.RelevantTables, "relevant-tables", 15, "Tables retrieved per prompt on databases above --retrieval-tables (0 = always send the full schema)")
+	flag.StringVar(&f.EmbeddingModel, "embedding-model", "", "Ollama embedding model used to match prompts with tables by meaning, e.g. nomic-embed-text (default: keyword matching only)")
+	flag.IntVar(&f.ContextWindow, "context-window", 0, "Context window of the AI model in tokens; larger requests are cut down to fit (default: the model's known window)")
+	flag.IntVar(&f.Candidates, "candidates", 1, "Candidate queries generated per prompt; above 1, candidates are checked with EXPLAIN and the most agreed-upon one is used")
+
 	// Timeout settings (in seconds, 0 = use default)
 	flag.IntVar(&f.TimeoutConnection, "timeout-connection", 0, "Database connection timeout in seconds (default: 10)")
 	flag.IntVar(&f.TimeoutQuery, "timeout-query", 0, "Database query execution timeout in seconds (default: 30)")
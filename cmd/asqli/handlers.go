@@ -1,11 +1,19 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
+	"text/tabwriter"
 
+	"github.com/alessandrolattao/asqli/internal/features/cost"
 	"github.com/alessandrolattao/asqli/internal/features/update"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/config"
 )
 
 // handleVersion prints the version information
@@ -55,3 +63,68 @@ func handleUpdate() {
 
 	fmt.Printf("Successfully updated to version %s!\n", latestVersion)
 }
+
+// handleUsageReport prints AI usage and cost from the usage ledger, grouped by user and model
+func handleUsageReport() {
+	dir, err := config.Dir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	path := filepath.Join(dir, usageLedgerFile)
+	entries, err := cost.ReadLedger(path)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("No AI usage recorded yet (%s)\n", path)
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading usage ledger: %v\n", err)
+		os.Exit(1)
+	}
+
+	type group struct {
+		user, model            string
+		requests, prompt, resp int
+		cost                   float64
+		unpriced               int
+	}
+
+	groups := make(map[[2]string]*group)
+	for _, entry := range entries {
+		model := entry.Provider + "/" + entry.Model
+		key := [2]string{entry.User, model}
+		g, ok := groups[key]
+		if !ok {
+			g = &group{user: entry.User, model: model}
+			groups[key] = g
+		}
+		g.requests++
+		g.prompt += entry.PromptTokens
+		g.resp += entry.ResponseTokens
+		g.cost += entry.Cost
+		if !entry.Priced {
+			g.unpriced++
+		}
+	}
+
+	sorted := slices.SortedFunc(maps.Values(groups), func(a, b *group) int {
+		return cmp.Or(cmp.Compare(a.user, b.user), cmp.Compare(a.model, b.model))
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "USER\tMODEL\tREQUESTS\tPROMPT TOKENS\tRESPONSE TOKENS\tCOST (USD)")
+
+	var total float64
+	for _, g := range sorted {
+		costText := fmt.Sprintf("%.4f", g.cost)
+		if g.unpriced > 0 {
+			costText += fmt.Sprintf(" (%d unpriced)", g.unpriced)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%s\n", g.user, g.model, g.requests, g.prompt, g.resp, costText)
+		total += g.cost
+	}
+	_ = w.Flush()
+
+	fmt.Printf("\nTotal: $%.4f over %d requests (%s)\n", total, len(entries), path)
+}
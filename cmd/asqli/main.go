@@ -5,6 +5,7 @@ import (
 	_ "github.com/alessandrolattao/asqli/internal/infrastructure/ai/gemini" // Register Gemini provider
 	_ "github.com/alessandrolattao/asqli/internal/infrastructure/ai/ollama" // Register Ollama provider
 	_ "github.com/alessandrolattao/asqli/internal/infrastructure/ai/openai" // Register OpenAI provider
+	_ "github.com/alessandrolattao/asqli/internal/infrastructure/ai/replay" // Register replay provider and recorder
 )
 
 func main() {
@@ -23,10 +24,19 @@ func main() {
 		return
 	}
 
+	// Handle usage report command
+	if flags.UsageReport {
+		handleUsageReport()
+		return
+	}
+
 	// Build configurations
 	dbConfig := buildDatabaseConfig(flags)
+	aiConfig := buildAIConfig(flags)
 	timeoutConfig := buildTimeoutConfig(flags)
+	generationConfig := buildGenerationConfig(flags, aiConfig)
+	costTracker := buildCostTracker(flags)
 
 	// Start query session
-	runQuerySession(dbConfig, timeoutConfig, flags.Provider, flags.Model)
+	runQuerySession(dbConfig, aiConfig, timeoutConfig, generationConfig, costTracker)
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/alessandrolattao/asqli/internal/features/cost"
 	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
 	"github.com/alessandrolattao/asqli/internal/infrastructure/config"
 	"github.com/alessandrolattao/asqli/internal/infrastructure/database/adapters"
@@ -11,48 +12,9 @@ import (
 )
 
 // runQuerySession starts a query session with the specified database and AI provider
-func runQuerySession(dbConfig adapters.Config, timeoutConfig config.TimeoutConfig, providerStr string, modelStr string) {
-	// Determine AI provider type
-	var providerType ai.ProviderType
-	var apiKeyEnvVar string
-
-	switch providerStr {
-	case "openai":
-		providerType = ai.ProviderOpenAI
-		apiKeyEnvVar = "OPENAI_API_KEY"
-	case "claude":
-		providerType = ai.ProviderClaude
-		apiKeyEnvVar = "ANTHROPIC_API_KEY"
-	case "gemini":
-		providerType = ai.ProviderGemini
-		apiKeyEnvVar = "GEMINI_API_KEY"
-	case "ollama":
-		providerType = ai.ProviderOllama
-		apiKeyEnvVar = "" // Ollama doesn't require an API key
-	default:
-		fmt.Fprintf(os.Stderr, "Error: Unsupported AI provider '%s'. Supported providers: openai, claude, gemini, ollama\n", providerStr)
-		os.Exit(1)
-	}
-
-	// Check AI Provider API key (skip for Ollama)
-	var apiKey string
-	if apiKeyEnvVar != "" {
-		apiKey = os.Getenv(apiKeyEnvVar)
-		if apiKey == "" {
-			fmt.Fprintf(os.Stderr, "Error: %s environment variable is not set\n", apiKeyEnvVar)
-			os.Exit(1)
-		}
-	}
-
-	aiConfig := ai.Config{
-		Type:        providerType,
-		APIKey:      apiKey,
-		Model:       modelStr, // Use specified model or default
-		Temperature: 0.0,
-	}
-
+func runQuerySession(dbConfig adapters.Config, aiConfig ai.Config, timeoutConfig config.TimeoutConfig, generationConfig config.GenerationConfig, costTracker *cost.Tracker) {
 	// Start CLI - it will handle connection and initialization
-	cliApp := cli.NewApp(dbConfig, aiConfig, timeoutConfig)
+	cliApp := cli.NewApp(dbConfig, aiConfig, timeoutConfig, generationConfig, costTracker)
 
 	if err := cliApp.Start(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error running application: %v\n", err)
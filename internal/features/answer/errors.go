@@ -0,0 +1,13 @@
+// Package answer defines errors related to answering questions from query results.
+package answer
+
+import "errors"
+
+// Sentinel errors returned by the answer service.
+var (
+	// ErrEmptyQuestion is returned when there is no question to answer
+	ErrEmptyQuestion = errors.New("question cannot be empty")
+
+	// ErrNoResult is returned when there is no result set to answer from
+	ErrNoResult = errors.New("no query result to answer from")
+)
@@ -0,0 +1,78 @@
+// Package answer provides plain-language answers to questions from query results using AI providers.
+package answer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alessandrolattao/asqli/internal/features/cost"
+	"github.com/alessandrolattao/asqli/internal/features/execution"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/database/adapters"
+)
+
+// Answer is a plain-language answer to the question behind a query
+type Answer struct {
+	// One-paragraph answer
+	Text string
+
+	// Usage metadata (tokens, model, provider, etc.)
+	Usage ai.UsageMetadata
+
+	// Cost of the AI request that produced the answer
+	Cost cost.Charge
+}
+
+// Service turns query results into plain-language answers
+type Service struct {
+	aiProvider  ai.Provider
+	costTracker *cost.Tracker
+
+	// SQL dialect of the database the results come from
+	dialect adapters.DriverType
+}
+
+// NewService creates a new answer service for results of a database of the given dialect.
+// Every AI request is charged to costTracker, which may be nil to disable accounting.
+func NewService(aiProvider ai.Provider, costTracker *cost.Tracker, dialect adapters.DriverType) *Service {
+	return &Service{
+		aiProvider:  aiProvider,
+		costTracker: costTracker,
+		dialect:     dialect,
+	}
+}
+
+// Answer sends a digest of result (see execution.Result.Digest, with up to sampleRows rows)
+// to the AI provider together with the question and the SQL that produced it, and returns
+// the provider's one-paragraph answer.
+func (s *Service) Answer(ctx context.Context, question, query string, result *execution.Result, sampleRows int) (*Answer, error) {
+	if question == "" {
+		return nil, ErrEmptyQuestion
+	}
+
+	digest := result.Digest(sampleRows)
+	if digest == "" {
+		return nil, ErrNoResult
+	}
+
+	// Refuse to spend more once the budget is exhausted
+	if err := s.costTracker.Check(); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.aiProvider.Answer(ctx, &ai.AnswerRequest{
+		Question:     question,
+		Query:        query,
+		Result:       digest,
+		DatabaseType: string(s.dialect),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to answer: %w", err)
+	}
+
+	return &Answer{
+		Text:  resp.Answer,
+		Usage: resp.Usage,
+		Cost:  s.costTracker.Record(resp.Usage),
+	}, nil
+}
@@ -0,0 +1,10 @@
+// Package cost defines errors related to AI spend accounting.
+package cost
+
+import "errors"
+
+// Sentinel errors returned by the cost tracker.
+var (
+	// ErrBudgetExceeded is returned when the session spend reached the configured budget
+	ErrBudgetExceeded = errors.New("AI budget exceeded")
+)
@@ -0,0 +1,105 @@
+// Package cost provides a persistent, append-only ledger of AI usage.
+package cost
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry is one AI request recorded in the usage ledger
+type Entry struct {
+	Time             time.Time `json:"time"`
+	User             string    `json:"user"`
+	Profile          string    `json:"profile,omitempty"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	ResponseTokens   int       `json:"response_tokens"`
+	CachedTokens     int       `json:"cached_tokens,omitempty"`
+	CacheWriteTokens int       `json:"cache_write_tokens,omitempty"`
+	Cost             float64   `json:"cost_usd"`
+	Priced           bool      `json:"priced"`
+}
+
+// Ledger appends usage entries to a JSON Lines file, one line per AI request,
+// so spend can be aggregated per analyst, profile or model across sessions
+type Ledger struct {
+	mu      sync.Mutex
+	path    string
+	user    string
+	profile string
+}
+
+// NewLedger creates a ledger writing to path for the current OS user and profile
+func NewLedger(path, profile string) *Ledger {
+	return &Ledger{
+		path:    path,
+		user:    currentUser(),
+		profile: profile,
+	}
+}
+
+// Append writes an entry to the ledger file, creating it if needed.
+// User and profile are filled in from the ledger.
+func (l *Ledger) Append(entry Entry) error {
+	entry.User = l.user
+	entry.Profile = l.profile
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("failed to create usage ledger directory: %w", err)
+	}
+
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open usage ledger: %w", err)
+	}
+
+	_, err = file.Write(append(data, '\n'))
+	return errors.Join(err, file.Close())
+}
+
+// ReadLedger reads all entries of a ledger file. Malformed lines are skipped.
+func ReadLedger(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, scanner.Err()
+}
+
+// currentUser returns the OS user name, used to attribute spend to an analyst
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
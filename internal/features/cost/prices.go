@@ -0,0 +1,150 @@
+// Package cost provides AI usage cost accounting, spend budgets and a persistent usage ledger.
+package cost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+)
+
+// Price is the cost of a model in USD per million tokens
+type Price struct {
+	// Uncached prompt tokens
+	Input float64 `json:"input"`
+
+	// Response tokens
+	Output float64 `json:"output"`
+
+	// Prompt tokens read from the provider's cache (0 = same as Input)
+	CachedInput float64 `json:"cached_input,omitempty"`
+
+	// Prompt tokens written to the provider's cache (0 = same as Input)
+	CacheWrite float64 `json:"cache_write,omitempty"`
+}
+
+// Cost returns the cost in USD of a request with the given usage
+func (p Price) Cost(usage ai.UsageMetadata) float64 {
+	cachedInput := p.CachedInput
+	if cachedInput == 0 {
+		cachedInput = p.Input
+	}
+	cacheWrite := p.CacheWrite
+	if cacheWrite == 0 {
+		cacheWrite = p.Input
+	}
+
+	uncached := max(usage.PromptTokens-usage.CachedTokens-usage.CacheWriteTokens, 0)
+
+	return (float64(uncached)*p.Input +
+		float64(usage.CachedTokens)*cachedInput +
+		float64(usage.CacheWriteTokens)*cacheWrite +
+		float64(usage.ResponseTokens)*p.Output) / 1_000_000
+}
+
+// PriceTable maps "provider/model" keys (e.g. "openai/gpt-4o") to prices.
+// A key also prices the dated snapshots and versions of its model returned by the API
+// ("gpt-4o-2024-08-06", "claude-3-5-sonnet-latest"), but not other models whose name
+// it starts ("o3-pro" for "o3"). Keys ending with "/" price every model of a provider.
+type PriceTable map[string]Price
+
+// DefaultPrices returns the built-in price table (USD per million tokens, standard tier).
+// Local Ollama models are free; custom endpoints need an entry in prices.json.
+func DefaultPrices() PriceTable {
+	return PriceTable{
+		// OpenAI (cached input at the automatic prompt caching rate)
+		"openai/gpt-5":        {Input: 1.25, Output: 10, CachedInput: 0.125},
+		"openai/gpt-5-mini":   {Input: 0.25, Output: 2, CachedInput: 0.025},
+		"openai/gpt-5-nano":   {Input: 0.05, Output: 0.40, CachedInput: 0.005},
+		"openai/gpt-4.1":      {Input: 2, Output: 8, CachedInput: 0.50},
+		"openai/gpt-4.1-mini": {Input: 0.40, Output: 1.60, CachedInput: 0.10},
+		"openai/gpt-4.1-nano": {Input: 0.10, Output: 0.40, CachedInput: 0.025},
+		"openai/gpt-4o":       {Input: 2.50, Output: 10, CachedInput: 1.25},
+		"openai/gpt-4o-mini":  {Input: 0.15, Output: 0.60, CachedInput: 0.075},
+		"openai/o3":           {Input: 2, Output: 8, CachedInput: 0.50},
+		"openai/o3-mini":      {Input: 1.10, Output: 4.40, CachedInput: 0.55},
+		"openai/o4-mini":      {Input: 1.10, Output: 4.40, CachedInput: 0.275},
+
+		// Anthropic (cache reads at 0.1x, 5-minute cache writes at 1.25x the input price)
+		"claude/claude-opus-4-5":   {Input: 5, Output: 25, CachedInput: 0.50, CacheWrite: 6.25},
+		"claude/claude-opus-4":     {Input: 15, Output: 75, CachedInput: 1.50, CacheWrite: 18.75},
+		"claude/claude-sonnet-4":   {Input: 3, Output: 15, CachedInput: 0.30, CacheWrite: 3.75},
+		"claude/claude-haiku-4-5":  {Input: 1, Output: 5, CachedInput: 0.10, CacheWrite: 1.25},
+		"claude/claude-3-7-sonnet": {Input: 3, Output: 15, CachedInput: 0.30, CacheWrite: 3.75},
+		"claude/claude-3-5-sonnet": {Input: 3, Output: 15, CachedInput: 0.30, CacheWrite: 3.75},
+		"claude/claude-3-5-haiku":  {Input: 0.80, Output: 4, CachedInput: 0.08, CacheWrite: 1},
+		"claude/claude-3-opus":     {Input: 15, Output: 75, CachedInput: 1.50, CacheWrite: 18.75},
+		"claude/claude-3-haiku":    {Input: 0.25, Output: 1.25, CachedInput: 0.03, CacheWrite: 0.30},
+
+		// Google (prompts up to 200k tokens; cache storage is not included)
+		"gemini/gemini-2.5-pro":        {Input: 1.25, Output: 10, CachedInput: 0.31},
+		"gemini/gemini-2.5-flash":      {Input: 0.30, Output: 2.50, CachedInput: 0.075},
+		"gemini/gemini-2.5-flash-lite": {Input: 0.10, Output: 0.40, CachedInput: 0.025},
+		"gemini/gemini-2.0-flash":      {Input: 0.10, Output: 0.40, CachedInput: 0.025},
+		"gemini/gemini-2.0-flash-lite": {Input: 0.075, Output: 0.30},
+
+		// Local models and recorded responses
+		"ollama/": {},
+		"replay/": {},
+	}
+}
+
+// LoadPrices returns the built-in price table with the entries of the JSON files at paths
+// added or replaced, in order, e.g. {"openai/gpt-4o": {"input": 2.5, "output": 10, "cached_input": 1.25}}.
+// Missing files are ignored.
+func LoadPrices(paths ...string) (PriceTable, error) {
+	prices := DefaultPrices()
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read price table: %w", err)
+		}
+
+		var overrides PriceTable
+		if err := json.Unmarshal(data, &overrides); err != nil {
+			return nil, fmt.Errorf("invalid price table %s: %w", path, err)
+		}
+		maps.Copy(prices, overrides)
+	}
+
+	return prices, nil
+}
+
+// Lookup returns the price of a provider's model, from the longest key matching it
+func (t PriceTable) Lookup(provider, model string) (Price, bool) {
+	key := provider + "/" + model
+
+	var best string
+	var price Price
+	found := false
+	for prefix, p := range t {
+		if prices(prefix, key) && len(prefix) >= len(best) {
+			best, price, found = prefix, p, true
+		}
+	}
+
+	return price, found
+}
+
+// prices reports whether the entry of prefix prices the model of key: the same model,
+// a snapshot or version of it ("-2024-08-06", "-1", "-latest"), or any model of a
+// provider for prefixes ending with "/"
+func prices(prefix, key string) bool {
+	rest, ok := strings.CutPrefix(key, prefix)
+	switch {
+	case !ok:
+		return false
+	case rest == "" || strings.HasSuffix(prefix, "/") || rest == "-latest":
+		return true
+	default:
+		return len(rest) > 1 && rest[0] == '-' && rest[1] >= '0' && rest[1] <= '9'
+	}
+}
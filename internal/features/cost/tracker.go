@@ -0,0 +1,166 @@
+// Package cost provides session spend tracking against an optional budget.
+package cost
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+)
+
+// Totals accumulates the usage of every AI request made in the session
+type Totals struct {
+	Requests         int
+	PromptTokens     int
+	ResponseTokens   int
+	CachedTokens     int
+	CacheWriteTokens int
+
+	// Cost in USD of the priced requests
+	Cost float64
+
+	// Requests to models missing from the price table (not included in Cost)
+	UnpricedRequests int
+}
+
+// Charge is the cost of a single AI request
+type Charge struct {
+	// Cost in USD (0 when the model is not priced)
+	Cost float64
+
+	// Priced is false when the model is missing from the price table
+	Priced bool
+}
+
+// Tracker prices AI usage, accumulates session totals, enforces the spend
+// budget and records every request in the usage ledger. It is safe for concurrent use.
+type Tracker struct {
+	mu     sync.Mutex
+	prices PriceTable
+	budget float64
+	ledger *Ledger
+	totals Totals
+
+	// Estimated cost of the requests still running (see Reserve), and the cost of the
+	// most expensive request so far, which estimates the next ones
+	reserved  float64
+	maxCharge float64
+}
+
+// NewTracker creates a tracker. A budget of 0 means unlimited; a nil ledger disables persistence.
+func NewTracker(prices PriceTable, budget float64, ledger *Ledger) *Tracker {
+	return &Tracker{
+		prices: prices,
+		budget: budget,
+		ledger: ledger,
+	}
+}
+
+// Check returns ErrBudgetExceeded when the session spend, with the estimated cost of
+// the requests still running, reached the budget
+func (t *Tracker) Check() error {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.check()
+}
+
+// Reserve checks the budget like Check before a request starts, and counts the request
+// as running until release is called, once its usage is recorded. Requests running in
+// parallel (e.g. candidates) are estimated at the cost of the most expensive request
+// so far, so that together they stop at the budget rather than all pass the check;
+// before any priced request, they can't be estimated.
+func (t *Tracker) Reserve() (release func(), err error) {
+	if t == nil {
+		return func() {}, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.check(); err != nil {
+		return nil, err
+	}
+
+	estimate := t.maxCharge
+	t.reserved += estimate
+	return func() {
+		t.mu.Lock()
+		t.reserved -= estimate
+		t.mu.Unlock()
+	}, nil
+}
+
+// check returns ErrBudgetExceeded when the spend and reservations reached the budget.
+// The caller must hold t.mu.
+func (t *Tracker) check() error {
+	if t.budget > 0 && t.totals.Cost+t.reserved >= t.budget {
+		return fmt.Errorf("%w: spent $%.4f of $%.2f", ErrBudgetExceeded, t.totals.Cost, t.budget)
+	}
+	return nil
+}
+
+// Record adds the usage of a completed AI request to the session totals and the ledger
+func (t *Tracker) Record(usage ai.UsageMetadata) Charge {
+	if t == nil {
+		return Charge{}
+	}
+
+	var charge Charge
+	if price, ok := t.prices.Lookup(usage.Provider, usage.Model); ok {
+		charge = Charge{Cost: price.Cost(usage), Priced: true}
+	}
+
+	t.mu.Lock()
+	t.totals.Requests++
+	t.totals.PromptTokens += usage.PromptTokens
+	t.totals.ResponseTokens += usage.ResponseTokens
+	t.totals.CachedTokens += usage.CachedTokens
+	t.totals.CacheWriteTokens += usage.CacheWriteTokens
+	t.totals.Cost += charge.Cost
+	t.maxCharge = max(t.maxCharge, charge.Cost)
+	if !charge.Priced {
+		t.totals.UnpricedRequests++
+	}
+	t.mu.Unlock()
+
+	if t.ledger != nil {
+		// Best effort: a read-only home directory must not break generation
+		_ = t.ledger.Append(Entry{
+			Time:             time.Now().UTC(),
+			Provider:         usage.Provider,
+			Model:            usage.Model,
+			PromptTokens:     usage.PromptTokens,
+			ResponseTokens:   usage.ResponseTokens,
+			CachedTokens:     usage.CachedTokens,
+			CacheWriteTokens: usage.CacheWriteTokens,
+			Cost:             charge.Cost,
+			Priced:           charge.Priced,
+		})
+	}
+
+	return charge
+}
+
+// Totals returns the session totals so far
+func (t *Tracker) Totals() Totals {
+	if t == nil {
+		return Totals{}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.totals
+}
+
+// Budget returns the session budget in USD (0 = unlimited)
+func (t *Tracker) Budget() float64 {
+	if t == nil {
+		return 0
+	}
+	return t.budget
+}
@@ -0,0 +1,146 @@
+package execution
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// digestMaxColumns is the number of columns described in a result digest
+	digestMaxColumns = 20
+
+	// digestMaxValueLength truncates long values in sample rows
+	digestMaxValueLength = 40
+
+	// digestMaxLength caps the size of a result digest in characters
+	digestMaxLength = 2000
+)
+
+// Digest returns a compact, size-capped description of the result for the AI:
+// row count, column names with inferred types and up to sampleRows sample rows
+// (0 keeps row values out of the digest). It lets follow-up prompts refer to
+// what the previous query returned.
+func (r *Result) Digest(sampleRows int) string {
+	if r == nil || len(r.Columns) == 0 {
+		return ""
+	}
+
+	columns := r.Columns[:min(len(r.Columns), digestMaxColumns)]
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Rows: %d\n", len(r.Rows)))
+
+	sb.WriteString("Columns: ")
+	for i, col := range columns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(fmt.Sprintf("%s (%s)", col, r.inferType(col)))
+	}
+	if omitted := len(r.Columns) - len(columns); omitted > 0 {
+		sb.WriteString(fmt.Sprintf(" and %d more", omitted))
+	}
+	sb.WriteString("\n")
+
+	if len(r.Rows) > 0 && sampleRows > 0 {
+		sb.WriteString("Sample rows:\n")
+		sb.WriteString(strings.Join(columns, " | "))
+		sb.WriteString("\n")
+		for _, row := range r.Rows[:min(len(r.Rows), sampleRows)] {
+			values := make([]string, len(columns))
+			for i, col := range columns {
+				values[i] = digestValue(row[col])
+			}
+			line := strings.Join(values, " | ") + "\n"
+
+			// Stop before exceeding the size cap rather than cutting a row in half
+			if sb.Len()+len(line) > digestMaxLength {
+				sb.WriteString("...\n")
+				break
+			}
+			sb.WriteString(line)
+		}
+	}
+
+	return sb.String()
+}
+
+// inferType returns the type shared by every non-NULL value of col, "mixed" when
+// values disagree, or "unknown" when the column only contains NULLs.
+// Drivers that return numbers as text (e.g. MySQL) are reported as numbers.
+func (r *Result) inferType(col string) string {
+	inferred := ""
+	for _, row := range r.Rows {
+		value := row[col]
+		if value == nil {
+			continue
+		}
+
+		valueType := valueType(value)
+		switch {
+		case inferred == "":
+			inferred = valueType
+		case inferred == valueType:
+		case inferred == "integer" && valueType == "number", inferred == "number" && valueType == "integer":
+			inferred = "number"
+		default:
+			return "mixed"
+		}
+	}
+
+	if inferred == "" {
+		return "unknown"
+	}
+	return inferred
+}
+
+// valueType returns the inferred type name of a single value
+func valueType(value any) string {
+	switch v := value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return "integer"
+	case float32, float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case time.Time:
+		return "timestamp"
+	case string:
+		if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return "integer"
+		}
+		if _, err := strconv.ParseFloat(v, 64); err == nil {
+			return "number"
+		}
+		if _, err := time.Parse(time.DateOnly, v); err == nil {
+			return "date"
+		}
+		if _, err := time.Parse(time.DateTime, v); err == nil {
+			return "timestamp"
+		}
+		return "text"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// digestValue formats a sample value on a single line, truncating long values
+func digestValue(value any) string {
+	if value == nil {
+		return "NULL"
+	}
+
+	var s string
+	if t, ok := value.(time.Time); ok {
+		s = t.Format(time.RFC3339)
+	} else {
+		s = strings.Join(strings.Fields(fmt.Sprint(value)), " ")
+	}
+
+	if runes := []rune(s); len(runes) > digestMaxValueLength {
+		s = string(runes[:digestMaxValueLength]) + "..."
+	}
+	return s
+}
@@ -0,0 +1,11 @@
+// Package execution defines errors related to query execution.
+package execution
+
+import "errors"
+
+// Sentinel errors returned by the execution service.
+var (
+	// ErrCommitFailed is returned when the changes of a preview couldn't be committed
+	// (nothing was changed): the query itself ran fine
+	ErrCommitFailed = errors.New("failed to commit")
+)
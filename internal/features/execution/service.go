@@ -5,18 +5,24 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/alessandrolattao/asqli/internal/features/undo"
 	"github.com/alessandrolattao/asqli/internal/infrastructure/database"
 )
 
 // Service handles SQL query execution
 type Service struct {
 	conn *database.Connection
+
+	// Undo snapshots of committed previews (nil disables them)
+	snapshots *undo.Store
 }
 
-// NewService creates a new execution service
-func NewService(conn *database.Connection) *Service {
+// NewService creates a new execution service. Committed previews of updates and deletes
+// save an undo snapshot in snapshots, unless it is nil.
+func NewService(conn *database.Connection, snapshots *undo.Store) *Service {
 	return &Service{
-		conn: conn,
+		conn:      conn,
+		snapshots: snapshots,
 	}
 }
 
@@ -34,3 +40,79 @@ func (s *Service) Execute(ctx context.Context, query string) (*Result, error) {
 		Columns: columns,
 	}, nil
 }
+
+// Preview executes a data-modifying query in a transaction left open and returns its impact,
+// with up to sampleRows of the changed rows. Queries that can't be previewed (see
+// database.Connection.DryRun) return database.ErrNoDryRun.
+func (s *Service) Preview(ctx context.Context, query string, sampleRows int) (*Preview, error) {
+	dryRun, err := s.conn.DryRun(ctx, query, sampleRows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	preview := &Preview{
+		RowsAffected: dryRun.RowsAffected,
+		Before:       dryRun.Before,
+		Approximate:  dryRun.Approximate,
+		dryRun:       dryRun,
+	}
+	if len(dryRun.Columns) > 0 {
+		preview.Sample = &Result{Rows: dryRun.Rows, Columns: dryRun.Columns}
+	}
+
+	switch snapshot := dryRun.Snapshot; {
+	case s.snapshots == nil:
+	case snapshot != nil && len(snapshot.Rows) > 0:
+		preview.Undoable = true
+		preview.Undo = fmt.Sprintf("undo snapshot of %d rows of %s, saved on commit", len(snapshot.Rows), snapshot.Table)
+	case dryRun.SnapshotErr != nil:
+		preview.Undo = dryRun.SnapshotErr.Error()
+	}
+	return preview, nil
+}
+
+// Commit applies the changes of a preview and returns the result of the query: the rows
+// it returned, if any, and the number of rows it changed. The undo snapshot of the preview
+// is saved first: when it can't be, the changes are rolled back.
+func (s *Service) Commit(preview *Preview) (*Result, error) {
+	var snapshotPath string
+	if preview.Undoable {
+		path, err := s.snapshots.Save(preview.dryRun.Snapshot)
+		if err != nil {
+			_ = preview.dryRun.Rollback()
+			return nil, fmt.Errorf("%w: %w, nothing was changed", ErrCommitFailed, err)
+		}
+		snapshotPath = path
+	}
+
+	if err := preview.dryRun.Commit(); err != nil {
+		if snapshotPath != "" {
+			_ = s.snapshots.Discard(snapshotPath)
+		}
+		return nil, fmt.Errorf("%w: %w", ErrCommitFailed, err)
+	}
+
+	result := &Result{RowsAffected: preview.RowsAffected}
+	if preview.Sample != nil && !preview.Before {
+		result.Rows = preview.Sample.Rows
+		result.Columns = preview.Sample.Columns
+	}
+	return result, nil
+}
+
+// Undo restores the rows changed by the last committed preview with an undo snapshot
+// (see undo.Store.Undo)
+func (s *Service) Undo(ctx context.Context) (*undo.Restore, error) {
+	if s.snapshots == nil {
+		return nil, undo.ErrNothingToUndo
+	}
+	return s.snapshots.Undo(ctx)
+}
+
+// Rollback discards the changes of a preview
+func (s *Service) Rollback(preview *Preview) error {
+	if err := preview.dryRun.Rollback(); err != nil {
+		return fmt.Errorf("failed to roll back: %w", err)
+	}
+	return nil
+}
@@ -1,5 +1,7 @@
 package execution
 
+import "github.com/alessandrolattao/asqli/internal/infrastructure/database"
+
 // Result represents the result of a query execution
 type Result struct {
 	// Rows contains the query result data
@@ -7,4 +9,33 @@ type Result struct {
 
 	// Columns contains the column names
 	Columns []string
+
+	// RowsAffected is the number of rows a committed preview inserted, updated or deleted
+	// (0 for other results, whose row count is len(Rows))
+	RowsAffected int64
+}
+
+// Preview is the impact of a data-modifying query executed in a transaction that is still
+// open: Commit applies the changes, Rollback discards them
+type Preview struct {
+	// Rows the query inserted, updated or deleted (-1 when unknown)
+	RowsAffected int64
+
+	// Sample of the changed rows (nil when the database can't tell which rows they are)
+	Sample *Result
+
+	// Sample shows the rows before the change rather than as the query left them
+	Before bool
+
+	// Sample may show other rows than the query changes (a LIMIT without ORDER BY)
+	Approximate bool
+
+	// Committing saves an undo snapshot of the rows an update or delete changes
+	Undoable bool
+
+	// Describes the undo snapshot of an update or delete, or why there is none
+	// (empty for other queries)
+	Undo string
+
+	dryRun *database.DryRun
 }
@@ -0,0 +1,20 @@
+// Package explore defines errors related to the schema exploration tools.
+package explore
+
+import "errors"
+
+// Sentinel errors returned by the exploration tools. They are reported to the model,
+// which can correct the call and try again.
+var (
+	// ErrUnknownTool is returned when the model calls a tool that does not exist
+	ErrUnknownTool = errors.New("unknown tool")
+
+	// ErrInvalidArguments is returned when the tool arguments can't be decoded
+	ErrInvalidArguments = errors.New("invalid tool arguments")
+
+	// ErrUnknownTable is returned when a table does not exist in the database
+	ErrUnknownTable = errors.New("unknown table")
+
+	// ErrUnknownColumn is returned when a column does not exist in the table
+	ErrUnknownColumn = errors.New("unknown column")
+)
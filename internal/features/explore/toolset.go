@@ -0,0 +1,228 @@
+// Package explore provides read-only tools that let AI providers inspect the database
+// before writing SQL.
+package explore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/alessandrolattao/asqli/internal/features/execution"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/database"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/database/adapters"
+)
+
+const (
+	// DefaultSampleRows is how many rows sample_rows returns when the model doesn't ask for a number
+	DefaultSampleRows = 5
+
+	// MaxSampleRows caps the rows returned by sample_rows
+	MaxSampleRows = 20
+)
+
+// Tool names offered to the model
+const (
+	ListTables    = "list_tables"
+	DescribeTable = "describe_table"
+	SampleRows    = "sample_rows"
+	CountDistinct = "count_distinct"
+)
+
+// Toolset lets the model list tables, describe them and look at their data.
+// Every query runs in a read-only transaction and returns a capped number of rows,
+// so the model can't modify the database or pull large result sets (implements ai.Toolset).
+type Toolset struct {
+	conn *database.Connection
+}
+
+// NewToolset creates the exploration toolset for a database connection
+func NewToolset(conn *database.Connection) *Toolset {
+	return &Toolset{conn: conn}
+}
+
+// Tools returns the exploration tools
+func (t *Toolset) Tools() []ai.Tool {
+	table := map[string]any{
+		"type":        "string",
+		"description": "Table name, as returned by list_tables",
+	}
+
+	return []ai.Tool{
+		{
+			Name:        ListTables,
+			Description: "List the names of all tables in the database.",
+		},
+		{
+			Name:        DescribeTable,
+			Description: "Describe a table: its columns with their types, nullability and defaults, and its constraints (primary and foreign keys).",
+			Properties:  map[string]any{"table": table},
+			Required:    []string{"table"},
+		},
+		{
+			Name:        SampleRows,
+			Description: fmt.Sprintf("Return a few rows of a table to see what its data looks like (at most %d rows).", MaxSampleRows),
+			Properties: map[string]any{
+				"table": table,
+				"limit": map[string]any{
+					"type":        "integer",
+					"description": fmt.Sprintf("Number of rows to return (default %d, at most %d)", DefaultSampleRows, MaxSampleRows),
+				},
+			},
+			Required: []string{"table"},
+		},
+		{
+			Name:        CountDistinct,
+			Description: "Count the distinct values of a column and the total rows of its table, e.g. to decide whether a column is a category or an identifier.",
+			Properties: map[string]any{
+				"table":  table,
+				"column": map[string]any{"type": "string", "description": "Column name, as returned by describe_table"},
+			},
+			Required: []string{"table", "column"},
+		},
+	}
+}
+
+// arguments holds the arguments of every exploration tool
+type arguments struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	Limit  int    `json:"limit"`
+}
+
+// Call runs an exploration tool and returns its result as text
+func (t *Toolset) Call(ctx context.Context, name string, raw json.RawMessage) (string, error) {
+	var args arguments
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidArguments, err)
+		}
+	}
+
+	switch name {
+	case ListTables:
+		return t.listTables(ctx)
+	case DescribeTable:
+		return t.describeTable(ctx, args.Table)
+	case SampleRows:
+		return t.sampleRows(ctx, args.Table, args.Limit)
+	case CountDistinct:
+		return t.countDistinct(ctx, args.Table, args.Column)
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownTool, name)
+	}
+}
+
+// listTables lists the table names, one per line
+func (t *Toolset) listTables(ctx context.Context) (string, error) {
+	tables, err := t.conn.GetTableNames(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(tables) == 0 {
+		return "The database has no tables.", nil
+	}
+
+	return strings.Join(tables, "\n"), nil
+}
+
+// describeTable formats the table definition the same way as the schema sent with prompts
+func (t *Toolset) describeTable(ctx context.Context, table string) (string, error) {
+	definition, err := t.definition(ctx, table)
+	if err != nil {
+		return "", err
+	}
+
+	return adapters.FormatTableDefinition(definition), nil
+}
+
+// sampleRows returns up to limit rows of the table as a result digest
+func (t *Toolset) sampleRows(ctx context.Context, table string, limit int) (string, error) {
+	table, err := t.table(ctx, table)
+	if err != nil {
+		return "", err
+	}
+
+	if limit <= 0 {
+		limit = DefaultSampleRows
+	}
+	limit = min(limit, MaxSampleRows)
+
+	query := fmt.Sprintf("SELECT * FROM %s LIMIT %d", t.conn.QuoteIdentifier(table), limit)
+	rows, columns, err := t.conn.QueryReadOnly(ctx, query, limit)
+	if err != nil {
+		return "", err
+	}
+	if len(rows) == 0 {
+		return fmt.Sprintf("Table %s is empty.", table), nil
+	}
+
+	result := &execution.Result{Rows: rows, Columns: columns}
+	return result.Digest(limit), nil
+}
+
+// countDistinct counts the distinct values of a column and the rows of its table
+func (t *Toolset) countDistinct(ctx context.Context, table, column string) (string, error) {
+	definition, err := t.definition(ctx, table)
+	if err != nil {
+		return "", err
+	}
+
+	found := ""
+	for _, col := range definition.Columns {
+		if col.Name == column || (found == "" && strings.EqualFold(col.Name, column)) {
+			found = col.Name
+		}
+	}
+	if found == "" {
+		return "", fmt.Errorf("%w: %s.%s", ErrUnknownColumn, definition.Name, column)
+	}
+
+	query := fmt.Sprintf("SELECT COUNT(DISTINCT %s) AS distinct_values, COUNT(*) AS total_rows FROM %s",
+		t.conn.QuoteIdentifier(found), t.conn.QuoteIdentifier(definition.Name))
+	rows, _, err := t.conn.QueryReadOnly(ctx, query, 1)
+	if err != nil {
+		return "", err
+	}
+	if len(rows) == 0 {
+		return "", fmt.Errorf("no result counting %s.%s", definition.Name, found)
+	}
+
+	return fmt.Sprintf("%s.%s: %v distinct values in %v rows",
+		definition.Name, found, rows[0]["distinct_values"], rows[0]["total_rows"]), nil
+}
+
+// definition returns the definition of an existing table
+func (t *Toolset) definition(ctx context.Context, table string) (*adapters.TableDefinition, error) {
+	table, err := t.table(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.conn.GetTableDefinition(ctx, table)
+}
+
+// table returns the name of an existing table matching name, case-insensitively when
+// there is no exact match. Only names listed by the database reach generated SQL.
+func (t *Toolset) table(ctx context.Context, name string) (string, error) {
+	tables, err := t.conn.GetTableNames(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	found := ""
+	for _, table := range tables {
+		if table == name {
+			return table, nil
+		}
+		if found == "" && strings.EqualFold(table, name) {
+			found = table
+		}
+	}
+	if found == "" {
+		return "", fmt.Errorf("%w: %q (call list_tables for the table names)", ErrUnknownTable, name)
+	}
+
+	return found, nil
+}
@@ -0,0 +1,37 @@
+package query
+
+import "github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+
+const (
+	// reservedResponseTokens is kept free in the context window for the response
+	// (at most a quarter of the window on small models)
+	reservedResponseTokens = 4096
+
+	// systemPromptTokens approximates the instructions and rules of the system prompt,
+	// which the providers add to every request
+	systemPromptTokens = 1000
+
+	// historyShare limits conversation turns to 1/historyShare of the request budget
+	// when the request doesn't fit the context window
+	historyShare = 4
+)
+
+// requestBudget returns the tokens available to the prompt, its context, the conversation
+// and the schema in the model's context window (0 = no limit)
+func (s *Service) requestBudget() int {
+	if s.limits.ContextWindow <= 0 {
+		return 0
+	}
+
+	reserved := min(reservedResponseTokens, s.limits.ContextWindow/4) + systemPromptTokens
+	return max(s.limits.ContextWindow-reserved, 1)
+}
+
+// countMessages estimates the tokens of conversation turns
+func (s *Service) countMessages(messages []ai.Message) int {
+	tokens := 0
+	for _, message := range messages {
+		tokens += s.limits.CountTokens(message.Content)
+	}
+	return tokens
+}
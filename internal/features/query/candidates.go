@@ -0,0 +1,215 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/alessandrolattao/asqli/internal/features/cost"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/database"
+)
+
+const (
+	// candidateTemperatureStep sets the sampling temperature of every candidate after the
+	// first one, which keeps the configured temperature: candidate i is sampled at i times
+	// the step, whatever the configured temperature
+	candidateTemperatureStep = 0.3
+
+	// maxCandidateTemperature caps the temperature of varied candidates
+	maxCandidateTemperature = 1.0
+)
+
+// Planner plans SQL against the live database without executing it
+// (implemented by *database.Connection)
+type Planner interface {
+	Explain(ctx context.Context, query string) (*database.QueryPlan, error)
+}
+
+// Candidate is one of several queries generated for the same prompt
+type Candidate struct {
+	// Generated SQL query (empty when generation failed)
+	Query string
+
+	// Plan chosen by the database (nil when the query was not planned)
+	Plan *database.QueryPlan
+
+	// Number of usable candidates with the same query, including this one
+	Votes int
+
+	// Why the candidate was discarded (generation, validation, schema or planning error), nil if usable
+	Err error
+}
+
+// generateCandidates asks the provider for several candidate queries in parallel, each with
+// a higher temperature, and discards the ones that fail validation or don't plan with EXPLAIN.
+// The winner is the query most candidates agree on, then the one with the lowest estimated
+// cost. Confidence is the share of candidates agreeing with the winner (self-consistency).
+// A clarifying question is only returned when no candidate produced usable SQL.
+// Only the first candidate is streamed to onChunk, so the preview shows one coherent response.
+func (s *Service) generateCandidates(ctx context.Context, aiReq *ai.GenerateRequest, onChunk ai.StreamHandler) (*SQL, error) {
+	responses := make([]*ai.GenerateResponse, s.candidates)
+	charges := make([]cost.Charge, s.candidates)
+	candidates := make([]Candidate, s.candidates)
+
+	var wg sync.WaitGroup
+	for i := range s.candidates {
+		wg.Go(func() {
+			req := *aiReq
+			var stream ai.StreamHandler
+			if i == 0 {
+				stream = onChunk
+			} else {
+				temperature := min(float64(i)*candidateTemperatureStep, maxCandidateTemperature)
+				req.Temperature = &temperature
+			}
+
+			resp, charge, err := s.generate(ctx, &req, stream)
+			if err != nil {
+				candidates[i].Err = err
+				return
+			}
+			responses[i] = resp
+			charges[i] = charge
+			if s.clarification(resp) != "" {
+				candidates[i].Err = ErrNeedsClarification
+				return
+			}
+			candidates[i].Query = resp.Query
+			candidates[i].Err = s.plan(ctx, &candidates[i])
+		})
+	}
+	wg.Wait()
+
+	// Count agreement between usable candidates
+	votes := make(map[string]int)
+	for _, c := range candidates {
+		if c.Err == nil {
+			votes[normalizeQuery(c.Query)]++
+		}
+	}
+
+	winner := -1
+	for i := range candidates {
+		c := &candidates[i]
+		if c.Err != nil {
+			continue
+		}
+		c.Votes = votes[normalizeQuery(c.Query)]
+		if winner < 0 || betterCandidate(c, &candidates[winner]) {
+			winner = i
+		}
+	}
+
+	confidence := 0.0
+	clarification := ""
+	if winner >= 0 {
+		confidence = float64(candidates[winner].Votes) / float64(len(candidates))
+	} else {
+		// No usable candidate: ask the first question a candidate asked, if any
+		for i, c := range candidates {
+			if errors.Is(c.Err, ErrNeedsClarification) {
+				winner = i
+				clarification = s.clarification(responses[i])
+				break
+			}
+		}
+	}
+	if winner < 0 {
+		// No candidate planned: return the first valid one anyway, so that
+		// executing it surfaces the database error to the repair loop
+		for i, c := range candidates {
+			var validationErr *ValidationError
+			if responses[i] != nil && !errors.As(c.Err, &validationErr) && !errors.Is(c.Err, ErrSchemaMismatch) {
+				winner = i
+				break
+			}
+		}
+	}
+	if winner < 0 {
+		// Every candidate failed to generate, validate or match the schema
+		return nil, candidates[0].Err
+	}
+
+	resp := responses[winner]
+	sql := &SQL{
+		Query:         resp.Query,
+		Explanation:   resp.Explanation,
+		Confidence:    confidence,
+		Assumptions:   resp.Assumptions,
+		Reasoning:     resp.Reasoning,
+		Usage:         resp.Usage,
+		Cost:          cost.Charge{Priced: true},
+		Candidates:    candidates,
+		Clarification: clarification,
+		ToolCalls:     resp.ToolCalls,
+	}
+	if clarification != "" {
+		sql.Query, sql.Explanation, sql.Assumptions = "", "", nil
+	}
+
+	// Every candidate was paid for: report the combined usage and cost
+	sql.Usage.PromptTokens, sql.Usage.ResponseTokens, sql.Usage.TotalTokens = 0, 0, 0
+	sql.Usage.CachedTokens, sql.Usage.CacheWriteTokens = 0, 0
+	for i, r := range responses {
+		if r == nil {
+			continue
+		}
+		sql.Usage.Add(r.Usage)
+		sql.Cost.Cost += charges[i].Cost
+		sql.Cost.Priced = sql.Cost.Priced && charges[i].Priced
+	}
+
+	return sql, nil
+}
+
+// plan validates the candidate's query, checks it against the schema and plans it with EXPLAIN,
+// returning why it is unusable.
+// Queries the database can't explain (e.g. DDL) are kept without a plan.
+func (s *Service) plan(ctx context.Context, c *Candidate) error {
+	if err := s.Validate(c.Query); err != nil {
+		return &ValidationError{
+			Query: c.Query,
+			Err:   err,
+		}
+	}
+	if err := s.checkSchema(ctx, c.Query); err != nil {
+		return err
+	}
+
+	if s.planner == nil {
+		return nil
+	}
+
+	plan, err := s.planner.Explain(ctx, c.Query)
+	if errors.Is(err, database.ErrNotExplainable) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to plan query: %w", err)
+	}
+
+	c.Plan = plan
+	return nil
+}
+
+// betterCandidate reports whether c should win over best: more votes first,
+// then the lower estimated cost when both plans report one
+func betterCandidate(c, best *Candidate) bool {
+	if c.Votes != best.Votes {
+		return c.Votes > best.Votes
+	}
+	if c.Plan != nil && c.Plan.HasCost && best.Plan != nil && best.Plan.HasCost {
+		return c.Plan.Cost < best.Plan.Cost
+	}
+	return false
+}
+
+// normalizeQuery returns the query with whitespace collapsed and without a trailing
+// semicolon, so that formatting differences don't split the vote
+func normalizeQuery(query string) string {
+	query = strings.TrimSuffix(strings.TrimSpace(query), ";")
+	return strings.Join(strings.Fields(query), " ")
+}
@@ -0,0 +1,84 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+)
+
+const (
+	// maxSummaryTurns is the number of older turns listed in the conversation summary
+	maxSummaryTurns = 20
+
+	// maxSummarySQLLength truncates the SQL quoted for each summarized turn
+	maxSummarySQLLength = 160
+)
+
+// buildConversation converts the query history into conversation turns for the provider.
+// Clarifying questions asked about a prompt become turns of their own, between the prompt and its SQL.
+// The most recent turns are kept verbatim as long as they fit in tokenWindow (the latest
+// turn always is); older turns are condensed into an extractive summary instead of being
+// dropped, so long sessions keep their thread. A tokenWindow of 0 keeps every turn.
+// Tokens are counted with countTokens.
+func buildConversation(history []History, tokenWindow int, countTokens func(string) int) ([]ai.Message, string) {
+	// Walk back from the newest turn until the window is full
+	start := len(history)
+	tokens := 0
+	for start > 0 {
+		turn := history[start-1]
+		turnTokens := countTokens(turn.Prompt) + countTokens(turn.SQL)
+		for _, c := range turn.Clarifications {
+			turnTokens += countTokens(c.Question) + countTokens(c.Answer)
+		}
+		if tokenWindow > 0 && start < len(history) && tokens+turnTokens > tokenWindow {
+			break
+		}
+		tokens += turnTokens
+		start--
+	}
+
+	messages := make([]ai.Message, 0, 2*(len(history)-start))
+	for _, turn := range history[start:] {
+		messages = append(messages, ai.Message{Role: ai.RoleUser, Content: turn.Prompt})
+		for _, c := range turn.Clarifications {
+			messages = append(messages,
+				ai.Message{Role: ai.RoleAssistant, Content: c.Question},
+				ai.Message{Role: ai.RoleUser, Content: c.Answer},
+			)
+		}
+		messages = append(messages, ai.Message{Role: ai.RoleAssistant, Content: turn.SQL})
+	}
+
+	return messages, summarizeTurns(history[:start])
+}
+
+// summarizeTurns returns an extractive summary of older conversation turns: every
+// request with its truncated SQL. Beyond maxSummaryTurns, the oldest turns are omitted.
+func summarizeTurns(turns []History) string {
+	if len(turns) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Summary of earlier conversation turns (oldest first), preceding the conversation messages:\n")
+
+	omitted := max(len(turns)-maxSummaryTurns, 0)
+	if omitted > 0 {
+		sb.WriteString(fmt.Sprintf("- (%d earlier requests omitted)\n", omitted))
+	}
+	for _, turn := range turns[omitted:] {
+		sql := strings.Join(strings.Fields(turn.SQL), " ")
+		if runes := []rune(sql); len(runes) > maxSummarySQLLength {
+			sql = string(runes[:maxSummarySQLLength]) + "..."
+		}
+		request := turn.Prompt
+		for _, c := range turn.Clarifications {
+			request += " (clarified: " + c.Answer + ")"
+		}
+		sb.WriteString(fmt.Sprintf("- User asked: \"%s\" -> %s\n", request, sql))
+	}
+	sb.WriteString("\n")
+
+	return sb.String()
+}
@@ -4,6 +4,7 @@ package query
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 // Sentinel errors returned by the query service.
@@ -11,8 +12,20 @@ var (
 	// ErrEmptyPrompt is returned when a prompt is empty
 	ErrEmptyPrompt = errors.New("prompt cannot be empty")
 
+	// ErrEmptyQuery is returned when there is no SQL query to explain
+	ErrEmptyQuery = errors.New("query cannot be empty")
+
 	// ErrInvalidSQL is returned when generated SQL is invalid
 	ErrInvalidSQL = errors.New("invalid SQL query")
+
+	// ErrContextWindowExceeded is returned when the request can't be cut down to fit the model's context window
+	ErrContextWindowExceeded = errors.New("request exceeds the model's context window")
+
+	// ErrNeedsClarification marks a candidate that asked the user a question instead of generating SQL
+	ErrNeedsClarification = errors.New("asked for clarification")
+
+	// ErrSchemaMismatch is returned when generated SQL names tables or columns the database doesn't have
+	ErrSchemaMismatch = errors.New("SQL doesn't match the schema")
 )
 
 // ValidationError is returned when SQL validation fails.
@@ -29,3 +42,19 @@ func (e *ValidationError) Error() string {
 func (e *ValidationError) Unwrap() error {
 	return e.Err
 }
+
+// SchemaError is returned when generated SQL names tables or columns the database doesn't have.
+// It includes the query and a diagnostic for every unknown name, e.g.
+// "column users.fullname does not exist; did you mean first_name/last_name?".
+type SchemaError struct {
+	Query    string
+	Problems []string
+}
+
+func (e *SchemaError) Error() string {
+	return strings.Join(e.Problems, " · ")
+}
+
+func (e *SchemaError) Unwrap() error {
+	return ErrSchemaMismatch
+}
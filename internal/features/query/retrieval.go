@@ -0,0 +1,36 @@
+package query
+
+import (
+	"context"
+	"strings"
+
+	"github.com/alessandrolattao/asqli/internal/features/schema"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/database/adapters"
+)
+
+// SchemaRetriever selects the tables relevant to a prompt on databases too large to send
+// whole, cut down to maxTokens tokens as counted by countTokens (0 = no limit). Relevant
+// returns nil when the full schema should be sent. Tables returns the definitions of every
+// table, which generated SQL is checked against (implemented by *schema.Service).
+type SchemaRetriever interface {
+	Relevant(ctx context.Context, text string, maxTokens int, countTokens func(string) int) (*schema.Selection, error)
+	Tables(ctx context.Context) ([]*adapters.TableDefinition, error)
+}
+
+// retrievalText returns the text tables are matched against: the prompt, the answers to
+// clarifying questions, failed attempts and the previous request with its SQL, so that
+// follow-ups like "only the last 10" keep the tables they build on
+func retrievalText(req *Request) string {
+	parts := []string{req.Prompt}
+	for _, c := range req.Clarifications {
+		parts = append(parts, c.Question, c.Answer)
+	}
+	for _, attempt := range req.FailedAttempts {
+		parts = append(parts, attempt.SQL)
+	}
+	if len(req.History) > 0 {
+		last := req.History[len(req.History)-1]
+		parts = append(parts, last.Prompt, last.SQL)
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,256 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/alessandrolattao/asqli/internal/infrastructure/database/adapters"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/sqlparse"
+)
+
+// maxSuggestions is how many similar names a diagnostic suggests
+const maxSuggestions = 3
+
+// checkedStatements are the statement keywords whose tables and columns are checked
+var checkedStatements = []string{"SELECT", "WITH", "INSERT", "UPDATE", "DELETE", "REPLACE"}
+
+// pseudoColumns can be selected from tables without being part of their definition
+var pseudoColumns = []string{"rowid", "oid", "_rowid_", "ctid", "xmin", "xmax", "cmin", "cmax", "tableoid"}
+
+// checkSchema checks the tables and columns query names against the table definitions of
+// the database, without a round trip to it. It returns a *SchemaError with a diagnostic
+// for every unknown name, or nil. Names that can't be resolved with certainty (e.g. columns
+// of subqueries and CTEs) aren't checked, and neither is anything when the definitions
+// can't be loaded: executing the query has the last word.
+func (s *Service) checkSchema(ctx context.Context, query string) error {
+	if !s.schemaCheck || s.retriever == nil {
+		return nil
+	}
+
+	definitions, err := s.retriever.Tables(ctx)
+	if err != nil || len(definitions) == 0 {
+		return nil
+	}
+	statements, err := sqlparse.Parse(query, s.dialect)
+	if err != nil {
+		return nil
+	}
+
+	tables := make(map[string]*adapters.TableDefinition, len(definitions))
+	for _, definition := range definitions {
+		tables[strings.ToLower(definition.Name)] = definition
+	}
+
+	var problems []string
+	for _, statement := range statements {
+		if !slices.Contains(checkedStatements, statement.Keyword) {
+			continue
+		}
+		for _, problem := range checkReferences(statement.References(), tables) {
+			if !slices.Contains(problems, problem) {
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return &SchemaError{Query: query, Problems: problems}
+}
+
+// checkReferences returns a diagnostic for every table and column of refs missing from
+// tables (keyed by lower-case name)
+func checkReferences(refs sqlparse.References, tables map[string]*adapters.TableDefinition) []string {
+	var problems []string
+
+	// Row sources by the names that qualify their columns (tables and aliases);
+	// nil for sources whose columns aren't known
+	sources := make(map[string]*adapters.TableDefinition)
+	for _, derived := range refs.Derived {
+		sources[strings.ToLower(derived)] = nil
+	}
+
+	// Tables of the statement, and whether some of its row sources aren't known tables,
+	// so that unqualified columns may come from elsewhere
+	var known []*adapters.TableDefinition
+	opaque := len(refs.Derived) > 0
+
+	for _, ref := range refs.Tables {
+		name := strings.ToLower(ref.Name)
+		table := tables[name]
+		_, derived := sources[name]
+		switch {
+		case ref.Schema != "" || derived || isSystemTable(name):
+			// Tables of other schemas, CTEs and system catalogs
+			table = nil
+		case table == nil:
+			problems = append(problems, fmt.Sprintf("table %s does not exist%s", ref.Name, didYouMean(ref.Name, tableNames(tables))))
+		}
+
+		if table == nil {
+			opaque = true
+		} else if !slices.Contains(known, table) {
+			known = append(known, table)
+		}
+		if !derived {
+			sources[name] = table
+		}
+		if ref.Alias != "" {
+			sources[strings.ToLower(ref.Alias)] = table
+		}
+	}
+
+	for _, column := range refs.Columns {
+		name := strings.ToLower(column.Name)
+		if slices.Contains(pseudoColumns, name) {
+			continue
+		}
+
+		if column.Qualifier != "" {
+			table := sources[strings.ToLower(column.Qualifier)]
+			if table != nil && !hasColumn(table, name) {
+				problems = append(problems, fmt.Sprintf("column %s.%s does not exist%s", table.Name, column.Name, didYouMean(column.Name, columnNames(table, false))))
+			}
+			continue
+		}
+
+		// Unqualified names may refer to aliases, or to whole rows by table name
+		if _, source := sources[name]; opaque || source || len(known) == 0 || slices.ContainsFunc(refs.Aliases, func(alias string) bool {
+			return strings.EqualFold(alias, name)
+		}) {
+			continue
+		}
+		if slices.ContainsFunc(known, func(table *adapters.TableDefinition) bool { return hasColumn(table, name) }) {
+			continue
+		}
+
+		if len(known) == 1 {
+			problems = append(problems, fmt.Sprintf("column %s.%s does not exist%s", known[0].Name, column.Name, didYouMean(column.Name, columnNames(known[0], false))))
+			continue
+		}
+		var names, candidates []string
+		for _, table := range known {
+			names = append(names, table.Name)
+			candidates = append(candidates, columnNames(table, true)...)
+		}
+		problems = append(problems, fmt.Sprintf("column %s does not exist in %s%s", column.Name, strings.Join(names, ", "), didYouMean(column.Name, candidates)))
+	}
+
+	return problems
+}
+
+// isSystemTable reports whether a table is a system catalog or the MySQL DUAL table,
+// which aren't in the schema but can be queried unqualified
+func isSystemTable(name string) bool {
+	return name == "dual" || strings.HasPrefix(name, "pg_") || strings.HasPrefix(name, "sqlite_")
+}
+
+// hasColumn reports whether table has a column named name (case-insensitive)
+func hasColumn(table *adapters.TableDefinition, name string) bool {
+	return slices.ContainsFunc(table.Columns, func(column adapters.ColumnDefinition) bool {
+		return strings.EqualFold(column.Name, name)
+	})
+}
+
+// tableNames returns the names of tables, sorted
+func tableNames(tables map[string]*adapters.TableDefinition) []string {
+	names := make([]string, 0, len(tables))
+	for _, table := range tables {
+		names = append(names, table.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// columnNames returns the column names of table, qualified with the table name if requested
+func columnNames(table *adapters.TableDefinition, qualified bool) []string {
+	names := make([]string, 0, len(table.Columns))
+	for _, column := range table.Columns {
+		if qualified {
+			names = append(names, table.Name+"."+column.Name)
+		} else {
+			names = append(names, column.Name)
+		}
+	}
+	return names
+}
+
+// didYouMean returns the suggestion part of a diagnostic ("; did you mean first_name/last_name?"),
+// empty when no candidate looks like name
+func didYouMean(name string, candidates []string) string {
+	suggestions := suggest(name, candidates)
+	if len(suggestions) == 0 {
+		return ""
+	}
+	return "; did you mean " + strings.Join(suggestions, "/") + "?"
+}
+
+// suggest returns up to maxSuggestions candidates that look like a misspelling or variant of
+// name, closest first: a few edits away (user and users), or sharing a word with it (fullname
+// and first_name). Qualified candidates (table.column) are compared by their last part.
+func suggest(name string, candidates []string) []string {
+	type match struct {
+		candidate string
+		distance  int
+	}
+
+	name = strings.ToLower(name)
+	var matches []match
+	for _, candidate := range candidates {
+		base := strings.ToLower(candidate[strings.LastIndex(candidate, ".")+1:])
+		distance := editDistance(name, base)
+		if distance <= max(1, len(name)/3) || sharesWord(name, base) || sharesWord(base, name) {
+			matches = append(matches, match{candidate: candidate, distance: distance})
+		}
+	}
+
+	slices.SortStableFunc(matches, func(a, b match) int { return a.distance - b.distance })
+
+	var suggestions []string
+	for _, m := range matches[:min(len(matches), maxSuggestions)] {
+		suggestions = append(suggestions, m.candidate)
+	}
+	return suggestions
+}
+
+// sharesWord reports whether name contains one of the words (3 letters or more) of the
+// underscore-separated identifier other
+func sharesWord(name, other string) bool {
+	for _, word := range strings.Split(other, "_") {
+		if len(word) >= 3 && strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// editDistance returns the edit distance between a and b: insertions, deletions,
+// substitutions and transpositions of adjacent letters (emial and email) count as one edit
+func editDistance(a, b string) int {
+	rows := make([][]int, len(a)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(b)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			substitution := rows[i-1][j-1]
+			if a[i-1] != b[j-1] {
+				substitution++
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, substitution)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return rows[len(a)][len(b)]
+}
@@ -4,77 +4,185 @@ package query
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/alessandrolattao/asqli/internal/features/cost"
 	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/config"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/database/adapters"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/sqlparse"
 )
 
 // History represents a previous query execution with prompt and SQL
 type History struct {
-	Prompt string // User's natural language prompt
-	SQL    string // Generated/executed SQL query
+	Prompt         string          // User's natural language prompt
+	Clarifications []Clarification // Questions asked about the prompt before the SQL, in order
+	SQL            string          // Generated/executed SQL query
+}
+
+// Clarification is a question the provider asked about an ambiguous prompt and the user's answer
+type Clarification struct {
+	Question string // Question asked by the provider
+	Answer   string // User's answer
+}
+
+// Attempt represents generated SQL that failed when executed against the database
+type Attempt struct {
+	SQL   string // Generated SQL query that failed
+	Error string // Database error returned for the query
 }
 
 // Service handles SQL query generation from natural language
 type Service struct {
-	aiProvider ai.Provider
+	aiProvider  ai.Provider
+	costTracker *cost.Tracker
+	planner     Planner
+	toolset     ai.Toolset
+	retriever   SchemaRetriever
+
+	// SQL dialect of the database, for validating generated SQL and classifying queries
+	dialect adapters.DriverType
+
+	// Context window and tokenizer density of the provider's model
+	limits ai.ModelLimits
+
+	// Generation settings
+	schemaCheck        bool
+	candidates         int
+	conversationTokens int
+	maxClarifications  int
 }
 
-// NewService creates a new query generation service
-func NewService(aiProvider ai.Provider) *Service {
+// NewService creates a new query generation service.
+// Every AI request is charged to costTracker, which may be nil to disable accounting.
+// When generation.Candidates is above 1, every prompt generates that many candidate queries,
+// which are checked with EXPLAIN through planner (nil skips planning) before one is selected.
+// The provider may call the tools of toolset to inspect the database first (nil offers no tools).
+// On large databases, retriever replaces Request.Schema with the tables relevant to the prompt,
+// and it cuts the schema down when the request doesn't fit generation.ContextWindow
+// (nil always sends Request.Schema). Queries are validated and classified in the SQL dialect
+// of the database, dialect; with generation.SchemaCheck, the tables and columns of generated
+// queries are checked against the table definitions of retriever as well.
+func NewService(aiProvider ai.Provider, costTracker *cost.Tracker, planner Planner, toolset ai.Toolset, retriever SchemaRetriever, dialect adapters.DriverType, generation config.GenerationConfig) *Service {
 	return &Service{
-		aiProvider: aiProvider,
+		aiProvider:         aiProvider,
+		costTracker:        costTracker,
+		planner:            planner,
+		toolset:            toolset,
+		retriever:          retriever,
+		dialect:            dialect,
+		limits:             ai.ModelLimits{ContextWindow: generation.ContextWindow, CharsPerToken: generation.CharsPerToken},
+		schemaCheck:        generation.SchemaCheck,
+		candidates:         generation.Candidates,
+		conversationTokens: generation.ConversationTokens,
+		maxClarifications:  generation.MaxClarifications,
 	}
 }
 
 // Generate generates a SQL query from a natural language prompt with contextual awareness.
 // It considers the database schema, query history, and currently selected table cell to generate
 // contextually relevant SQL queries that understand follow-up requests and references.
-func (s *Service) Generate(ctx context.Context, prompt, schema string, queryHistory []History, selectedColumn string, selectedValue any) (*SQL, error) {
+func (s *Service) Generate(ctx context.Context, req *Request) (*SQL, error) {
+	return s.GenerateStream(ctx, req, nil)
+}
+
+// GenerateStream works like Generate but streams the raw provider output to onChunk
+// while it is being produced. A nil onChunk performs a regular, non-streaming request.
+func (s *Service) GenerateStream(ctx context.Context, req *Request, onChunk ai.StreamHandler) (*SQL, error) {
 	// Validate input
-	if prompt == "" {
+	if req.Prompt == "" {
 		return nil, ErrEmptyPrompt
 	}
 
-	// Build context from previous queries and selected cell
-	var contextStr string
-	var sb strings.Builder
+	// Previous prompts and their SQL are sent as conversation turns; the ones
+	// that don't fit in the token window are summarized in the context instead
+	messages, summary := buildConversation(req.History, s.conversationTokens, s.limits.CountTokens)
+	contextStr := s.buildContext(req, summary)
 
-	// Add selected cell context if available
-	if selectedColumn != "" && selectedValue != nil {
-		sb.WriteString("Currently selected cell:\n")
-		sb.WriteString(fmt.Sprintf("Column: %s\n", selectedColumn))
-		sb.WriteString(fmt.Sprintf("Value: %v\n", selectedValue))
-		sb.WriteString("\nIf the user refers to 'selected', 'this', or similar terms, they likely mean this value.\n")
-		sb.WriteString("Use this information to filter or reference specific data in your query.\n\n")
+	// Fit the request in the model's context window: the prompt and its context are kept,
+	// more conversation turns are summarized, and the schema gets the tokens left
+	var notices []string
+	budget := s.requestBudget()
+	if historyBudget := budget / historyShare; budget > 0 && s.countMessages(messages) > historyBudget {
+		if fewer, shorter := buildConversation(req.History, historyBudget, s.limits.CountTokens); len(fewer) < len(messages) {
+			messages, summary = fewer, shorter
+			contextStr = s.buildContext(req, summary)
+			notices = append(notices, "older conversation turns summarized")
+		}
 	}
-
-	// Add previous queries context with both prompts and SQL
-	if len(queryHistory) > 0 {
-		sb.WriteString("Recent conversation history:\n")
-		sb.WriteString("The user has previously asked the following questions and received these SQL queries:\n\n")
-		for i, qh := range queryHistory {
-			sb.WriteString(fmt.Sprintf("%d. User asked: \"%s\"\n", i+1, qh.Prompt))
-			sb.WriteString(fmt.Sprintf("   Generated SQL: %s\n\n", qh.SQL))
+	schemaBudget := 0
+	if budget > 0 {
+		used := s.limits.CountTokens(req.Prompt) + s.limits.CountTokens(contextStr) + s.countMessages(messages)
+		schemaBudget = budget - used
+		if schemaBudget <= 0 {
+			return nil, fmt.Errorf("%w: the prompt and its context need about %d tokens, %d are available", ErrContextWindowExceeded, used, budget)
 		}
-		sb.WriteString("Use this conversation context to understand what the user is referring to.\n")
-		sb.WriteString("If the user's current request is a follow-up (e.g., \"show only the last 10\", \"filter by that user\", \"add a limit\"),\n")
-		sb.WriteString("base your query on the most recent SQL but apply the requested modification.\n\n")
 	}
 
-	contextStr = sb.String()
+	// The schema is cut down to the tables relevant to the prompt on very large databases,
+	// and to what fits in the budget
+	schemaStr := req.Schema
+	var schemaTables []string
+	schemaSelected := false
+	if s.retriever != nil {
+		selection, err := s.retriever.Relevant(ctx, retrievalText(req), schemaBudget, s.limits.CountTokens)
+		if err != nil {
+			return nil, fmt.Errorf("failed to select relevant tables: %w", err)
+		}
+		if selection != nil {
+			schemaStr = selection.Schema
+			schemaTables = selection.Tables
+			schemaSelected = true
+			notices = append(notices, selection.Notices...)
+		}
+	} else if schemaBudget > 0 && s.limits.CountTokens(schemaStr) > schemaBudget {
+		return nil, fmt.Errorf("%w: the schema needs about %d tokens, %d are available", ErrContextWindowExceeded, s.limits.CountTokens(schemaStr), schemaBudget)
+	}
 
 	// Create request for AI provider
-	req := &ai.GenerateRequest{
-		Prompt:  prompt,
-		Schema:  schema,
-		Context: contextStr,
+	aiReq := &ai.GenerateRequest{
+		Prompt:         req.Prompt,
+		Schema:         schemaStr,
+		SchemaSelected: schemaSelected,
+		Context:        contextStr,
+		DatabaseType:   string(s.dialect),
+		Messages:       messages,
+		Toolset:        s.toolset,
+	}
+
+	// Refuse to spend more once the budget is exhausted
+	if err := s.costTracker.Check(); err != nil {
+		return nil, err
+	}
+
+	// Generate and select between several candidates (self-consistency)
+	if s.candidates > 1 {
+		sql, err := s.generateCandidates(ctx, aiReq, onChunk)
+		if err != nil {
+			return nil, err
+		}
+		sql.SchemaTables = schemaTables
+		sql.Notices = notices
+		return sql, nil
 	}
 
-	// Generate SQL via AI provider
-	resp, err := s.aiProvider.GenerateSQL(ctx, req)
+	resp, charge, err := s.generate(ctx, aiReq, onChunk)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate SQL: %w", err)
+		return nil, err
+	}
+
+	// The provider may ask the user to clarify an ambiguous prompt instead
+	if question := s.clarification(resp); question != "" {
+		return &SQL{
+			Clarification: question,
+			Reasoning:     resp.Reasoning,
+			Usage:         resp.Usage,
+			Cost:          charge,
+			ToolCalls:     resp.ToolCalls,
+			SchemaTables:  schemaTables,
+			Notices:       notices,
+		}, nil
 	}
 
 	// Validate generated SQL
@@ -86,53 +194,212 @@ func (s *Service) Generate(ctx context.Context, prompt, schema string, queryHist
 		}
 	}
 
+	// Catch hallucinated tables and columns without a round trip to the database
+	if err := s.checkSchema(ctx, resp.Query); err != nil {
+		return nil, err
+	}
+
 	return &SQL{
-		Query:       resp.Query,
-		Explanation: resp.Explanation,
-		Usage:       resp.Usage,
+		Query:        resp.Query,
+		Explanation:  resp.Explanation,
+		Confidence:   resp.Confidence,
+		Assumptions:  resp.Assumptions,
+		Reasoning:    resp.Reasoning,
+		Usage:        resp.Usage,
+		Cost:         charge,
+		ToolCalls:    resp.ToolCalls,
+		SchemaTables: schemaTables,
+		Notices:      notices,
 	}, nil
 }
 
-// Validate validates a SQL query
-func (s *Service) Validate(query string) error {
-	trimmed := strings.TrimSpace(query)
+// buildContext builds the request context: the selected cell, the last result, read-only
+// sessions, the summary of older conversation turns, answers to clarifying questions and failed attempts
+func (s *Service) buildContext(req *Request, summary string) string {
+	var sb strings.Builder
 
-	if trimmed == "" {
-		return ErrInvalidSQL
+	// Add selected cell context if available
+	if req.SelectedColumn != "" && req.SelectedValue != nil {
+		sb.WriteString("Currently selected cell:\n")
+		sb.WriteString(fmt.Sprintf("Column: %s\n", req.SelectedColumn))
+		sb.WriteString(fmt.Sprintf("Value: %v\n", req.SelectedValue))
+		sb.WriteString("\nIf the user refers to 'selected', 'this', or similar terms, they likely mean this value.\n")
+		sb.WriteString("Use this information to filter or reference specific data in your query.\n\n")
+	}
+
+	// Add a digest of what the previous query returned
+	if req.LastResult != "" {
+		sb.WriteString("Result of the most recent query, as shown to the user:\n")
+		sb.WriteString(req.LastResult)
+		sb.WriteString("\nIf the user refers to 'those', 'these rows', or asks about values in this result,\n")
+		sb.WriteString("they mean this result set: build on the most recent SQL to answer.\n\n")
+	}
+
+	// Writes would be refused by the database
+	if req.ReadOnly {
+		sb.WriteString("The session is read-only: the database refuses any write. Answer with queries that only read data.\n\n")
+	}
+
+	// Summary of the conversation turns that didn't fit in the token window
+	sb.WriteString(summary)
+	if len(req.History) > 0 {
+		sb.WriteString("The conversation so far is included as messages: the user's earlier requests and the SQL generated for them.\n")
+		sb.WriteString("Use this conversation context to understand what the user is referring to.\n")
+		sb.WriteString("If the user's current request is a follow-up (e.g., \"show only the last 10\", \"filter by that user\", \"add a limit\"),\n")
+		sb.WriteString("base your query on the most recent SQL but apply the requested modification.\n\n")
 	}
 
-	// Basic SQL validation (can be extended)
-	upper := strings.ToUpper(trimmed)
-	validStarts := []string{
-		"SELECT", "INSERT", "UPDATE", "DELETE", "WITH",
-		"CREATE", "ALTER", "DROP", "TRUNCATE",
-		"SHOW", "DESCRIBE", "DESC", "EXPLAIN",
-		"SET", "USE",
+	// Add the user's answers to clarifying questions about the current request
+	if len(req.Clarifications) > 0 {
+		sb.WriteString("You asked the user to clarify the current request:\n\n")
+		for _, c := range req.Clarifications {
+			sb.WriteString(fmt.Sprintf("Question: %s\n", c.Question))
+			sb.WriteString(fmt.Sprintf("User's answer: %s\n\n", c.Answer))
+		}
+		sb.WriteString("Use these answers to resolve the ambiguity.\n")
+	}
+	if len(req.Clarifications) >= s.maxClarifications {
+		sb.WriteString("Do not ask the user for clarification: answer with SQL and list any remaining assumptions.\n\n")
+	} else if len(req.Clarifications) > 0 {
+		sb.WriteString("\n")
 	}
 
-	for _, start := range validStarts {
-		if strings.HasPrefix(upper, start) {
-			return nil
+	// Add failed attempts so the model can correct its own mistakes (self-healing)
+	if len(req.FailedAttempts) > 0 {
+		sb.WriteString("Previous attempts to answer the current request failed when checked against the schema or executed against the database:\n\n")
+		for i, attempt := range req.FailedAttempts {
+			sb.WriteString(fmt.Sprintf("Attempt %d SQL: %s\n", i+1, attempt.SQL))
+			sb.WriteString(fmt.Sprintf("Error: %s\n\n", attempt.Error))
 		}
+		sb.WriteString("Fix the query so that it runs successfully. Check every table and column name against the schema\n")
+		sb.WriteString("and make sure the syntax matches the target database. Do not repeat a query that already failed.\n\n")
 	}
 
-	return ErrInvalidSQL
+	return sb.String()
 }
 
-// IsDangerous checks if a query might modify data
-func (s *Service) IsDangerous(query string) bool {
-	trimmed := strings.TrimSpace(strings.ToUpper(query))
-	dangerousStarts := []string{
-		"INSERT", "UPDATE", "DELETE",
-		"DROP", "ALTER", "CREATE", "TRUNCATE",
-		"SET", "USE",
+// Explain explains an existing SQL query step by step in plain language, the reverse of
+// Generate. When the service has a planner, the database's execution plan is sent along
+// so the explanation can point out performance pitfalls; queries that can't be planned
+// (DDL, several statements) are explained from their text alone.
+func (s *Service) Explain(ctx context.Context, query, databaseType string) (*Explanation, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, ErrEmptyQuery
+	}
+
+	// The plan is optional context: planning never executes the query
+	var plan string
+	if s.planner != nil {
+		if queryPlan, err := s.planner.Explain(ctx, query); err == nil {
+			plan = queryPlan.Plan
+		}
+	}
+
+	// Refuse to spend more once the budget is exhausted
+	if err := s.costTracker.Check(); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.aiProvider.ExplainSQL(ctx, &ai.ExplainRequest{
+		Query:        query,
+		Plan:         plan,
+		DatabaseType: databaseType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain SQL: %w", err)
+	}
+
+	return &Explanation{
+		Query: query,
+		Plan:  plan,
+		Text:  resp.Explanation,
+		Usage: resp.Usage,
+		Cost:  s.costTracker.Record(resp.Usage),
+	}, nil
+}
+
+// generate sends a single request to the AI provider and charges it to the cost tracker,
+// which counts it against the budget while it runs
+func (s *Service) generate(ctx context.Context, aiReq *ai.GenerateRequest, onChunk ai.StreamHandler) (*ai.GenerateResponse, cost.Charge, error) {
+	release, err := s.costTracker.Reserve()
+	if err != nil {
+		return nil, cost.Charge{}, err
+	}
+	defer release()
+
+	var resp *ai.GenerateResponse
+	if onChunk != nil {
+		resp, err = s.aiProvider.GenerateSQLStream(ctx, aiReq, onChunk)
+	} else {
+		resp, err = s.aiProvider.GenerateSQL(ctx, aiReq)
+	}
+	if err != nil {
+		return nil, cost.Charge{}, fmt.Errorf("failed to generate SQL: %w", err)
+	}
+
+	// Charge the request before validation: invalid output costs tokens too
+	return resp, s.costTracker.Record(resp.Usage), nil
+}
+
+// clarification returns the question the provider asked instead of generating SQL, if any.
+// Models that ignore the response format may ask in plain text: a response that is not
+// valid SQL and ends with a question mark is taken as a question as well.
+func (s *Service) clarification(resp *ai.GenerateResponse) string {
+	if resp.Clarification != "" && strings.TrimSpace(resp.Query) == "" {
+		return strings.TrimSpace(resp.Clarification)
 	}
 
-	for _, start := range dangerousStarts {
-		if strings.HasPrefix(trimmed, start) {
-			return true
+	text := strings.TrimSpace(resp.Query)
+	if resp.Clarification == "" && s.Validate(text) != nil && strings.HasSuffix(text, "?") {
+		return text
+	}
+
+	return ""
+}
+
+// validStatements are the statement keywords accepted from providers
+var validStatements = []string{
+	"SELECT", "INSERT", "UPDATE", "DELETE", "WITH",
+	"CREATE", "ALTER", "DROP", "TRUNCATE",
+	"SHOW", "DESCRIBE", "DESC", "EXPLAIN",
+	"SET", "USE",
+}
+
+// Validate validates a SQL query: it must tokenize in the database's dialect,
+// and every statement must start with a known statement keyword
+func (s *Service) Validate(query string) error {
+	statements, err := sqlparse.Parse(query, s.dialect)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSQL, err)
+	}
+	if len(statements) == 0 {
+		return ErrInvalidSQL
+	}
+
+	for _, statement := range statements {
+		if !slices.Contains(validStatements, statement.Keyword) {
+			return ErrInvalidSQL
 		}
 	}
 
-	return false
+	return nil
+}
+
+// IsDangerous reports whether a query needs the user's confirmation before running (see Risks)
+func (s *Service) IsDangerous(query string) bool {
+	return len(s.Risks(query)) > 0
+}
+
+// Risks returns why a query needs the user's confirmation before running, for display:
+// writes anywhere in it (including WITH clauses and subqueries), several statements,
+// statements other than reads, row locks and functions with side effects.
+// Queries that can't be tokenized are risky too, since what they do can't be told.
+func (s *Service) Risks(query string) []string {
+	statements, err := sqlparse.Parse(query, s.dialect)
+	if err != nil {
+		return []string{fmt.Sprintf("can't be checked (%v)", err)}
+	}
+
+	return sqlparse.Risks(statements)
 }
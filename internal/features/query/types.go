@@ -1,6 +1,9 @@
 package query
 
-import "github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+import (
+	"github.com/alessandrolattao/asqli/internal/features/cost"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+)
 
 // SQL represents a generated SQL query with metadata
 type SQL struct {
@@ -10,6 +13,83 @@ type SQL struct {
 	// Optional explanation of what the query does
 	Explanation string
 
+	// Confidence score (0.0-1.0) reported by the model, 0 when unknown.
+	// With several candidates, the share of candidates agreeing on Query instead.
+	Confidence float64
+
+	// Assumptions the model made about ambiguous parts of the prompt
+	Assumptions []string
+
+	// Reasoning the model went through before answering, when the provider reports it
+	Reasoning string
+
 	// Usage metadata (tokens, model, provider, etc.)
 	Usage ai.UsageMetadata
+
+	// Cost of the AI requests that generated the query
+	Cost cost.Charge
+
+	// Every candidate considered, in generation order (multi-candidate generation only)
+	Candidates []Candidate
+
+	// Tables described in the schema sent to the provider, most relevant first
+	// (only when the schema was cut down to the relevant tables)
+	SchemaTables []string
+
+	// How the request was cut down to fit the model's context window, for the user
+	// (e.g. "constraint details left out")
+	Notices []string
+
+	// Tools called by the provider to inspect the database, in order (exploration only)
+	ToolCalls []ai.ToolCall
+
+	// Question for the user when the prompt is too ambiguous to answer. Query is empty then:
+	// answer it through Request.Clarifications and generate again
+	Clarification string
+}
+
+// Explanation is a plain-language, step-by-step explanation of an existing SQL query
+type Explanation struct {
+	// The SQL query that was explained
+	Query string
+
+	// Execution plan reported by the database, empty when the query could not be planned
+	Plan string
+
+	// Step-by-step explanation: joins, filters and likely performance pitfalls
+	Text string
+
+	// Usage metadata (tokens, model, provider, etc.)
+	Usage ai.UsageMetadata
+
+	// Cost of the AI request that produced the explanation
+	Cost cost.Charge
+}
+
+// Request contains the input for SQL generation
+type Request struct {
+	// User's natural language prompt
+	Prompt string
+
+	// Database schema context
+	Schema string
+
+	// Previous prompts and their SQL, oldest first
+	History []History
+
+	// Currently selected table cell (optional)
+	SelectedColumn string
+	SelectedValue  any
+
+	// Digest of the result set returned by the previous query (optional, see execution.Result.Digest)
+	LastResult string
+
+	// Earlier SQL generated for this prompt that failed to execute (self-healing)
+	FailedAttempts []Attempt
+
+	// Questions asked about this prompt and the user's answers, in order
+	Clarifications []Clarification
+
+	// The session is read-only: the database refuses writes
+	ReadOnly bool
 }
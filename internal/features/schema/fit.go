@@ -0,0 +1,129 @@
+package schema
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/alessandrolattao/asqli/internal/infrastructure/database/adapters"
+)
+
+// fit formats tables (most relevant first, out of total in the database) in at most
+// maxTokens tokens (0 = no limit). Full definitions are tried first, then definitions
+// without constraint details, then the least relevant tables are left out. With namesOnly
+// only the table names are listed, as in the outline sent to providers with exploration tools.
+func (s *Service) fit(tables []*adapters.TableDefinition, total int, namesOnly bool, maxTokens int, countTokens func(string) int) *Selection {
+	selection := &Selection{Total: total}
+
+	if namesOnly {
+		names := make([]string, len(tables))
+		for i, table := range tables {
+			names[i] = table.Name
+		}
+		kept, schema := shrink(len(names), maxTokens, countTokens,
+			func(kept int) string { return s.outline(names[:kept]) },
+			func(i int) int { return countTokens(fmt.Sprintf("  %s\n", names[i])) })
+		selection.Schema = schema
+		if kept < len(names) {
+			selection.Notices = append(selection.Notices, fmt.Sprintf("%d of %d table names left out", len(names)-kept, len(names)))
+		}
+		return selection
+	}
+
+	selection.Schema = s.describe(tables, total, adapters.FormatTableDefinition)
+	if maxTokens > 0 && countTokens(selection.Schema) > maxTokens {
+		selection.Notices = append(selection.Notices, "constraint details left out")
+
+		// Then leave out the least relevant tables
+		kept, schema := shrink(len(tables), maxTokens, countTokens,
+			func(kept int) string { return s.describe(tables[:kept], total, formatCompact) },
+			func(i int) int { return countTokens(formatCompact(tables[i])) })
+		selection.Schema = schema
+		if kept < len(tables) {
+			selection.Notices = append(selection.Notices, fmt.Sprintf("%d of %d tables left out", len(tables)-kept, len(tables)))
+		}
+		tables = tables[:kept]
+	}
+
+	for _, table := range tables {
+		selection.Tables = append(selection.Tables, table.Name)
+	}
+	return selection
+}
+
+// shrink returns how many of n items (most relevant first) can be rendered in maxTokens
+// tokens, and their rendering. The count is estimated from the tokens of each item, then
+// corrected by rendering, so large schemas aren't re-rendered once per item.
+func shrink(n, maxTokens int, countTokens func(string) int, render func(kept int) string, itemTokens func(i int) int) (int, string) {
+	rendered := render(n)
+	if maxTokens <= 0 {
+		return n, rendered
+	}
+
+	kept := n
+	size := countTokens(rendered)
+	for kept > 0 && size > maxTokens {
+		kept--
+		size -= itemTokens(kept)
+	}
+	if kept < n {
+		rendered = render(kept)
+	}
+	for kept > 0 && countTokens(rendered) > maxTokens {
+		kept--
+		rendered = render(kept)
+	}
+
+	return kept, rendered
+}
+
+// describe formats the definitions of tables, noting when they are only part of the database
+func (s *Service) describe(tables []*adapters.TableDefinition, total int, format func(*adapters.TableDefinition) string) string {
+	sorted := slices.Clone(tables)
+	slices.SortFunc(sorted, func(a, b *adapters.TableDefinition) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	var sb strings.Builder
+	sb.WriteString("DATABASE SCHEMA:\n\n")
+	for _, table := range sorted {
+		sb.WriteString(format(table))
+	}
+
+	if len(tables) < total {
+		sb.WriteString(fmt.Sprintf("Only %d of the %d tables in the database are described, the ones most relevant to the request.\n", len(tables), total))
+		if s.explore {
+			sb.WriteString("Call list_tables and describe_table for the other tables.\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// formatCompact formats a table definition without defaults and constraint details:
+// column names and types, primary keys and the tables referenced by foreign keys
+func formatCompact(table *adapters.TableDefinition) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("TABLE: %s\n", table.Name))
+
+	for _, col := range table.Columns {
+		primaryKey := ""
+		if col.IsPrimary {
+			primaryKey = " PRIMARY KEY"
+		}
+		sb.WriteString(fmt.Sprintf("  %s %s%s\n", col.Name, col.Type, primaryKey))
+	}
+
+	var references []string
+	for _, constraint := range table.Constraints {
+		if constraint.ReferencedTable != "" && !slices.Contains(references, constraint.ReferencedTable) {
+			references = append(references, constraint.ReferencedTable)
+		}
+	}
+	if len(references) > 0 {
+		sb.WriteString(fmt.Sprintf("  REFERENCES: %s\n", strings.Join(references, ", ")))
+	}
+
+	sb.WriteString("\n")
+	return sb.String()
+}
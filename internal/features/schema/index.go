@@ -0,0 +1,322 @@
+package schema
+
+import (
+	"context"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/alessandrolattao/asqli/internal/infrastructure/database/adapters"
+)
+
+const (
+	// Term weights: a word matching a table name counts more than one matching a column
+	tableNameWeight  = 3.0
+	columnNameWeight = 1.0
+	referenceWeight  = 0.5
+
+	// embeddingBatchSize is how many table descriptions are embedded per request
+	embeddingBatchSize = 64
+)
+
+// stopWords are common prompt words that never identify a table
+var stopWords = map[string]bool{
+	"a": true, "all": true, "an": true, "and": true, "are": true, "by": true, "each": true,
+	"find": true, "for": true, "from": true, "get": true, "give": true, "ha": true, "have": true,
+	"how": true, "in": true, "is": true, "list": true, "many": true, "me": true, "much": true,
+	"of": true, "on": true, "or": true, "per": true, "show": true, "that": true, "the": true,
+	"their": true, "them": true, "thi": true, "those": true, "to": true, "top": true, "wa": true,
+	"were": true, "what": true, "where": true, "which": true, "who": true, "with": true,
+}
+
+// Embedder turns texts into embedding vectors, to match prompts with tables by meaning
+// (implemented by *ollama.Embedder)
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// Index ranks the tables of a database by their relevance to a prompt. Tables are scored
+// lexically on the words of their name, columns and referenced tables (weighted by how
+// rare each word is across the schema), plus the cosine similarity of embeddings when
+// an embedder is available.
+type Index struct {
+	tables   []*adapters.TableDefinition
+	terms    []map[string]float64
+	idf      map[string]float64
+	vectors  [][]float32
+	embedder Embedder
+}
+
+// NewIndex indexes table definitions. With an embedder, every table is embedded as well;
+// if that fails, the index falls back to lexical scoring alone.
+func NewIndex(ctx context.Context, tables []*adapters.TableDefinition, embedder Embedder) *Index {
+	index := &Index{
+		tables: slices.Clone(tables),
+		terms:  make([]map[string]float64, len(tables)),
+		idf:    make(map[string]float64),
+	}
+
+	// Weighted terms of every table, and how many tables contain each term
+	documents := make(map[string]int)
+	for i, table := range index.tables {
+		terms := make(map[string]float64)
+		addTerms(terms, table.Name, tableNameWeight)
+		for _, col := range table.Columns {
+			addTerms(terms, col.Name, columnNameWeight)
+		}
+		for _, constraint := range table.Constraints {
+			addTerms(terms, constraint.ReferencedTable, referenceWeight)
+		}
+		for term := range terms {
+			documents[term]++
+		}
+		index.terms[i] = terms
+	}
+	for term, count := range documents {
+		index.idf[term] = math.Log(1 + float64(len(tables))/float64(count))
+	}
+
+	if embedder != nil {
+		if vectors, err := embedTables(ctx, embedder, index.tables); err == nil {
+			index.vectors = vectors
+			index.embedder = embedder
+		}
+	}
+
+	return index
+}
+
+// Len returns the number of indexed tables
+func (i *Index) Len() int {
+	return len(i.tables)
+}
+
+// names returns the names of the indexed tables
+func (i *Index) names() []string {
+	names := make([]string, len(i.tables))
+	for n, table := range i.tables {
+		names[n] = table.Name
+	}
+	return names
+}
+
+// Rank returns every table ordered by relevance to text, most relevant first
+func (i *Index) Rank(ctx context.Context, text string) []*adapters.TableDefinition {
+	order, _ := i.rank(ctx, text)
+
+	tables := make([]*adapters.TableDefinition, len(order))
+	for n, table := range order {
+		tables[n] = i.tables[table]
+	}
+	return tables
+}
+
+// Select returns the k tables most relevant to text, followed by the tables related to them
+// by foreign keys (referenced tables first), up to 2k tables in all. Tables that don't
+// match text at all are never among the first k.
+func (i *Index) Select(ctx context.Context, text string, k int) []*adapters.TableDefinition {
+	order, scores := i.rank(ctx, text)
+
+	selected := make(map[string]bool)
+	var tables []*adapters.TableDefinition
+	for _, n := range order {
+		if len(tables) == k || scores[n] <= 0 {
+			break
+		}
+		selected[i.tables[n].Name] = true
+		tables = append(tables, i.tables[n])
+	}
+
+	// Foreign-key neighbours, in relevance order: tables the selection references, then
+	// tables that reference it
+	references := func(from, to *adapters.TableDefinition) bool {
+		for _, constraint := range from.Constraints {
+			if constraint.ReferencedTable == to.Name {
+				return true
+			}
+		}
+		return false
+	}
+	top := slices.Clone(tables)
+	for _, outgoing := range []bool{true, false} {
+		for _, n := range order {
+			table := i.tables[n]
+			if len(tables) >= 2*k {
+				return tables
+			}
+			if selected[table.Name] {
+				continue
+			}
+			related := slices.ContainsFunc(top, func(t *adapters.TableDefinition) bool {
+				if outgoing {
+					return references(t, table)
+				}
+				return references(table, t)
+			})
+			if related {
+				selected[table.Name] = true
+				tables = append(tables, table)
+			}
+		}
+	}
+
+	return tables
+}
+
+// rank returns the table indexes ordered by relevance to text, and the score of every table
+func (i *Index) rank(ctx context.Context, text string) ([]int, []float64) {
+	scores := i.score(ctx, text)
+
+	order := make([]int, len(i.tables))
+	for n := range order {
+		order[n] = n
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		switch {
+		case scores[a] > scores[b]:
+			return -1
+		case scores[a] < scores[b]:
+			return 1
+		default:
+			return strings.Compare(i.tables[a].Name, i.tables[b].Name)
+		}
+	})
+
+	return order, scores
+}
+
+// score returns the relevance of every table to text
+func (i *Index) score(ctx context.Context, text string) []float64 {
+	query := make(map[string]float64)
+	addTerms(query, text, 1)
+
+	// Lexical score, normalized so the best match scores 1
+	scores := make([]float64, len(i.tables))
+	best := 0.0
+	for n, terms := range i.terms {
+		for term := range query {
+			scores[n] += terms[term] * i.idf[term]
+		}
+		best = max(best, scores[n])
+	}
+	if best > 0 {
+		for n := range scores {
+			scores[n] /= best
+		}
+	}
+
+	// Semantic score: similarity between the embeddings of the text and of every table
+	if i.embedder != nil {
+		if vectors, err := i.embedder.Embed(ctx, []string{text}); err == nil {
+			for n, vector := range i.vectors {
+				scores[n] += max(cosine(vectors[0], vector), 0)
+			}
+		}
+	}
+
+	return scores
+}
+
+// embedTables embeds a description of every table, in batches
+func embedTables(ctx context.Context, embedder Embedder, tables []*adapters.TableDefinition) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(tables))
+	for batch := range slices.Chunk(tables, embeddingBatchSize) {
+		texts := make([]string, len(batch))
+		for n, table := range batch {
+			texts[n] = describe(table)
+		}
+
+		embeddings, err := embedder.Embed(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, embeddings...)
+	}
+
+	return vectors, nil
+}
+
+// describe returns the text embedded for a table: its name and column names
+func describe(table *adapters.TableDefinition) string {
+	var sb strings.Builder
+	sb.WriteString("table ")
+	sb.WriteString(table.Name)
+	sb.WriteString(" with columns ")
+	for n, col := range table.Columns {
+		if n > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(col.Name)
+	}
+	return sb.String()
+}
+
+// addTerms adds the words of text to terms with the given weight, keeping the highest
+// weight of a repeated word
+func addTerms(terms map[string]float64, text string, weight float64) {
+	for _, word := range words(text) {
+		terms[word] = max(terms[word], weight)
+	}
+}
+
+// words splits identifiers and prose into lowercase, singular words: "OrderItems",
+// "order_items" and "order items" all yield "order" and "item"
+func words(text string) []string {
+	var result []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			w := stem(strings.ToLower(string(word)))
+			if len(w) > 1 && !stopWords[w] {
+				result = append(result, w)
+			}
+			word = word[:0]
+		}
+	}
+
+	runes := []rune(text)
+	for n, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && n > 0 && unicode.IsLower(runes[n-1]):
+			// camelCase boundary
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+
+	return result
+}
+
+// stem reduces an English plural to its singular form ("categories" -> "category")
+func stem(word string) string {
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case len(word) > 4 && (strings.HasSuffix(word, "sses") || strings.HasSuffix(word, "xes") || strings.HasSuffix(word, "ches") || strings.HasSuffix(word, "shes")):
+		return word[:len(word)-2]
+	case len(word) > 2 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us"):
+		return word[:len(word)-1]
+	default:
+		return word
+	}
+}
+
+// cosine returns the cosine similarity of two vectors
+func cosine(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for n := range min(len(a), len(b)) {
+		dot += float64(a[n]) * float64(b[n])
+		normA += float64(a[n]) * float64(a[n])
+		normB += float64(b[n]) * float64(b[n])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
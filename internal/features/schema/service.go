@@ -3,22 +3,73 @@ package schema
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync"
 
+	"github.com/alessandrolattao/asqli/internal/infrastructure/config"
 	"github.com/alessandrolattao/asqli/internal/infrastructure/database"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/database/adapters"
 )
 
+// Selection is the part of the schema sent with a prompt when the whole schema can't be:
+// the database is too large to send whole, or the schema doesn't fit the model's context window
+type Selection struct {
+	// Formatted definitions of the selected tables
+	Schema string
+
+	// Names of the selected tables, most relevant first (empty when no table matched)
+	Tables []string
+
+	// Number of tables in the database
+	Total int
+
+	// How the schema was cut down to fit the token budget, for the user
+	// (e.g. "constraint details left out")
+	Notices []string
+}
+
 // Service handles database schema extraction and caching
 type Service struct {
-	conn  *database.Connection
-	cache *Cache
+	conn     *database.Connection
+	cache    *Cache
+	embedder Embedder
+
+	// Databases with more tables are only outlined by their table names (0 = never),
+	// for providers that can describe tables with exploration tools
+	explore   bool
+	maxTables int
+
+	// Databases with more than retrievalTables tables only send the relevantTables
+	// tables most relevant to each prompt (0 relevantTables = never)
+	retrievalTables int
+	relevantTables  int
+
+	mu    sync.Mutex
+	index *Index
 }
 
-// NewService creates a new schema service
-func NewService(conn *database.Connection) *Service {
-	return &Service{
-		conn:  conn,
-		cache: NewCache(),
+// NewService creates a new schema service.
+// With generation.Explore, databases with more than generation.ExploreSchemaTables tables are
+// only outlined by their table names, for providers that describe the tables they need with
+// tools. Databases with more than generation.RetrievalTables tables are indexed so that only
+// the tables relevant to each prompt are sent (see Relevant), matched by meaning as well
+// through embedder (nil = lexical matching only).
+func NewService(conn *database.Connection, generation config.GenerationConfig, embedder Embedder) *Service {
+	s := &Service{
+		conn:     conn,
+		cache:    NewCache(),
+		embedder: embedder,
+	}
+	if generation.Explore {
+		s.explore = true
+		s.maxTables = generation.ExploreSchemaTables
 	}
+	if generation.RelevantTables > 0 {
+		s.retrievalTables = generation.RetrievalTables
+		s.relevantTables = generation.RelevantTables
+	}
+	return s
 }
 
 // Get retrieves the database schema (from cache if available)
@@ -29,7 +80,7 @@ func (s *Service) Get(ctx context.Context) (string, error) {
 	}
 
 	// Extract schema from database
-	schema, err := s.conn.GetDatabaseSchema(ctx)
+	schema, err := s.extract(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -40,9 +91,57 @@ func (s *Service) Get(ctx context.Context) (string, error) {
 	return schema, nil
 }
 
+// Relevant selects the part of the schema to send with text (a prompt and its context).
+// On databases with more than the retrieval threshold of tables, only the most relevant
+// tables and their foreign-key neighbours are selected. When the selection, or the whole
+// schema, needs more than maxTokens tokens as counted by countTokens, it is cut down to
+// fit: constraint details go first, then the least relevant tables (0 maxTokens = no limit).
+// It returns nil when the whole schema (see Get) should be sent.
+func (s *Service) Relevant(ctx context.Context, text string, maxTokens int, countTokens func(string) int) (*Selection, error) {
+	index, err := s.loadIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	outlined := s.maxTables > 0 && index.Len() > s.maxTables
+
+	// Small databases send the whole schema as long as it fits
+	if s.relevantTables <= 0 || index.Len() <= s.retrievalTables {
+		schema, err := s.Get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if maxTokens <= 0 || countTokens(schema) <= maxTokens {
+			return nil, nil
+		}
+		return s.fit(index.Rank(ctx, text), index.Len(), outlined, maxTokens, countTokens), nil
+	}
+
+	tables := index.Select(ctx, text, s.relevantTables)
+	if len(tables) == 0 {
+		// Nothing matched: the table names at least let the provider pick the right ones
+		return s.fit(index.Rank(ctx, text), index.Len(), true, maxTokens, countTokens), nil
+	}
+
+	return s.fit(tables, index.Len(), false, maxTokens, countTokens), nil
+}
+
+// Tables returns the definitions of every table in the database, including the ones
+// the schema sent with prompts leaves out
+func (s *Service) Tables(ctx context.Context) ([]*adapters.TableDefinition, error) {
+	index, err := s.loadIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return index.tables, nil
+}
+
 // Invalidate clears the schema cache
 func (s *Service) Invalidate() {
 	s.cache.Clear()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index = nil
 }
 
 // Refresh forces a schema refresh from the database
@@ -50,3 +149,51 @@ func (s *Service) Refresh(ctx context.Context) (string, error) {
 	s.Invalidate()
 	return s.Get(ctx)
 }
+
+// extract extracts the full schema, or the table outline when the database has too many tables.
+// The table definitions are indexed on the way, for Relevant.
+func (s *Service) extract(ctx context.Context) (string, error) {
+	index, err := s.loadIndex(ctx)
+	if err != nil {
+		return "", err
+	}
+	if s.maxTables > 0 && index.Len() > s.maxTables {
+		return s.outline(index.names()), nil
+	}
+
+	return adapters.FormatDatabaseSchema(index.tables), nil
+}
+
+// loadIndex returns the schema index, building it from the table definitions if needed
+func (s *Service) loadIndex(ctx context.Context) (*Index, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.index != nil {
+		return s.index, nil
+	}
+
+	definitions, err := s.conn.GetTableDefinitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.index = NewIndex(ctx, definitions, s.embedder)
+
+	return s.index, nil
+}
+
+// outline lists the table names in place of the full schema
+func (s *Service) outline(tables []string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("The database has %d tables. Only their names are listed here", len(tables)))
+	if s.explore {
+		sb.WriteString(":\ncall describe_table for the columns of the tables you need before writing SQL.\n\n")
+	} else {
+		sb.WriteString(".\n\n")
+	}
+	sb.WriteString("TABLES:\n")
+	for _, table := range tables {
+		sb.WriteString(fmt.Sprintf("  %s\n", table))
+	}
+	return sb.String()
+}
@@ -0,0 +1,10 @@
+// Package undo defines errors related to undo snapshots.
+package undo
+
+import "errors"
+
+// Sentinel errors returned by the undo store.
+var (
+	// ErrNothingToUndo is returned when the database has no snapshot left to restore
+	ErrNothingToUndo = errors.New("nothing to undo")
+)
@@ -0,0 +1,247 @@
+// Package undo keeps snapshots of the rows changed by UPDATE and DELETE statements, as SQL
+// files restoring them, and restores the last one on request.
+package undo
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alessandrolattao/asqli/internal/infrastructure/config"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/database"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/database/adapters"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/sqlparse"
+)
+
+const (
+	// snapshotExt is the extension of snapshot files
+	snapshotExt = ".sql"
+
+	// undoneExt is appended to snapshot files once restored, so that the next undo
+	// restores the snapshot before
+	undoneExt = ".undone"
+)
+
+// Restore describes a restored snapshot
+type Restore struct {
+	// Table whose rows were restored
+	Table string
+
+	// Rows the restore statements changed
+	Rows int64
+
+	// Snapshot file, renamed with the .undone extension
+	Path string
+}
+
+// Store keeps the undo snapshots of a database in a directory of the configuration
+// directory (e.g. ~/.config/asqli/undo/3f2a9c41d0e7), one timestamped SQL file per
+// committed statement, whose statements put the rows back as they were
+type Store struct {
+	conn *database.Connection
+	dir  string
+
+	// Database written in snapshot files, without credentials
+	label string
+}
+
+// NewStore creates the snapshot store of the database dbConfig connects to. Each
+// database has its own directory, so undo never restores rows into another one.
+func NewStore(conn *database.Connection, dbConfig adapters.Config) (*Store, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	key, label := identify(dbConfig)
+	return &Store{
+		conn:  conn,
+		dir:   filepath.Join(dir, "undo", key),
+		label: label,
+	}, nil
+}
+
+// identify returns a key naming the directory of a database's snapshots and a description
+// of the database. Connection strings are hashed, not written, as they may hold a password.
+func identify(dbConfig adapters.Config) (key, label string) {
+	var identity, description string
+	switch {
+	case dbConfig.ConnectionString != "":
+		identity = dbConfig.ConnectionString
+		description = "connection string"
+	case dbConfig.DriverType == adapters.SQLite:
+		path := dbConfig.FilePath
+		if path == "" {
+			path = dbConfig.DBName
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		identity, description = path, path
+	default:
+		description = fmt.Sprintf("%s@%s:%d/%s", dbConfig.User, dbConfig.Host, dbConfig.Port, dbConfig.DBName)
+		identity = description
+	}
+
+	sum := sha256.Sum256([]byte(string(dbConfig.DriverType) + "\n" + identity))
+	return hex.EncodeToString(sum[:6]), string(dbConfig.DriverType) + " " + description
+}
+
+// Save writes a snapshot file with the statements restoring the rows of snapshot:
+// inserts for deleted rows, updates by primary key for updated ones. It returns the path
+// of the file.
+func (s *Store) Save(snapshot *database.Snapshot) (string, error) {
+	now := time.Now().UTC()
+
+	var sb strings.Builder
+	sb.WriteString("-- asqli undo snapshot\n")
+	sb.WriteString("-- database: " + s.label + "\n")
+	sb.WriteString("-- time: " + now.Format(time.RFC3339) + "\n")
+	sb.WriteString("-- statement: " + strings.Join(strings.Fields(snapshot.Statement), " ") + "\n")
+	sb.WriteString("-- table: " + snapshot.Table + "\n")
+	sb.WriteString("-- rows: " + strconv.Itoa(len(snapshot.Rows)) + "\n\n")
+	for _, statement := range s.restoreStatements(snapshot) {
+		sb.WriteString(statement + ";\n")
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create undo directory: %w", err)
+	}
+
+	// Timestamps sort in the order the snapshots were taken
+	path := filepath.Join(s.dir, now.Format("20060102T150405.000000000Z")+snapshotExt)
+	if err := os.WriteFile(path, []byte(sb.String()), 0600); err != nil {
+		return "", fmt.Errorf("failed to write undo snapshot: %w", err)
+	}
+
+	return path, nil
+}
+
+// Discard deletes a snapshot file whose statement wasn't committed after all
+func (s *Store) Discard(path string) error {
+	return os.Remove(path)
+}
+
+// Undo restores the last snapshot not restored yet in a transaction, and marks it as
+// restored. Rows changed again since the snapshot are overwritten.
+func (s *Store) Undo(ctx context.Context) (*Restore, error) {
+	path, err := s.last()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read undo snapshot: %w", err)
+	}
+	statements, err := sqlparse.Parse(string(data), s.conn.DriverType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid undo snapshot %s: %w", path, err)
+	}
+
+	texts := make([]string, len(statements))
+	for i, statement := range statements {
+		texts[i] = statement.Text
+	}
+	rows, err := s.conn.ExecInTransaction(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore %s, nothing was changed: %w", filepath.Base(path), err)
+	}
+
+	if err := os.Rename(path, path+undoneExt); err != nil {
+		return nil, fmt.Errorf("restored %s but failed to mark it as restored: %w", filepath.Base(path), err)
+	}
+
+	return &Restore{
+		Table: header(string(data), "table"),
+		Rows:  rows,
+		Path:  path + undoneExt,
+	}, nil
+}
+
+// last returns the path of the newest snapshot not restored yet
+func (s *Store) last() (string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return "", ErrNothingToUndo
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to list undo snapshots: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), snapshotExt) {
+			names = append(names, entry.Name())
+		}
+	}
+	if len(names) == 0 {
+		return "", ErrNothingToUndo
+	}
+
+	slices.Sort(names)
+	return filepath.Join(s.dir, names[len(names)-1]), nil
+}
+
+// restoreStatements returns the statements putting the rows of snapshot back
+func (s *Store) restoreStatements(snapshot *database.Snapshot) []string {
+	table := s.conn.QuoteIdentifier(snapshot.Table)
+	if snapshot.Schema != "" {
+		table = s.conn.QuoteIdentifier(snapshot.Schema) + "." + table
+	}
+
+	statements := make([]string, 0, len(snapshot.Rows))
+	for _, row := range snapshot.Rows {
+		var columns, values, assignments, conditions []string
+		for _, column := range snapshot.Columns {
+			is := func(name string) bool { return strings.EqualFold(name, column) }
+			if slices.ContainsFunc(snapshot.Generated, is) {
+				// Computed again from the restored values
+				continue
+			}
+
+			name, value := s.conn.QuoteIdentifier(column), s.conn.QuoteLiteral(row[column])
+			columns = append(columns, name)
+			values = append(values, value)
+			if slices.ContainsFunc(snapshot.PrimaryKey, is) {
+				conditions = append(conditions, name+" = "+value)
+			} else {
+				assignments = append(assignments, name+" = "+value)
+			}
+		}
+
+		switch {
+		case snapshot.Keyword == "DELETE":
+			statements = append(statements, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+				table, strings.Join(columns, ", "), strings.Join(values, ", ")))
+		case len(assignments) > 0 && len(conditions) > 0:
+			statements = append(statements, fmt.Sprintf("UPDATE %s SET %s WHERE %s",
+				table, strings.Join(assignments, ", "), strings.Join(conditions, " AND ")))
+		}
+	}
+
+	return statements
+}
+
+// header returns the value of a "-- key: value" header line of a snapshot file
+func header(data, key string) string {
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line, ok := strings.CutPrefix(scanner.Text(), "-- ")
+		if !ok {
+			break
+		}
+		if value, ok := strings.CutPrefix(line, key+": "); ok {
+			return value
+		}
+	}
+	return ""
+}
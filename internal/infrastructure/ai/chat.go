@@ -0,0 +1,91 @@
+// Package ai provides the plain-language modes (answer, explain) shared by all providers.
+package ai
+
+import (
+	"context"
+	"strings"
+
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai/prompt"
+)
+
+// CompleteFunc is the transport a provider supplies for the plain-language modes: it sends
+// the rendered prompt as a single non-streaming chat request and returns the response text.
+type CompleteFunc func(ctx context.Context, chat prompt.ChatPrompt) (string, UsageMetadata, error)
+
+// Templates returns the prompt templates of the configuration, the built-in ones if none are set
+func (c Config) Templates() *prompt.Templates {
+	if c.Prompts == nil {
+		return prompt.Default()
+	}
+	return c.Prompts
+}
+
+// Answer implements Provider.Answer with the prompts of templates and the provider's transport
+func Answer(ctx context.Context, templates *prompt.Templates, complete CompleteFunc, req *AnswerRequest) (*AnswerResponse, error) {
+	if req.Question == "" {
+		return nil, ErrEmptyPrompt
+	}
+
+	chat, err := templates.Answer(prompt.AnswerData{
+		DatabaseType: req.DatabaseType,
+		Question:     req.Question,
+		Query:        req.Query,
+		Result:       req.Result,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	answer, usage, err := chatText(ctx, complete, chat)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AnswerResponse{
+		Answer: answer,
+		Usage:  usage,
+	}, nil
+}
+
+// ExplainSQL implements Provider.ExplainSQL with the prompts of templates and the provider's transport
+func ExplainSQL(ctx context.Context, templates *prompt.Templates, complete CompleteFunc, req *ExplainRequest) (*ExplainResponse, error) {
+	if req.Query == "" {
+		return nil, ErrEmptyPrompt
+	}
+
+	chat, err := templates.Explain(prompt.ExplainData{
+		DatabaseType: req.DatabaseType,
+		Query:        req.Query,
+		Plan:         req.Plan,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	explanation, usage, err := chatText(ctx, complete, chat)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExplainResponse{
+		Explanation: explanation,
+		Usage:       usage,
+	}, nil
+}
+
+// chatText sends chat through complete and returns the response without inline
+// reasoning (local models served through Ollama or OpenAI-compatible servers)
+func chatText(ctx context.Context, complete CompleteFunc, chat prompt.ChatPrompt) (string, UsageMetadata, error) {
+	text, usage, err := complete(ctx, chat)
+	if err != nil {
+		return "", UsageMetadata{}, err
+	}
+
+	_, text = SplitThinking(text)
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", UsageMetadata{}, ErrGenerationFailed
+	}
+
+	return text, usage, nil
+}
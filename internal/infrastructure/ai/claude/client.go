@@ -3,10 +3,12 @@ package claude
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai/prompt"
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
 	"github.com/anthropics/anthropic-sdk-go/packages/param"
@@ -18,10 +20,12 @@ import (
 
 // Client implements the ai.Provider interface for Anthropic Claude
 type Client struct {
-	client      anthropic.Client
-	model       anthropic.Model
-	temperature float64
-	maxTokens   int64
+	client         anthropic.Client
+	model          anthropic.Model
+	temperature    float64
+	maxTokens      int64
+	thinkingBudget int64
+	prompts        *prompt.Templates
 }
 
 // Ensure Client implements ai.Provider interface
@@ -36,6 +40,16 @@ func init() {
 	ai.RegisterProvider(ai.ProviderClaude, New)
 }
 
+// Provider-specific option keys (ai.Config.Options)
+const (
+	// OptionThinkingBudget enables extended thinking with a budget of this many tokens
+	// (an int of at least 1024), on top of the response's own max tokens
+	OptionThinkingBudget = "thinking_budget"
+)
+
+// minThinkingBudget is the smallest thinking budget accepted by the API
+const minThinkingBudget = 1024
+
 // New creates a new Claude provider (implements ai.ProviderFactory)
 func New(config ai.Config) (ai.Provider, error) {
 	if config.APIKey == "" {
@@ -60,15 +74,24 @@ func New(config ai.Config) (ai.Provider, error) {
 		maxTokens = 4096
 	}
 
+	thinkingBudget, _ := config.Options[OptionThinkingBudget].(int)
+	if thinkingBudget != 0 && thinkingBudget < minThinkingBudget {
+		return nil, fmt.Errorf("claude thinking budget must be at least %d tokens: %w", minThinkingBudget, ai.ErrInvalidConfig)
+	}
+
+	// Retries are handled by ai.RetryProvider, disable the SDK's own to avoid retrying twice
 	client := anthropic.NewClient(
 		option.WithAPIKey(config.APIKey),
+		option.WithMaxRetries(0),
 	)
 
 	return &Client{
-		client:      client,
-		model:       model,
-		temperature: temperature,
-		maxTokens:   maxTokens,
+		client:         client,
+		model:          model,
+		temperature:    temperature,
+		maxTokens:      maxTokens,
+		thinkingBudget: int64(thinkingBudget),
+		prompts:        config.Templates(),
 	}, nil
 }
 
@@ -82,61 +105,101 @@ func (c *Client) GenerateSQL(ctx context.Context, req *ai.GenerateRequest) (*ai.
 		return nil, ai.ErrEmptyPrompt
 	}
 
-	systemPrompt := buildSystemPrompt(req.Schema, req.DatabaseType, req.Context)
+	params, err := c.buildParams(req)
+	if err != nil {
+		return nil, err
+	}
 
-	message, err := c.client.Messages.New(ctx, anthropic.MessageNewParams{
-		Model:       c.model,
-		MaxTokens:   c.maxTokens,
-		Temperature: param.NewOpt(c.temperature),
-		System: []anthropic.TextBlockParam{
-			{Text: systemPrompt},
-		},
-		Messages: []anthropic.MessageParam{
-			anthropic.NewUserMessage(anthropic.NewTextBlock(req.Prompt)),
-		},
-	})
+	if req.Toolset != nil {
+		return c.generateWithTools(ctx, req.Toolset, params)
+	}
 
+	message, err := c.client.Messages.New(ctx, params)
 	if err != nil {
-		return nil, fmt.Errorf("claude API error: %w", err)
+		return nil, newAPIError(err)
 	}
 
-	if len(message.Content) == 0 {
-		return nil, ai.ErrGenerationFailed
+	return buildResponse(message)
+}
+
+// GenerateSQLStream generates a SQL query using Claude, streaming partial text to onChunk
+func (c *Client) GenerateSQLStream(ctx context.Context, req *ai.GenerateRequest, onChunk ai.StreamHandler) (*ai.GenerateResponse, error) {
+	if req.Prompt == "" {
+		return nil, ai.ErrEmptyPrompt
 	}
 
-	// Extract text from the first content block
-	var responseText string
-	firstBlock := message.Content[0]
+	// Tool rounds are not streamed
+	if req.Toolset != nil {
+		return c.GenerateSQL(ctx, req)
+	}
 
-	// Use tagged switch for better readability
-	switch firstBlock.Type {
-	case "thinking":
-		// Skip thinking blocks, get the next one
-		if len(message.Content) > 1 {
-			responseText = message.Content[1].AsText().Text
-		} else {
-			return nil, ai.ErrGenerationFailed
+	params, err := c.buildParams(req)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := c.client.Messages.NewStreaming(ctx, params)
+	defer func() { _ = stream.Close() }()
+
+	// Accumulate events into a complete message so usage and content blocks
+	// can be handled exactly like the non-streaming response
+	message := anthropic.Message{}
+	for stream.Next() {
+		event := stream.Current()
+		if err := message.Accumulate(event); err != nil {
+			return nil, newAPIError(err)
+		}
+
+		if delta, ok := event.AsAny().(anthropic.ContentBlockDeltaEvent); ok && onChunk != nil {
+			switch d := delta.Delta.AsAny().(type) {
+			case anthropic.TextDelta:
+				onChunk(d.Text)
+			case anthropic.InputJSONDelta:
+				// Structured output arrives as partial tool input JSON
+				onChunk(d.PartialJSON)
+			}
 		}
-	case "text":
-		responseText = firstBlock.AsText().Text
-	default:
-		return nil, ai.ErrGenerationFailed
 	}
 
-	query := cleanSQLResponse(responseText)
+	if err := stream.Err(); err != nil {
+		return nil, newAPIError(err)
+	}
 
-	return &ai.GenerateResponse{
-		Query:      query,
-		Confidence: 1.0, // Claude doesn't provide confidence scores
-		Usage: ai.UsageMetadata{
-			Provider:       "claude",
-			Model:          string(message.Model),
-			PromptTokens:   int(message.Usage.InputTokens),
-			ResponseTokens: int(message.Usage.OutputTokens),
-			TotalTokens:    int(message.Usage.InputTokens + message.Usage.OutputTokens),
-			CachedTokens:   int(message.Usage.CacheReadInputTokens + message.Usage.CacheCreationInputTokens),
+	return buildResponse(&message)
+}
+
+// Answer answers a question in plain language from a query result using Claude
+func (c *Client) Answer(ctx context.Context, req *ai.AnswerRequest) (*ai.AnswerResponse, error) {
+	return ai.Answer(ctx, c.prompts, c.complete, req)
+}
+
+// ExplainSQL explains a SQL query step by step in plain language using Claude
+func (c *Client) ExplainSQL(ctx context.Context, req *ai.ExplainRequest) (*ai.ExplainResponse, error) {
+	return ai.ExplainSQL(ctx, c.prompts, c.complete, req)
+}
+
+// complete sends a non-streaming chat request and returns the response text (ai.CompleteFunc)
+func (c *Client) complete(ctx context.Context, chat prompt.ChatPrompt) (string, ai.UsageMetadata, error) {
+	message, err := c.client.Messages.New(ctx, anthropic.MessageNewParams{
+		Model:     c.model,
+		MaxTokens: c.maxTokens,
+		System:    []anthropic.TextBlockParam{{Text: chat.System}},
+		Messages: []anthropic.MessageParam{
+			anthropic.NewUserMessage(anthropic.NewTextBlock(chat.User)),
 		},
-	}, nil
+	})
+	if err != nil {
+		return "", ai.UsageMetadata{}, newAPIError(err)
+	}
+
+	var text strings.Builder
+	for _, block := range message.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+
+	return text.String(), buildUsage(message), nil
 }
 
 // Name returns the provider name
@@ -154,47 +217,232 @@ func (c *Client) Close() error {
 // Helper Functions
 // ============================================
 
-// buildSystemPrompt constructs the system prompt with schema information
-func buildSystemPrompt(schema, dbType, context string) string {
-	prompt := `You are a helpful assistant that generates SQL queries based on natural language descriptions.
+// buildParams creates the message request shared by streaming and non-streaming calls
+func (c *Client) buildParams(req *ai.GenerateRequest) (anthropic.MessageNewParams, error) {
+	systemPrompt, err := c.prompts.System(prompt.Data{
+		DatabaseType: req.DatabaseType,
+		Schema:       req.Schema,
+		Context:      req.Context,
+	})
+	if err != nil {
+		return anthropic.MessageNewParams{}, err
+	}
+
+	temperature := c.temperature
+	if req.Temperature != nil {
+		temperature = *req.Temperature
+	}
+
+	// Previous conversation turns, then the current prompt
+	messages := make([]anthropic.MessageParam, 0, len(req.Messages)+1)
+	for _, message := range req.Messages {
+		block := anthropic.NewTextBlock(message.Content)
+		if message.Role == ai.RoleAssistant {
+			messages = append(messages, anthropic.NewAssistantMessage(block))
+		} else {
+			messages = append(messages, anthropic.NewUserMessage(block))
+		}
+	}
+	messages = append(messages, anthropic.NewUserMessage(anthropic.NewTextBlock(req.Prompt)))
+
+	params := anthropic.MessageNewParams{
+		Model:       c.model,
+		MaxTokens:   c.maxTokens,
+		Temperature: param.NewOpt(temperature),
+		System:      buildSystemBlocks(systemPrompt, !req.SchemaSelected || req.Toolset != nil),
+		Messages:    messages,
+		// Structured output: force the model to answer through the submit tool
+		Tools: []anthropic.ToolUnionParam{
+			{OfTool: &anthropic.ToolParam{
+				Name:        ai.StructuredResponseName,
+				Description: anthropic.String("Submit the generated SQL query together with its explanation, confidence and assumptions, or a clarification question"),
+				InputSchema: anthropic.ToolInputSchemaParam{
+					Properties: ai.ResponseSchemaProperties(),
+					Required:   ai.ResponseSchemaRequired(),
+				},
+			}},
+		},
+		ToolChoice: anthropic.ToolChoiceParamOfTool(ai.StructuredResponseName),
+	}
+
+	// Extended thinking doesn't support a custom temperature or forced tool use:
+	// the model is left to call the submit tool, as the system prompt asks.
+	// Thinking counts towards max tokens, the response keeps its own share.
+	if c.thinkingBudget > 0 {
+		params.MaxTokens += c.thinkingBudget
+		params.Thinking = anthropic.ThinkingConfigParamOfEnabled(c.thinkingBudget)
+		params.Temperature = param.Opt[float64]{}
+		params.ToolChoice = anthropic.ToolChoiceUnionParam{OfAuto: &anthropic.ToolChoiceAutoParam{}}
+	}
+
+	return params, nil
+}
+
+// generateWithTools lets the model call the tools of toolset, for up to ai.MaxToolRounds
+// rounds, before it answers through the structured response tool
+func (c *Client) generateWithTools(ctx context.Context, toolset ai.Toolset, params anthropic.MessageNewParams) (*ai.GenerateResponse, error) {
+	for _, tool := range toolset.Tools() {
+		params.Tools = append(params.Tools, anthropic.ToolUnionParam{
+			OfTool: &anthropic.ToolParam{
+				Name:        tool.Name,
+				Description: anthropic.String(tool.Description),
+				InputSchema: anthropic.ToolInputSchemaParam{
+					Properties: tool.Properties,
+					Required:   tool.Required,
+				},
+			},
+		})
+	}
+
+	// Any tool: exploring, or submitting the answer (extended thinking only allows auto)
+	thinking := c.thinkingBudget > 0
+	submit := params.Tools[0]
+	if !thinking {
+		params.ToolChoice = anthropic.ToolChoiceUnionParam{OfAny: &anthropic.ToolChoiceAnyParam{}}
+	}
+
+	var usage ai.UsageMetadata
+	var calls []ai.ToolCall
+	var reasoning []string
+	for round := 0; ; round++ {
+		// Out of rounds: the model must answer now (with thinking, by having no other tool left)
+		if round == ai.MaxToolRounds {
+			if thinking {
+				params.Tools = []anthropic.ToolUnionParam{submit}
+			} else {
+				params.ToolChoice = anthropic.ToolChoiceParamOfTool(ai.StructuredResponseName)
+			}
+		}
 
-You'll receive database schema information that includes tables, their columns, data types, constraints,
-and relationships between tables. Use this information to generate accurate SQL queries.
+		message, err := c.client.Messages.New(ctx, params)
+		if err != nil {
+			return nil, newAPIError(err)
+		}
+
+		if round == 0 {
+			usage = buildUsage(message)
+		} else {
+			usage.Add(buildUsage(message))
+		}
+
+		// Run the exploration tools called in this round, answering each by id,
+		// unless the model submitted its answer
+		var results []anthropic.ContentBlockParamUnion
+		for _, block := range message.Content {
+			if block.Type == "thinking" {
+				reasoning = append(reasoning, block.Thinking)
+			}
+			if block.Type != "tool_use" {
+				continue
+			}
+			if block.Name == ai.StructuredResponseName {
+				results = nil
+				break
+			}
+			result, call := ai.RunTool(ctx, toolset, block.Name, block.Input)
+			calls = append(calls, call)
+			results = append(results, anthropic.NewToolResultBlock(block.ID, result, call.Error != ""))
+		}
 
-Respond ONLY with the SQL query without any explanation or markdown formatting. Do not include any comments
-in the SQL or any additional text.`
+		if len(results) == 0 {
+			response, err := buildResponse(message)
+			if err != nil {
+				return nil, err
+			}
+			response.Usage = usage
+			response.ToolCalls = calls
+			response.Reasoning = ai.JoinReasoning(reasoning...)
+			return response, nil
+		}
 
-	if dbType != "" {
-		prompt += fmt.Sprintf("\n\nTarget database: %s", dbType)
+		params.Messages = append(params.Messages, message.ToParam(), anthropic.NewUserMessage(results...))
 	}
+}
 
-	if schema != "" {
-		prompt += fmt.Sprintf("\n\n%s", schema)
+// buildSystemBlocks splits the system prompt into a static block (instructions and
+// schema), cached when cache is set (a schema selected for the prompt is only read
+// again by its own tool rounds), and an uncached volatile block. The cache breakpoint
+// on the static block also covers the tool definition, which precedes the system prompt
+// in the cache prefix.
+func buildSystemBlocks(systemPrompt prompt.SystemPrompt, cache bool) []anthropic.TextBlockParam {
+	blocks := []anthropic.TextBlockParam{{Text: systemPrompt.Static}}
+	if cache {
+		blocks[0].CacheControl = anthropic.NewCacheControlEphemeralParam()
 	}
 
-	if context != "" {
-		prompt += fmt.Sprintf("\n\n%s", context)
+	if systemPrompt.Volatile != "" {
+		blocks = append(blocks, anthropic.TextBlockParam{Text: systemPrompt.Volatile})
 	}
 
-	return prompt
+	return blocks
 }
 
-// cleanSQLResponse removes markdown formatting from AI response
-func cleanSQLResponse(response string) string {
-	// Remove markdown code blocks
-	query := strings.TrimSpace(response)
+// buildResponse extracts the SQL and usage from a complete Claude message
+func buildResponse(message *anthropic.Message) (*ai.GenerateResponse, error) {
+	if len(message.Content) == 0 {
+		return nil, ai.ErrGenerationFailed
+	}
 
-	// Check for opening code block with language identifier
-	if strings.HasPrefix(query, "```") {
-		lines := strings.Split(query, "\n")
-		if len(lines) > 2 {
-			// Remove first line (```sql or ```)
-			query = strings.Join(lines[1:], "\n")
+	// Find the structured tool call, falling back to plain text for models that answer directly
+	var structured *ai.StructuredResponse
+	var thinking []string
+	for _, block := range message.Content {
+		switch block.Type {
+		case "thinking":
+			thinking = append(thinking, block.Thinking)
+		case "tool_use":
+			if block.Name == ai.StructuredResponseName {
+				structured = ai.ParseStructuredResponse(string(block.Input))
+			}
+		case "text":
+			if structured == nil {
+				structured = ai.ParseStructuredResponse(block.Text)
+			}
 		}
 	}
 
-	// Remove closing code block - TrimSuffix is idempotent
-	query = strings.TrimSuffix(query, "```")
+	if structured == nil {
+		return nil, ai.ErrGenerationFailed
+	}
+
+	return &ai.GenerateResponse{
+		Query:         prompt.CleanSQL(structured.SQL),
+		Confidence:    structured.Confidence,
+		Explanation:   structured.Explanation,
+		Assumptions:   structured.Assumptions,
+		Clarification: structured.Clarification,
+		Reasoning:     ai.JoinReasoning(append(thinking, structured.Reasoning)...),
+		Usage:         buildUsage(message),
+	}, nil
+}
+
+// buildUsage converts the token usage of a Claude message into ai.UsageMetadata
+func buildUsage(message *anthropic.Message) ai.UsageMetadata {
+	// InputTokens only counts the uncached part of the prompt
+	promptTokens := message.Usage.InputTokens + message.Usage.CacheReadInputTokens + message.Usage.CacheCreationInputTokens
+
+	return ai.UsageMetadata{
+		Provider:         "claude",
+		Model:            string(message.Model),
+		PromptTokens:     int(promptTokens),
+		ResponseTokens:   int(message.Usage.OutputTokens),
+		TotalTokens:      int(promptTokens + message.Usage.OutputTokens),
+		CachedTokens:     int(message.Usage.CacheReadInputTokens),
+		CacheWriteTokens: int(message.Usage.CacheCreationInputTokens),
+	}
+}
+
+// newAPIError wraps an SDK error into an ai.APIError carrying the HTTP status
+func newAPIError(err error) error {
+	apiErr := &ai.APIError{Provider: "claude", Err: err}
+
+	var respErr *anthropic.Error
+	if errors.As(err, &respErr) {
+		apiErr.StatusCode = respErr.StatusCode
+		if respErr.Response != nil {
+			apiErr.RetryAfter = ai.ParseRetryAfter(respErr.Response.Header.Get("Retry-After"))
+		}
+	}
 
-	return strings.TrimSpace(query)
+	return apiErr
 }
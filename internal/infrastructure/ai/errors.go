@@ -1,7 +1,15 @@
 // Package ai defines errors related to AI provider operations and configuration.
 package ai
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
 
 // Sentinel errors returned by AI providers and the provider factory.
 var (
@@ -17,3 +25,90 @@ var (
 	// ErrEmptyPrompt is returned when the prompt is empty
 	ErrEmptyPrompt = errors.New("prompt cannot be empty")
 )
+
+// APIError is returned when a call to a provider's remote API fails.
+// It records the HTTP status (when known) so callers can tell transient failures
+// such as rate limits and outages apart from permanent ones.
+type APIError struct {
+	// Provider name (e.g., "openai", "claude")
+	Provider string
+
+	// HTTP status code, 0 when the request never got a response (network errors)
+	StatusCode int
+
+	// Delay requested by the provider before retrying (Retry-After), 0 when unknown
+	RetryAfter time.Duration
+
+	// Underlying SDK error
+	Err error
+}
+
+func (e *APIError) Error() string {
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests && e.RetryAfter > 0:
+		return fmt.Sprintf("%s rate limit exceeded, try again in %s", e.Provider, e.RetryAfter.Round(time.Second))
+	case e.StatusCode == http.StatusTooManyRequests:
+		return fmt.Sprintf("%s rate limit exceeded, try again later", e.Provider)
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return fmt.Sprintf("%s rejected the API key (HTTP %d)", e.Provider, e.StatusCode)
+	case e.StatusCode >= http.StatusInternalServerError:
+		return fmt.Sprintf("%s service unavailable (HTTP %d)", e.Provider, e.StatusCode)
+	default:
+		return fmt.Sprintf("%s API error: %v", e.Provider, e.Err)
+	}
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// IsTransient reports whether err is a temporary provider failure that may succeed
+// when retried or sent to another provider: rate limits (429), timeouts, 5xx server
+// errors and network failures. Cancellation by the caller is never transient.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode != 0 {
+		return apiErr.StatusCode == http.StatusTooManyRequests ||
+			apiErr.StatusCode == http.StatusRequestTimeout ||
+			apiErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	// Connection refused, DNS failures, dial/read timeouts, ...
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// RetryAfter returns the delay requested by the provider before retrying err, 0 if none
+func RetryAfter(err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.RetryAfter
+	}
+	return 0
+}
+
+// ParseRetryAfter parses a Retry-After header value, either delay seconds ("20")
+// or an HTTP date. It returns 0 for empty or malformed values.
+func ParseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return max(time.Duration(seconds*float64(time.Second)), 0)
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+
+	return 0
+}
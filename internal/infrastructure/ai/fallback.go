@@ -0,0 +1,128 @@
+// Package ai provides a composite provider that falls back to alternative providers on outages.
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FallbackProvider wraps an ordered list of providers. Requests go to the first provider;
+// when it fails with a transient error (rate limit, timeout, 5xx, network failure) the next
+// provider is tried, and so on. Permanent errors (invalid key, bad request) are returned as-is.
+type FallbackProvider struct {
+	providers []Provider
+}
+
+// Ensure FallbackProvider implements Provider interface
+var _ Provider = (*FallbackProvider)(nil)
+
+// NewFallbackProvider creates a provider that tries providers in order
+func NewFallbackProvider(providers ...Provider) *FallbackProvider {
+	return &FallbackProvider{
+		providers: providers,
+	}
+}
+
+// GenerateSQL generates SQL with the first provider that does not fail transiently
+func (f *FallbackProvider) GenerateSQL(ctx context.Context, req *GenerateRequest) (*GenerateResponse, error) {
+	return try(ctx, f, func(p Provider) (*GenerateResponse, error) {
+		return p.GenerateSQL(ctx, req)
+	}, func(resp *GenerateResponse) *UsageMetadata {
+		return &resp.Usage
+	})
+}
+
+// GenerateSQLStream streams SQL from the first provider that does not fail transiently.
+// When a provider fails after streaming part of its output, onChunk receives StreamReset
+// before the next provider starts, so the partial output is not mixed with the new one.
+func (f *FallbackProvider) GenerateSQLStream(ctx context.Context, req *GenerateRequest, onChunk StreamHandler) (*GenerateResponse, error) {
+	streamed := false
+	handler := func(chunk string) {
+		streamed = true
+		if onChunk != nil {
+			onChunk(chunk)
+		}
+	}
+
+	return try(ctx, f, func(p Provider) (*GenerateResponse, error) {
+		if streamed {
+			streamed = false
+			if onChunk != nil {
+				onChunk(StreamReset)
+			}
+		}
+		return p.GenerateSQLStream(ctx, req, handler)
+	}, func(resp *GenerateResponse) *UsageMetadata {
+		return &resp.Usage
+	})
+}
+
+// Answer answers with the first provider that does not fail transiently
+func (f *FallbackProvider) Answer(ctx context.Context, req *AnswerRequest) (*AnswerResponse, error) {
+	return try(ctx, f, func(p Provider) (*AnswerResponse, error) {
+		return p.Answer(ctx, req)
+	}, func(resp *AnswerResponse) *UsageMetadata {
+		return &resp.Usage
+	})
+}
+
+// ExplainSQL explains SQL with the first provider that does not fail transiently
+func (f *FallbackProvider) ExplainSQL(ctx context.Context, req *ExplainRequest) (*ExplainResponse, error) {
+	return try(ctx, f, func(p Provider) (*ExplainResponse, error) {
+		return p.ExplainSQL(ctx, req)
+	}, func(resp *ExplainResponse) *UsageMetadata {
+		return &resp.Usage
+	})
+}
+
+// Name returns the names of the wrapped providers, in order (e.g., "claude,openai,ollama")
+func (f *FallbackProvider) Name() string {
+	names := make([]string, len(f.providers))
+	for i, p := range f.providers {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// Close releases the resources of every wrapped provider
+func (f *FallbackProvider) Close() error {
+	var errs []error
+	for _, p := range f.providers {
+		if err := p.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// try calls each provider of f in order until one succeeds or fails permanently.
+// The providers that were skipped are recorded in the usage metadata returned by usage.
+func try[T any](ctx context.Context, f *FallbackProvider, call func(Provider) (T, error), usage func(T) *UsageMetadata) (T, error) {
+	var zero T
+	var failed []string
+	var lastErr error
+
+	for i, p := range f.providers {
+		resp, err := call(p)
+		if err == nil {
+			usage(resp).FallbackFrom = failed
+			return resp, nil
+		}
+		lastErr = err
+
+		// Stop on permanent errors, on the last provider, or when the caller gave up
+		if !IsTransient(err) || i == len(f.providers)-1 || ctx.Err() != nil {
+			break
+		}
+
+		failed = append(failed, p.Name())
+	}
+
+	if len(failed) == 0 {
+		return zero, lastErr
+	}
+
+	return zero, fmt.Errorf("%w (after transient failures from %s)", lastErr, strings.Join(failed, ", "))
+}
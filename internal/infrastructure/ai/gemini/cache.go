@@ -0,0 +1,123 @@
+// Package gemini provides explicit context caching of the static system prompt.
+package gemini
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+	"google.golang.org/genai"
+)
+
+const (
+	// cacheTTL is how long a cached system prompt lives on Google's side
+	cacheTTL = 30 * time.Minute
+
+	// cacheRefreshMargin recreates a cache shortly before it expires
+	cacheRefreshMargin = 1 * time.Minute
+
+	// cacheMinChars skips caching for short prompts: Gemini rejects caches below
+	// a model-dependent minimum (1024-4096 tokens, roughly 4 characters each)
+	cacheMinChars = 4096
+
+	// cacheCleanupTimeout bounds the cache deletion on Close
+	cacheCleanupTimeout = 5 * time.Second
+)
+
+// promptCache holds the cached content created for the current static system prompt
+type promptCache struct {
+	mu       sync.Mutex
+	key      string          // hash of the cached system prompt
+	name     string          // cached content resource name
+	expires  time.Time       // expiry of the cached content
+	creating string          // hash of the system prompt being cached, if any
+	failed   map[string]bool // prompts that could not be cached, not retried
+}
+
+// cachedContent returns the name of a cached content holding systemPrompt, creating it
+// if needed. It returns "" when the prompt is too short or caching is not available,
+// in which case the system prompt must be sent inline. The cache is created without
+// holding the lock: requests made meanwhile send the prompt inline rather than wait.
+func (c *Client) cachedContent(ctx context.Context, systemPrompt string) string {
+	if len(systemPrompt) < cacheMinChars {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(c.model + "\x00" + systemPrompt))
+	key := hex.EncodeToString(sum[:])
+
+	c.cache.mu.Lock()
+	if c.cache.key == key && time.Until(c.cache.expires) > cacheRefreshMargin {
+		defer c.cache.mu.Unlock()
+		return c.cache.name
+	}
+	if c.cache.failed[key] || c.cache.creating != "" {
+		c.cache.mu.Unlock()
+		return ""
+	}
+	c.cache.creating = key
+	c.cache.mu.Unlock()
+
+	cached, err := c.client.Caches.Create(ctx, c.model, &genai.CreateCachedContentConfig{
+		DisplayName:       "asqli system prompt",
+		TTL:               cacheTTL,
+		SystemInstruction: genai.NewContentFromText(systemPrompt, genai.RoleUser),
+	})
+
+	c.cache.mu.Lock()
+	c.cache.creating = ""
+	if err != nil {
+		defer c.cache.mu.Unlock()
+
+		// Rate limits, server errors, timeouts and cancellations may not happen again
+		if ctx.Err() != nil || ai.IsTransient(newAPIError(err)) {
+			return ""
+		}
+
+		// Unsupported model, prompt below the minimum size, ...: don't try again
+		if c.cache.failed == nil {
+			c.cache.failed = make(map[string]bool)
+		}
+		c.cache.failed[key] = true
+		return ""
+	}
+
+	// The schema changed (or the cache expired): the previous cache is dropped
+	previous := c.cache.name
+	c.cache.key = key
+	c.cache.name = cached.Name
+	c.cache.expires = time.Now().Add(cacheTTL)
+	if !cached.ExpireTime.IsZero() {
+		c.cache.expires = cached.ExpireTime
+	}
+	c.cache.mu.Unlock()
+
+	c.deleteCachedContent(ctx, previous)
+	return cached.Name
+}
+
+// deleteCachedContent deletes a cached content by name (best effort)
+func (c *Client) deleteCachedContent(ctx context.Context, name string) {
+	if name == "" {
+		return
+	}
+
+	_, _ = c.client.Caches.Delete(ctx, name, nil)
+}
+
+// releaseCache deletes the cached content so it stops accruing storage costs
+func (c *Client) releaseCache() {
+	c.cache.mu.Lock()
+	name := c.cache.name
+	c.cache.key = ""
+	c.cache.name = ""
+	c.cache.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cacheCleanupTimeout)
+	defer cancel()
+
+	c.deleteCachedContent(ctx, name)
+}
@@ -3,10 +3,14 @@ package gemini
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai/prompt"
 	"google.golang.org/genai"
 )
 
@@ -20,6 +24,8 @@ type Client struct {
 	model       string
 	temperature float64
 	maxTokens   int
+	prompts     *prompt.Templates
+	cache       promptCache
 }
 
 // Ensure Client implements ai.Provider interface
@@ -68,6 +74,7 @@ func New(config ai.Config) (ai.Provider, error) {
 		model:       model,
 		temperature: temperature,
 		maxTokens:   config.MaxTokens,
+		prompts:     config.Templates(),
 	}, nil
 }
 
@@ -81,37 +88,19 @@ func (c *Client) GenerateSQL(ctx context.Context, req *ai.GenerateRequest) (*ai.
 		return nil, ai.ErrEmptyPrompt
 	}
 
-	systemPrompt := buildSystemPrompt(req.Schema, req.DatabaseType, req.Context)
-
-	// Build the full prompt with system instructions and user query
-	fullPrompt := fmt.Sprintf("%s\n\nUser query: %s", systemPrompt, req.Prompt)
-
-	// Create content parts
-	parts := []*genai.Part{
-		{Text: fullPrompt},
+	contents, generationConfig, err := c.buildContents(ctx, req)
+	if err != nil {
+		return nil, err
 	}
 
-	// Create generation config
-	var generationConfig *genai.GenerateContentConfig
-	if c.maxTokens > 0 || c.temperature != 0 {
-		generationConfig = &genai.GenerateContentConfig{}
-		if c.maxTokens > 0 {
-			generationConfig.MaxOutputTokens = int32(c.maxTokens)
-		}
-		if c.temperature != 0 {
-			generationConfig.Temperature = genai.Ptr(float32(c.temperature))
-		}
+	if req.Toolset != nil {
+		return c.generateWithTools(ctx, req.Toolset, contents, generationConfig)
 	}
 
 	// Generate content
-	result, err := c.client.Models.GenerateContent(
-		ctx,
-		c.model,
-		[]*genai.Content{{Parts: parts}},
-		generationConfig,
-	)
+	result, err := c.client.Models.GenerateContent(ctx, c.model, contents, generationConfig)
 	if err != nil {
-		return nil, fmt.Errorf("gemini API error: %w", err)
+		return nil, newAPIError(err)
 	}
 
 	// Extract text from response
@@ -119,39 +108,75 @@ func (c *Client) GenerateSQL(ctx context.Context, req *ai.GenerateRequest) (*ai.
 		return nil, ai.ErrGenerationFailed
 	}
 
-	var queryText string
-	for _, part := range result.Candidates[0].Content.Parts {
-		if part.Text != "" {
-			queryText += part.Text
-		}
-	}
+	return c.buildResponse(extractText(result), result.UsageMetadata)
+}
 
-	if queryText == "" {
-		return nil, ai.ErrGenerationFailed
+// GenerateSQLStream generates a SQL query using Gemini, streaming partial text to onChunk
+func (c *Client) GenerateSQLStream(ctx context.Context, req *ai.GenerateRequest, onChunk ai.StreamHandler) (*ai.GenerateResponse, error) {
+	if req.Prompt == "" {
+		return nil, ai.ErrEmptyPrompt
 	}
 
-	query := cleanSQLResponse(queryText)
+	// Tool rounds are not streamed
+	if req.Toolset != nil {
+		return c.GenerateSQL(ctx, req)
+	}
 
-	// Build usage metadata
-	usage := ai.UsageMetadata{
-		Provider: "gemini",
-		Model:    c.model,
+	contents, generationConfig, err := c.buildContents(ctx, req)
+	if err != nil {
+		return nil, err
 	}
 
-	if result.UsageMetadata != nil {
-		usage.PromptTokens = int(result.UsageMetadata.PromptTokenCount)
-		usage.ResponseTokens = int(result.UsageMetadata.CandidatesTokenCount)
-		usage.TotalTokens = int(result.UsageMetadata.TotalTokenCount)
-		if result.UsageMetadata.CachedContentTokenCount > 0 {
-			usage.CachedTokens = int(result.UsageMetadata.CachedContentTokenCount)
+	var queryText strings.Builder
+	var usageMetadata *genai.GenerateContentResponseUsageMetadata
+
+	for result, err := range c.client.Models.GenerateContentStream(ctx, c.model, contents, generationConfig) {
+		if err != nil {
+			return nil, newAPIError(err)
+		}
+
+		// Usage metadata is cumulative, the last chunk holds the final counts
+		if result.UsageMetadata != nil {
+			usageMetadata = result.UsageMetadata
+		}
+
+		if chunk := extractText(result); chunk != "" {
+			queryText.WriteString(chunk)
+			if onChunk != nil {
+				onChunk(chunk)
+			}
 		}
 	}
 
-	return &ai.GenerateResponse{
-		Query:      query,
-		Confidence: 1.0, // Gemini doesn't provide confidence scores
-		Usage:      usage,
-	}, nil
+	return c.buildResponse(queryText.String(), usageMetadata)
+}
+
+// Answer answers a question in plain language from a query result using Gemini
+func (c *Client) Answer(ctx context.Context, req *ai.AnswerRequest) (*ai.AnswerResponse, error) {
+	return ai.Answer(ctx, c.prompts, c.complete, req)
+}
+
+// ExplainSQL explains a SQL query step by step in plain language using Gemini
+func (c *Client) ExplainSQL(ctx context.Context, req *ai.ExplainRequest) (*ai.ExplainResponse, error) {
+	return ai.ExplainSQL(ctx, c.prompts, c.complete, req)
+}
+
+// complete sends a non-streaming chat request and returns the response text (ai.CompleteFunc)
+func (c *Client) complete(ctx context.Context, chat prompt.ChatPrompt) (string, ai.UsageMetadata, error) {
+	generationConfig := &genai.GenerateContentConfig{
+		SystemInstruction: genai.NewContentFromText(chat.System, genai.RoleUser),
+	}
+	if c.maxTokens > 0 {
+		generationConfig.MaxOutputTokens = int32(c.maxTokens)
+	}
+
+	contents := []*genai.Content{genai.NewContentFromText(chat.User, genai.RoleUser)}
+	result, err := c.client.Models.GenerateContent(ctx, c.model, contents, generationConfig)
+	if err != nil {
+		return "", ai.UsageMetadata{}, newAPIError(err)
+	}
+
+	return extractText(result), c.buildUsage(result.UsageMetadata), nil
 }
 
 // Name returns the provider name
@@ -161,7 +186,8 @@ func (c *Client) Name() string {
 
 // Close releases any resources held by the provider
 func (c *Client) Close() error {
-	// Gemini client doesn't need explicit cleanup
+	// Delete the cached system prompt, the Gemini client itself needs no cleanup
+	c.releaseCache()
 	return nil
 }
 
@@ -169,47 +195,226 @@ func (c *Client) Close() error {
 // Helper Functions
 // ============================================
 
-// buildSystemPrompt constructs the system prompt with schema information
-func buildSystemPrompt(schema, dbType, context string) string {
-	prompt := `You are a helpful assistant that generates SQL queries based on natural language descriptions.
+// buildContents creates the request contents and generation config shared by streaming and non-streaming calls.
+// The static system prompt (instructions and schema) is served from Gemini's context cache when possible.
+func (c *Client) buildContents(ctx context.Context, req *ai.GenerateRequest) ([]*genai.Content, *genai.GenerateContentConfig, error) {
+	systemPrompt, err := c.prompts.System(prompt.Data{
+		DatabaseType: req.DatabaseType,
+		Schema:       req.Schema,
+		Context:      req.Context,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
 
-You'll receive database schema information that includes tables, their columns, data types, constraints,
-and relationships between tables. Use this information to generate accurate SQL queries.
+	// Build the user turn with the per-request context and the user query
+	userPrompt := fmt.Sprintf("User query: %s", req.Prompt)
+	if systemPrompt.Volatile != "" {
+		userPrompt = fmt.Sprintf("%s\n\n%s", systemPrompt.Volatile, userPrompt)
+	}
 
-Respond ONLY with the SQL query without any explanation or markdown formatting. Do not include any comments
-in the SQL or any additional text.`
+	// Create content parts
+	parts := []*genai.Part{
+		{Text: userPrompt},
+	}
 
-	if dbType != "" {
-		prompt += fmt.Sprintf("\n\nTarget database: %s", dbType)
+	// Create generation config requesting JSON that matches the structured response schema
+	generationConfig := &genai.GenerateContentConfig{
+		ResponseMIMEType:   "application/json",
+		ResponseJsonSchema: ai.ResponseSchema(),
+	}
+	if c.maxTokens > 0 {
+		generationConfig.MaxOutputTokens = int32(c.maxTokens)
+	}
+	if req.Temperature != nil {
+		generationConfig.Temperature = genai.Ptr(float32(*req.Temperature))
+	} else if c.temperature != 0 {
+		generationConfig.Temperature = genai.Ptr(float32(c.temperature))
 	}
 
-	if schema != "" {
-		prompt += fmt.Sprintf("\n\n%s", schema)
+	// Cached content already contains the system instruction, which can't be repeated.
+	// Requests with tools can't use it either, their tools would have to be cached too
+	cachedContent := ""
+	if req.Toolset == nil && !req.SchemaSelected {
+		cachedContent = c.cachedContent(ctx, systemPrompt.Static)
 	}
+	if cachedContent != "" {
+		generationConfig.CachedContent = cachedContent
+	} else {
+		generationConfig.SystemInstruction = genai.NewContentFromText(systemPrompt.Static, genai.RoleUser)
+	}
+
+	// Previous conversation turns, then the current user turn
+	contents := make([]*genai.Content, 0, len(req.Messages)+1)
+	for _, message := range req.Messages {
+		role := genai.RoleUser
+		if message.Role == ai.RoleAssistant {
+			role = genai.RoleModel
+		}
+		contents = append(contents, genai.NewContentFromText(message.Content, genai.Role(role)))
+	}
+	contents = append(contents, &genai.Content{Role: genai.RoleUser, Parts: parts})
+
+	return contents, generationConfig, nil
+}
+
+// generateWithTools lets the model call the tools of toolset, for up to ai.MaxToolRounds
+// rounds, before it answers with the structured response
+func (c *Client) generateWithTools(ctx context.Context, toolset ai.Toolset, contents []*genai.Content, generationConfig *genai.GenerateContentConfig) (*ai.GenerateResponse, error) {
+	declarations := make([]*genai.FunctionDeclaration, 0, len(toolset.Tools()))
+	for _, tool := range toolset.Tools() {
+		declarations = append(declarations, &genai.FunctionDeclaration{
+			Name:                 tool.Name,
+			Description:          tool.Description,
+			ParametersJsonSchema: tool.Schema(),
+		})
+	}
+
+	// JSON mode can't be combined with function calling, the system prompt asks for JSON anyway
+	mimeType, schema := generationConfig.ResponseMIMEType, generationConfig.ResponseJsonSchema
+	generationConfig.ResponseMIMEType, generationConfig.ResponseJsonSchema = "", nil
+	generationConfig.Tools = []*genai.Tool{{FunctionDeclarations: declarations}}
+
+	var usage ai.UsageMetadata
+	var calls []ai.ToolCall
+	for round := 0; ; round++ {
+		// Out of rounds: the model must answer now
+		if round == ai.MaxToolRounds {
+			generationConfig.Tools = nil
+			generationConfig.ResponseMIMEType, generationConfig.ResponseJsonSchema = mimeType, schema
+		}
+
+		result, err := c.client.Models.GenerateContent(ctx, c.model, contents, generationConfig)
+		if err != nil {
+			return nil, newAPIError(err)
+		}
+		if len(result.Candidates) == 0 || result.Candidates[0].Content == nil {
+			return nil, ai.ErrGenerationFailed
+		}
+
+		if round == 0 {
+			usage = c.buildUsage(result.UsageMetadata)
+		} else {
+			usage.Add(c.buildUsage(result.UsageMetadata))
+		}
+
+		functionCalls := result.FunctionCalls()
+		if len(functionCalls) == 0 {
+			response, err := c.buildResponse(extractText(result), result.UsageMetadata)
+			if err != nil {
+				return nil, err
+			}
+			response.Usage = usage
+			response.ToolCalls = calls
+			return response, nil
+		}
+
+		// Answer every function call in a single user turn
+		contents = append(contents, result.Candidates[0].Content)
+		parts := make([]*genai.Part, 0, len(functionCalls))
+		for _, functionCall := range functionCalls {
+			arguments, err := json.Marshal(functionCall.Args)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode tool arguments: %w", err)
+			}
+
+			result, call := ai.RunTool(ctx, toolset, functionCall.Name, arguments)
+			calls = append(calls, call)
+
+			response := map[string]any{"output": result}
+			if call.Error != "" {
+				response = map[string]any{"error": result}
+			}
+			parts = append(parts, &genai.Part{FunctionResponse: &genai.FunctionResponse{
+				ID:       functionCall.ID,
+				Name:     functionCall.Name,
+				Response: response,
+			}})
+		}
+		contents = append(contents, genai.NewContentFromParts(parts, genai.RoleUser))
+	}
+}
+
+// extractText concatenates the text parts of the first candidate
+func extractText(result *genai.GenerateContentResponse) string {
+	if len(result.Candidates) == 0 || result.Candidates[0].Content == nil {
+		return ""
+	}
+
+	var text string
+	for _, part := range result.Candidates[0].Content.Parts {
+		if part.Text != "" {
+			text += part.Text
+		}
+	}
+
+	return text
+}
 
-	if context != "" {
-		prompt += fmt.Sprintf("\n\n%s", context)
+// buildResponse converts the response text and usage metadata into an ai.GenerateResponse
+func (c *Client) buildResponse(queryText string, usageMetadata *genai.GenerateContentResponseUsageMetadata) (*ai.GenerateResponse, error) {
+	if queryText == "" {
+		return nil, ai.ErrGenerationFailed
 	}
 
-	return prompt
+	structured := ai.ParseStructuredResponse(queryText)
+
+	return &ai.GenerateResponse{
+		Query:         prompt.CleanSQL(structured.SQL),
+		Confidence:    structured.Confidence,
+		Explanation:   structured.Explanation,
+		Assumptions:   structured.Assumptions,
+		Clarification: structured.Clarification,
+		Reasoning:     structured.Reasoning,
+		Usage:         c.buildUsage(usageMetadata),
+	}, nil
 }
 
-// cleanSQLResponse removes markdown formatting from AI response
-func cleanSQLResponse(response string) string {
-	// Remove markdown code blocks
-	query := strings.TrimSpace(response)
+// buildUsage converts Gemini usage metadata (which may be nil) into ai.UsageMetadata
+func (c *Client) buildUsage(usageMetadata *genai.GenerateContentResponseUsageMetadata) ai.UsageMetadata {
+	usage := ai.UsageMetadata{
+		Provider: "gemini",
+		Model:    c.model,
+	}
 
-	// Check for opening code block with language identifier
-	if strings.HasPrefix(query, "```") {
-		lines := strings.Split(query, "\n")
-		if len(lines) > 2 {
-			// Remove first line (```sql or ```)
-			query = strings.Join(lines[1:], "\n")
+	if usageMetadata != nil {
+		usage.PromptTokens = int(usageMetadata.PromptTokenCount)
+		// Thinking tokens are billed as output, but not counted with the candidates
+		usage.ResponseTokens = int(usageMetadata.CandidatesTokenCount + usageMetadata.ThoughtsTokenCount)
+		usage.TotalTokens = int(usageMetadata.TotalTokenCount)
+		if usageMetadata.CachedContentTokenCount > 0 {
+			usage.CachedTokens = int(usageMetadata.CachedContentTokenCount)
 		}
 	}
 
-	// Remove closing code block - TrimSuffix is idempotent
-	query = strings.TrimSuffix(query, "```")
+	return usage
+}
+
+// newAPIError wraps an SDK error into an ai.APIError carrying the HTTP status
+func newAPIError(err error) error {
+	apiErr := &ai.APIError{Provider: "gemini", Err: err}
+
+	var respErr genai.APIError
+	if errors.As(err, &respErr) {
+		apiErr.StatusCode = respErr.Code
+		apiErr.RetryAfter = retryDelay(respErr.Details)
+	}
+
+	return apiErr
+}
 
-	return strings.TrimSpace(query)
+// retryDelay extracts the retry delay from the google.rpc.RetryInfo error detail
+// (e.g. {"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "20s"})
+func retryDelay(details []map[string]any) time.Duration {
+	for _, detail := range details {
+		if kind, _ := detail["@type"].(string); !strings.HasSuffix(kind, "google.rpc.RetryInfo") {
+			continue
+		}
+		if value, ok := detail["retryDelay"].(string); ok {
+			if delay, err := time.ParseDuration(value); err == nil {
+				return delay
+			}
+		}
+	}
+	return 0
 }
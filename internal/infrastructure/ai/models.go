@@ -0,0 +1,93 @@
+// Package ai provides the context window sizes and token estimates of known models.
+package ai
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// ModelLimits describes how much a model can read and how densely its tokenizer encodes text
+type ModelLimits struct {
+	// Tokens shared by the prompt and the response
+	ContextWindow int
+
+	// Average characters of English text, SQL and schema definitions per token
+	CharsPerToken float64
+}
+
+// CountTokens estimates the tokens of text for the model's tokenizer. ASCII text is
+// counted by CharsPerToken; other characters (accents, CJK, emoji) count as a token each,
+// which slightly overestimates, so requests stay on the safe side of the window.
+func (l ModelLimits) CountTokens(text string) int {
+	ascii := 0
+	other := 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+
+	charsPerToken := l.CharsPerToken
+	if charsPerToken <= 0 {
+		charsPerToken = 4
+	}
+	return int(float64(ascii)/charsPerToken+0.999) + other
+}
+
+// ModelTable maps "provider/model" keys (e.g. "openai/gpt-4o") to model limits.
+// Models are matched by longest prefix, like cost.PriceTable; the "provider/" entries
+// cover unknown models and the provider's default model.
+type ModelTable map[string]ModelLimits
+
+// DefaultModels returns the built-in model table. Ollama models get a conservative window,
+// since the context length depends on how the model is run.
+func DefaultModels() ModelTable {
+	return ModelTable{
+		// OpenAI (o200k tokenizer)
+		"openai/":        {ContextWindow: 128_000, CharsPerToken: 3.8},
+		"openai/gpt-5":   {ContextWindow: 400_000, CharsPerToken: 3.8},
+		"openai/gpt-4.1": {ContextWindow: 1_047_576, CharsPerToken: 3.8},
+		"openai/o3":      {ContextWindow: 200_000, CharsPerToken: 3.8},
+		"openai/o4-mini": {ContextWindow: 200_000, CharsPerToken: 3.8},
+
+		// Anthropic (tokenizer denser on code and identifiers)
+		"claude/": {ContextWindow: 200_000, CharsPerToken: 3.3},
+
+		// Google
+		"gemini/": {ContextWindow: 1_048_576, CharsPerToken: 3.8},
+
+		// Local models (Llama-style tokenizers) and recorded responses
+		"ollama/": {ContextWindow: 8192, CharsPerToken: 3.5},
+		"replay/": {ContextWindow: 1_000_000, CharsPerToken: 4},
+	}
+}
+
+// Lookup returns the limits of a provider's model, matching the longest known prefix.
+// Unknown providers get a 128k window.
+func (t ModelTable) Lookup(provider, model string) ModelLimits {
+	key := provider + "/" + model
+
+	var best string
+	limits := ModelLimits{ContextWindow: 128_000, CharsPerToken: 4}
+	for prefix, l := range t {
+		if strings.HasPrefix(key, prefix) && len(prefix) >= len(best) {
+			best, limits = prefix, l
+		}
+	}
+
+	return limits
+}
+
+// ChainLimits returns the limits every provider of config and its fallbacks can handle:
+// the smallest context window and the densest tokenizer of the chain
+func (t ModelTable) ChainLimits(config Config) ModelLimits {
+	limits := t.Lookup(string(config.Type), config.Model)
+	for _, fallback := range config.Fallbacks {
+		other := t.ChainLimits(fallback)
+		limits.ContextWindow = min(limits.ContextWindow, other.ContextWindow)
+		limits.CharsPerToken = min(limits.CharsPerToken, other.CharsPerToken)
+	}
+	return limits
+}
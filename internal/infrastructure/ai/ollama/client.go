@@ -3,12 +3,14 @@ package ollama
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
-	"strings"
 
 	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai/prompt"
 	"github.com/ollama/ollama/api"
 )
 
@@ -18,9 +20,11 @@ import (
 
 // Client implements the ai.Provider interface for Ollama
 type Client struct {
-	client  *api.Client
-	model   string
-	baseURL string
+	client         *api.Client
+	model          string
+	baseURL        string
+	responseSchema json.RawMessage
+	prompts        *prompt.Templates
 }
 
 // Ensure Client implements ai.Provider interface
@@ -37,22 +41,9 @@ func init() {
 
 // New creates a new Ollama provider (implements ai.ProviderFactory)
 func New(config ai.Config) (ai.Provider, error) {
-	var client *api.Client
-	var err error
-
-	// If custom base URL is provided, use NewClient
-	if config.BaseURL != "" {
-		baseURL, parseErr := url.Parse(config.BaseURL)
-		if parseErr != nil {
-			return nil, fmt.Errorf("invalid base URL: %w", parseErr)
-		}
-		client = api.NewClient(baseURL, http.DefaultClient)
-	} else {
-		// Otherwise use ClientFromEnvironment (uses OLLAMA_HOST env var or default localhost:11434)
-		client, err = api.ClientFromEnvironment()
-		if err != nil {
-			return nil, fmt.Errorf("failed to create Ollama client: %w", err)
-		}
+	client, err := newAPIClient(config.BaseURL)
+	if err != nil {
+		return nil, err
 	}
 
 	// Determine which model to use
@@ -65,13 +56,38 @@ func New(config ai.Config) (ai.Provider, error) {
 		}
 	}
 
+	responseSchema, err := json.Marshal(ai.ResponseSchema())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode response schema: %w", err)
+	}
+
 	return &Client{
-		client:  client,
-		model:   model,
-		baseURL: config.BaseURL,
+		client:         client,
+		model:          model,
+		baseURL:        config.BaseURL,
+		responseSchema: responseSchema,
+		prompts:        config.Templates(),
 	}, nil
 }
 
+// newAPIClient creates an Ollama API client for baseURL, or from the environment when
+// baseURL is empty (OLLAMA_HOST env var or default localhost:11434)
+func newAPIClient(baseURL string) (*api.Client, error) {
+	if baseURL != "" {
+		parsed, err := url.Parse(baseURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base URL: %w", err)
+		}
+		return api.NewClient(parsed, http.DefaultClient), nil
+	}
+
+	client, err := api.ClientFromEnvironment()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Ollama client: %w", err)
+	}
+	return client, nil
+}
+
 // detectModel tries to find an available model to use
 func detectModel(client *api.Client) (string, error) {
 	ctx := context.Background()
@@ -103,38 +119,75 @@ func detectModel(client *api.Client) (string, error) {
 
 // GenerateSQL generates a SQL query from natural language using Ollama
 func (c *Client) GenerateSQL(ctx context.Context, req *ai.GenerateRequest) (*ai.GenerateResponse, error) {
+	return c.chat(ctx, req, false, nil)
+}
+
+// GenerateSQLStream generates a SQL query using Ollama, streaming partial text to onChunk
+func (c *Client) GenerateSQLStream(ctx context.Context, req *ai.GenerateRequest, onChunk ai.StreamHandler) (*ai.GenerateResponse, error) {
+	return c.chat(ctx, req, true, onChunk)
+}
+
+// chat runs a chat request against Ollama, optionally streaming response content to onChunk
+func (c *Client) chat(ctx context.Context, req *ai.GenerateRequest, stream bool, onChunk ai.StreamHandler) (*ai.GenerateResponse, error) {
 	if req.Prompt == "" {
 		return nil, ai.ErrEmptyPrompt
 	}
 
-	systemPrompt := buildSystemPrompt(req.Schema, req.DatabaseType, req.Context)
+	systemPrompt, err := c.prompts.System(prompt.Data{
+		DatabaseType: req.DatabaseType,
+		Schema:       req.Schema,
+		Context:      req.Context,
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	// Prepare messages
+	// Prepare messages (Ollama reuses its KV cache for the unchanged static prefix):
+	// system prompt, previous conversation turns, then the current prompt
 	messages := []api.Message{
 		{
 			Role:    "system",
-			Content: systemPrompt,
-		},
-		{
-			Role:    "user",
-			Content: req.Prompt,
+			Content: systemPrompt.String(),
 		},
 	}
+	for _, message := range req.Messages {
+		messages = append(messages, api.Message{
+			Role:    string(message.Role),
+			Content: message.Content,
+		})
+	}
+	messages = append(messages, api.Message{
+		Role:    "user",
+		Content: req.Prompt,
+	})
 
-	// Prepare chat request
+	// Prepare chat request, constraining output to the structured response schema
 	chatReq := &api.ChatRequest{
 		Model:    c.model,
 		Messages: messages,
-		Stream:   new(bool), // Disable streaming (false)
+		Stream:   &stream,
+		Format:   c.responseSchema,
+	}
+	if req.Temperature != nil {
+		chatReq.Options = map[string]any{"temperature": *req.Temperature}
+	}
+
+	if req.Toolset != nil {
+		return c.generateWithTools(ctx, req.Toolset, chatReq)
 	}
 
-	var fullResponse string
+	var fullResponse, thinking string
 	var promptTokens, responseTokens int
 
-	// Execute chat request
-	err := c.client.Chat(ctx, chatReq, func(resp api.ChatResponse) error {
-		// Accumulate response content
+	// Execute chat request (the callback runs once per chunk when streaming)
+	err = c.client.Chat(ctx, chatReq, func(resp api.ChatResponse) error {
+		// Accumulate response content, and the reasoning Ollama separates from it for thinking models
 		fullResponse += resp.Message.Content
+		thinking += resp.Message.Thinking
+
+		if stream && onChunk != nil && resp.Message.Content != "" {
+			onChunk(resp.Message.Content)
+		}
 
 		if resp.Done {
 			// Capture token usage when done
@@ -145,28 +198,170 @@ func (c *Client) GenerateSQL(ctx context.Context, req *ai.GenerateRequest) (*ai.
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("ollama API error: %w", err)
+		return nil, newAPIError(err)
+	}
+
+	return c.buildResponse(fullResponse, thinking, c.buildUsage(promptTokens, responseTokens))
+}
+
+// Answer answers a question in plain language from a query result using Ollama
+func (c *Client) Answer(ctx context.Context, req *ai.AnswerRequest) (*ai.AnswerResponse, error) {
+	return ai.Answer(ctx, c.prompts, c.complete, req)
+}
+
+// ExplainSQL explains a SQL query step by step in plain language using Ollama
+func (c *Client) ExplainSQL(ctx context.Context, req *ai.ExplainRequest) (*ai.ExplainResponse, error) {
+	return ai.ExplainSQL(ctx, c.prompts, c.complete, req)
+}
+
+// complete sends a non-streaming chat request and returns the response text (ai.CompleteFunc)
+func (c *Client) complete(ctx context.Context, chat prompt.ChatPrompt) (string, ai.UsageMetadata, error) {
+	stream := false
+	chatReq := &api.ChatRequest{
+		Model: c.model,
+		Messages: []api.Message{
+			{Role: "system", Content: chat.System},
+			{Role: "user", Content: chat.User},
+		},
+		Stream: &stream,
+	}
+
+	var text string
+	var promptTokens, responseTokens int
+	err := c.client.Chat(ctx, chatReq, func(resp api.ChatResponse) error {
+		text += resp.Message.Content
+		if resp.Done {
+			promptTokens = int(resp.PromptEvalCount)
+			responseTokens = int(resp.EvalCount)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", ai.UsageMetadata{}, newAPIError(err)
+	}
+
+	return text, c.buildUsage(promptTokens, responseTokens), nil
+}
+
+// generateWithTools lets the model call the tools of toolset, for up to ai.MaxToolRounds
+// rounds, before it answers with the structured response
+func (c *Client) generateWithTools(ctx context.Context, toolset ai.Toolset, chatReq *api.ChatRequest) (*ai.GenerateResponse, error) {
+	for _, tool := range toolset.Tools() {
+		// Ollama models parameters with its own types, convert through JSON
+		schema, err := json.Marshal(tool.Schema())
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode tool schema: %w", err)
+		}
+		var parameters api.ToolFunctionParameters
+		if err := json.Unmarshal(schema, &parameters); err != nil {
+			return nil, fmt.Errorf("failed to convert tool schema: %w", err)
+		}
+
+		chatReq.Tools = append(chatReq.Tools, api.Tool{
+			Type: "function",
+			Function: api.ToolFunction{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  parameters,
+			},
+		})
+	}
+
+	// The response format would keep the model from calling tools, the system prompt asks for JSON anyway
+	stream := false
+	chatReq.Stream = &stream
+	format := chatReq.Format
+	chatReq.Format = nil
+
+	var usage ai.UsageMetadata
+	var calls []ai.ToolCall
+	var reasoning []string
+	for round := 0; ; round++ {
+		// Out of rounds: the model must answer now
+		if round == ai.MaxToolRounds {
+			chatReq.Tools = nil
+			chatReq.Format = format
+		}
+
+		var message api.Message
+		var promptTokens, responseTokens int
+		err := c.client.Chat(ctx, chatReq, func(resp api.ChatResponse) error {
+			message = resp.Message
+			promptTokens = int(resp.PromptEvalCount)
+			responseTokens = int(resp.EvalCount)
+			return nil
+		})
+		if err != nil {
+			return nil, newAPIError(err)
+		}
+
+		if round == 0 {
+			usage = c.buildUsage(promptTokens, responseTokens)
+		} else {
+			usage.Add(c.buildUsage(promptTokens, responseTokens))
+		}
+
+		if len(message.ToolCalls) == 0 {
+			response, err := c.buildResponse(message.Content, message.Thinking, usage)
+			if err != nil {
+				return nil, err
+			}
+			response.ToolCalls = calls
+			response.Reasoning = ai.JoinReasoning(append(reasoning, response.Reasoning)...)
+			return response, nil
+		}
+		reasoning = append(reasoning, message.Thinking)
+
+		// Results are matched to calls by tool name and order
+		chatReq.Messages = append(chatReq.Messages, message)
+		for _, toolCall := range message.ToolCalls {
+			arguments, err := json.Marshal(toolCall.Function.Arguments)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode tool arguments: %w", err)
+			}
+
+			result, call := ai.RunTool(ctx, toolset, toolCall.Function.Name, arguments)
+			calls = append(calls, call)
+			chatReq.Messages = append(chatReq.Messages, api.Message{
+				Role:     "tool",
+				Content:  result,
+				ToolName: toolCall.Function.Name,
+			})
+		}
 	}
+}
 
-	if fullResponse == "" {
+// buildResponse converts the raw response text and the model's separate thinking
+// into an ai.GenerateResponse
+func (c *Client) buildResponse(content, thinking string, usage ai.UsageMetadata) (*ai.GenerateResponse, error) {
+	if content == "" {
 		return nil, ai.ErrGenerationFailed
 	}
 
-	query := cleanSQLResponse(fullResponse)
+	structured := ai.ParseStructuredResponse(content)
 
 	return &ai.GenerateResponse{
-		Query:      query,
-		Confidence: 1.0, // Ollama doesn't provide confidence scores
-		Usage: ai.UsageMetadata{
-			Provider:       "ollama",
-			Model:          c.model,
-			PromptTokens:   promptTokens,
-			ResponseTokens: responseTokens,
-			TotalTokens:    promptTokens + responseTokens,
-		},
+		Query:         prompt.CleanSQL(structured.SQL),
+		Confidence:    structured.Confidence,
+		Explanation:   structured.Explanation,
+		Assumptions:   structured.Assumptions,
+		Clarification: structured.Clarification,
+		Reasoning:     ai.JoinReasoning(thinking, structured.Reasoning),
+		Usage:         usage,
 	}, nil
 }
 
+// buildUsage converts Ollama token counts into ai.UsageMetadata
+func (c *Client) buildUsage(promptTokens, responseTokens int) ai.UsageMetadata {
+	return ai.UsageMetadata{
+		Provider:       "ollama",
+		Model:          c.model,
+		PromptTokens:   promptTokens,
+		ResponseTokens: responseTokens,
+		TotalTokens:    promptTokens + responseTokens,
+	}
+}
+
 // Name returns the provider name
 func (c *Client) Name() string {
 	return "ollama"
@@ -182,47 +377,14 @@ func (c *Client) Close() error {
 // Helper Functions
 // ============================================
 
-// buildSystemPrompt constructs the system prompt with schema information
-func buildSystemPrompt(schema, dbType, context string) string {
-	prompt := `You are a helpful assistant that generates SQL queries based on natural language descriptions.
-
-You'll receive database schema information that includes tables, their columns, data types, constraints,
-and relationships between tables. Use this information to generate accurate SQL queries.
-
-Respond ONLY with the SQL query without any explanation or markdown formatting. Do not include any comments
-in the SQL or any additional text.`
+// newAPIError wraps an SDK error into an ai.APIError carrying the HTTP status
+func newAPIError(err error) error {
+	apiErr := &ai.APIError{Provider: "ollama", Err: err}
 
-	if dbType != "" {
-		prompt += fmt.Sprintf("\n\nTarget database: %s", dbType)
+	var respErr api.StatusError
+	if errors.As(err, &respErr) {
+		apiErr.StatusCode = respErr.StatusCode
 	}
 
-	if schema != "" {
-		prompt += fmt.Sprintf("\n\n%s", schema)
-	}
-
-	if context != "" {
-		prompt += fmt.Sprintf("\n\n%s", context)
-	}
-
-	return prompt
-}
-
-// cleanSQLResponse removes markdown formatting from AI response
-func cleanSQLResponse(response string) string {
-	// Remove markdown code blocks
-	query := strings.TrimSpace(response)
-
-	// Check for opening code block with language identifier
-	if strings.HasPrefix(query, "```") {
-		lines := strings.Split(query, "\n")
-		if len(lines) > 2 {
-			// Remove first line (```sql or ```)
-			query = strings.Join(lines[1:], "\n")
-		}
-	}
-
-	// Remove closing code block - TrimSuffix is idempotent
-	query = strings.TrimSuffix(query, "```")
-
-	return strings.TrimSpace(query)
+	return apiErr
 }
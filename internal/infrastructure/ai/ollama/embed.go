@@ -0,0 +1,46 @@
+// Package ollama provides text embeddings computed by local Ollama models.
+package ollama
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ollama/ollama/api"
+)
+
+// Embedder computes text embeddings with a local Ollama embedding model (e.g. nomic-embed-text).
+// It is used to match prompts with tables by meaning on large schemas.
+type Embedder struct {
+	client *api.Client
+	model  string
+}
+
+// NewEmbedder creates an embedder for model, served at baseURL
+// (empty = OLLAMA_HOST env var or default localhost:11434)
+func NewEmbedder(baseURL, model string) (*Embedder, error) {
+	client, err := newAPIClient(baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Embedder{
+		client: client,
+		model:  model,
+	}, nil
+}
+
+// Embed returns the embedding of every text, in order
+func (e *Embedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	resp, err := e.client.Embed(ctx, &api.EmbedRequest{
+		Model: e.model,
+		Input: texts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed with %s: %w", e.model, err)
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("failed to embed with %s: got %d embeddings for %d texts", e.model, len(resp.Embeddings), len(texts))
+	}
+
+	return resp.Embeddings, nil
+}
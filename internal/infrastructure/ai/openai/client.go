@@ -3,10 +3,19 @@ package openai
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai/prompt"
 	"github.com/sashabaranov/go-openai"
 )
 
@@ -16,10 +25,18 @@ import (
 
 // Client implements the ai.Provider interface for OpenAI
 type Client struct {
-	client      *openai.Client
-	model       string
-	temperature float64
-	maxTokens   int
+	client         *openai.Client
+	model          string
+	temperature    float64
+	maxTokens      int
+	reasoning      string
+	responseSchema json.RawMessage
+	prompts        *prompt.Templates
+
+	// Index in responseFormats of the response format asked for; it moves to the next
+	// format when the endpoint rejects it, unless the format was configured
+	responseFormat      atomic.Int32
+	fixedResponseFormat bool
 }
 
 // Ensure Client implements ai.Provider interface
@@ -34,12 +51,43 @@ func init() {
 	ai.RegisterProvider(ai.ProviderOpenAI, New)
 }
 
-// New creates a new OpenAI provider (implements ai.ProviderFactory)
+// Provider-specific option keys (ai.Config.Options)
+const (
+	// OptionAPIType selects the API flavour for custom endpoints ("openai" or "azure")
+	OptionAPIType = "api_type"
+
+	// OptionAPIVersion sets the API version required by Azure OpenAI deployments
+	OptionAPIVersion = "api_version"
+
+	// OptionReasoningEffort sets how much reasoning models (gpt-5, o-series) think
+	// before answering ("minimal", "low", "medium" or "high")
+	OptionReasoningEffort = "reasoning_effort"
+
+	// OptionResponseFormat sets the response format asked for ("json_schema", "json_object"
+	// or "text"). By default structured outputs (json_schema) are asked for, falling back
+	// to the next format when the endpoint rejects them.
+	OptionResponseFormat = "response_format"
+)
+
+// reasoningEfforts are the accepted OptionReasoningEffort values
+var reasoningEfforts = []string{"minimal", "low", "medium", "high"}
+
+// responseFormats are the accepted OptionResponseFormat values, from the strictest
+var responseFormats = []string{"json_schema", "json_object", "text"}
+
+// New creates a new OpenAI provider (implements ai.ProviderFactory).
+// When config.BaseURL is set, the client talks to that OpenAI-compatible endpoint
+// (vLLM, LM Studio, llama.cpp server, LiteLLM, Azure OpenAI, ...) and the API key is optional.
 func New(config ai.Config) (ai.Provider, error) {
-	if config.APIKey == "" {
+	if config.APIKey == "" && config.BaseURL == "" {
 		return nil, fmt.Errorf("OpenAI API key is required: %w", ai.ErrInvalidConfig)
 	}
 
+	clientConfig, err := buildClientConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
 	// Default model
 	model := config.Model
 	if model == "" {
@@ -52,14 +100,83 @@ func New(config ai.Config) (ai.Provider, error) {
 		temperature = 0.0 // Deterministic for SQL
 	}
 
-	client := openai.NewClient(config.APIKey)
+	reasoning, _ := config.Options[OptionReasoningEffort].(string)
+	if reasoning != "" && !slices.Contains(reasoningEfforts, reasoning) {
+		return nil, fmt.Errorf("invalid reasoning effort %q, expected one of %s: %w", reasoning, strings.Join(reasoningEfforts, ", "), ai.ErrInvalidConfig)
+	}
+
+	responseFormat, _ := config.Options[OptionResponseFormat].(string)
+	if responseFormat != "" && !slices.Contains(responseFormats, responseFormat) {
+		return nil, fmt.Errorf("invalid response format %q, expected one of %s: %w", responseFormat, strings.Join(responseFormats, ", "), ai.ErrInvalidConfig)
+	}
+
+	// go-openai errors don't expose response headers, record Retry-After on the way back
+	clientConfig.HTTPClient = retryAfterRecorder{doer: clientConfig.HTTPClient}
+
+	client := openai.NewClientWithConfig(clientConfig)
+
+	responseSchema, err := json.Marshal(ai.ResponseSchema())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode response schema: %w", err)
+	}
+
+	c := &Client{
+		client:              client,
+		model:               model,
+		temperature:         temperature,
+		maxTokens:           config.MaxTokens,
+		reasoning:           reasoning,
+		responseSchema:      responseSchema,
+		prompts:             config.Templates(),
+		fixedResponseFormat: responseFormat != "",
+	}
+	if responseFormat != "" {
+		c.responseFormat.Store(int32(slices.Index(responseFormats, responseFormat)))
+	}
+	return c, nil
+}
+
+// buildClientConfig creates the client configuration, honouring custom OpenAI-compatible endpoints
+func buildClientConfig(config ai.Config) (openai.ClientConfig, error) {
+	if config.BaseURL == "" {
+		return openai.DefaultConfig(config.APIKey), nil
+	}
 
-	return &Client{
-		client:      client,
-		model:       model,
-		temperature: temperature,
-		maxTokens:   config.MaxTokens,
-	}, nil
+	baseURL, err := url.Parse(config.BaseURL)
+	if err != nil || baseURL.Scheme == "" || baseURL.Host == "" {
+		return openai.ClientConfig{}, fmt.Errorf("invalid base URL %q: %w", config.BaseURL, ai.ErrInvalidConfig)
+	}
+	endpoint := strings.TrimSuffix(config.BaseURL, "/")
+
+	// Azure OpenAI uses deployment-based URLs and api-key authentication
+	apiType, _ := config.Options[OptionAPIType].(string)
+	if strings.EqualFold(apiType, "azure") || isAzureHost(baseURL.Hostname()) {
+		clientConfig := openai.DefaultAzureConfig(config.APIKey, endpoint)
+		if apiVersion, ok := config.Options[OptionAPIVersion].(string); ok && apiVersion != "" {
+			clientConfig.APIVersion = apiVersion
+		}
+		return clientConfig, nil
+	}
+
+	clientConfig := openai.DefaultConfig(config.APIKey)
+	clientConfig.BaseURL = endpoint
+	return clientConfig, nil
+}
+
+// isReasoningModel reports whether model is an OpenAI reasoning model (gpt-5, o-series),
+// which only samples at its own temperature
+func isReasoningModel(model string) bool {
+	for _, prefix := range []string{"gpt-5", "o1", "o3", "o4"} {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAzureHost reports whether host belongs to an Azure OpenAI resource
+func isAzureHost(host string) bool {
+	return strings.HasSuffix(host, ".openai.azure.com") || strings.HasSuffix(host, ".cognitiveservices.azure.com")
 }
 
 // ============================================
@@ -72,49 +189,141 @@ func (c *Client) GenerateSQL(ctx context.Context, req *ai.GenerateRequest) (*ai.
 		return nil, ai.ErrEmptyPrompt
 	}
 
-	systemPrompt := buildSystemPrompt(req.Schema, req.DatabaseType, req.Context)
+	chatReq, err := c.buildRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Toolset != nil {
+		return c.generateWithTools(ctx, req.Toolset, chatReq)
+	}
+
+	ctx, retryAfter := withRetryAfter(ctx)
+
+	var resp openai.ChatCompletionResponse
+	err = c.withFormatFallback(&chatReq, func() (err error) {
+		resp, err = c.client.CreateChatCompletion(ctx, chatReq)
+		return err
+	})
+	if err != nil {
+		return nil, newAPIError(err, *retryAfter)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, ai.ErrGenerationFailed
+	}
+
+	message := resp.Choices[0].Message
+	return c.buildResponse(message.Content, message.ReasoningContent, resp.Model, resp.Usage), nil
+}
+
+// GenerateSQLStream generates a SQL query using OpenAI, streaming partial text to onChunk
+func (c *Client) GenerateSQLStream(ctx context.Context, req *ai.GenerateRequest, onChunk ai.StreamHandler) (*ai.GenerateResponse, error) {
+	if req.Prompt == "" {
+		return nil, ai.ErrEmptyPrompt
+	}
+
+	// Tool rounds are not streamed
+	if req.Toolset != nil {
+		return c.GenerateSQL(ctx, req)
+	}
+
+	chatReq, err := c.buildRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	chatReq.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+
+	ctx, retryAfter := withRetryAfter(ctx)
+
+	var stream *openai.ChatCompletionStream
+	err = c.withFormatFallback(&chatReq, func() (err error) {
+		stream, err = c.client.CreateChatCompletionStream(ctx, chatReq)
+		return err
+	})
+	if err != nil {
+		return nil, newAPIError(err, *retryAfter)
+	}
+	defer func() { _ = stream.Close() }()
+
+	var fullResponse strings.Builder
+	var reasoning strings.Builder
+	var usage openai.Usage
+	model := c.model
+
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, newAPIError(err, 0)
+		}
+
+		if chunk.Model != "" {
+			model = chunk.Model
+		}
+
+		// The final chunk carries usage only (no choices) when IncludeUsage is set
+		if chunk.Usage != nil {
+			usage = *chunk.Usage
+		}
+
+		// Reasoning of OpenAI-compatible servers (e.g. DeepSeek, vLLM) is kept, not previewed
+		if len(chunk.Choices) > 0 {
+			reasoning.WriteString(chunk.Choices[0].Delta.ReasoningContent)
+		}
+
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			fullResponse.WriteString(chunk.Choices[0].Delta.Content)
+			if onChunk != nil {
+				onChunk(chunk.Choices[0].Delta.Content)
+			}
+		}
+	}
+
+	if fullResponse.Len() == 0 {
+		return nil, ai.ErrGenerationFailed
+	}
+
+	return c.buildResponse(fullResponse.String(), reasoning.String(), model, usage), nil
+}
+
+// Answer answers a question in plain language from a query result using OpenAI
+func (c *Client) Answer(ctx context.Context, req *ai.AnswerRequest) (*ai.AnswerResponse, error) {
+	return ai.Answer(ctx, c.prompts, c.complete, req)
+}
+
+// ExplainSQL explains a SQL query step by step in plain language using OpenAI
+func (c *Client) ExplainSQL(ctx context.Context, req *ai.ExplainRequest) (*ai.ExplainResponse, error) {
+	return ai.ExplainSQL(ctx, c.prompts, c.complete, req)
+}
 
+// complete sends a non-streaming chat request and returns the response text (ai.CompleteFunc)
+func (c *Client) complete(ctx context.Context, chat prompt.ChatPrompt) (string, ai.UsageMetadata, error) {
 	chatReq := openai.ChatCompletionRequest{
 		Model: c.model,
 		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleSystem,
-				Content: systemPrompt,
-			},
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: req.Prompt,
-			},
+			{Role: openai.ChatMessageRoleSystem, Content: chat.System},
+			{Role: openai.ChatMessageRoleUser, Content: chat.User},
 		},
-		// Temperature and TopP are omitted - reasoning models optimize these internally
 	}
-
 	if c.maxTokens > 0 {
 		chatReq.MaxTokens = c.maxTokens
 	}
 
+	ctx, retryAfter := withRetryAfter(ctx)
+
 	resp, err := c.client.CreateChatCompletion(ctx, chatReq)
 	if err != nil {
-		return nil, fmt.Errorf("OpenAI API error: %w", err)
+		return "", ai.UsageMetadata{}, newAPIError(err, *retryAfter)
 	}
 
 	if len(resp.Choices) == 0 {
-		return nil, ai.ErrGenerationFailed
+		return "", ai.UsageMetadata{}, ai.ErrGenerationFailed
 	}
 
-	query := cleanSQLResponse(resp.Choices[0].Message.Content)
-
-	return &ai.GenerateResponse{
-		Query:      query,
-		Confidence: 1.0, // OpenAI doesn't provide confidence scores
-		Usage: ai.UsageMetadata{
-			Provider:       "openai",
-			Model:          resp.Model,
-			PromptTokens:   resp.Usage.PromptTokens,
-			ResponseTokens: resp.Usage.CompletionTokens,
-			TotalTokens:    resp.Usage.TotalTokens,
-		},
-	}, nil
+	return resp.Choices[0].Message.Content, c.buildUsage(resp.Model, resp.Usage), nil
 }
 
 // Name returns the provider name
@@ -132,47 +341,273 @@ func (c *Client) Close() error {
 // Helper Functions
 // ============================================
 
-// buildSystemPrompt constructs the system prompt with schema information
-func buildSystemPrompt(schema, dbType, context string) string {
-	prompt := `You are a helpful assistant that generates SQL queries based on natural language descriptions.
+// buildRequest creates the chat completion request shared by streaming and non-streaming calls
+func (c *Client) buildRequest(req *ai.GenerateRequest) (openai.ChatCompletionRequest, error) {
+	systemPrompt, err := c.prompts.System(prompt.Data{
+		DatabaseType: req.DatabaseType,
+		Schema:       req.Schema,
+		Context:      req.Context,
+	})
+	if err != nil {
+		return openai.ChatCompletionRequest{}, err
+	}
+
+	// OpenAI caches the longest previously seen prompt prefix automatically:
+	// keep the static system prompt (instructions and schema) first and put
+	// everything that changes between requests after it
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: systemPrompt.Static,
+		},
+	}
+	if systemPrompt.Volatile != "" {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: systemPrompt.Volatile,
+		})
+	}
+
+	// Previous conversation turns (ai roles match OpenAI's role names), then the current prompt
+	for _, message := range req.Messages {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    string(message.Role),
+			Content: message.Content,
+		})
+	}
+	messages = append(messages, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: req.Prompt,
+	})
 
-You'll receive database schema information that includes tables, their columns, data types, constraints,
-and relationships between tables. Use this information to generate accurate SQL queries.
+	chatReq := openai.ChatCompletionRequest{
+		Model:    c.model,
+		Messages: messages,
+		// Temperature and TopP are omitted - reasoning models optimize these internally
+	}
 
-Respond ONLY with the SQL query without any explanation or markdown formatting. Do not include any comments
-in the SQL or any additional text.`
+	// Other models, such as gpt-4.1 and those of custom endpoints, take the temperature
+	// of the request (e.g. to vary candidates); reasoning models would reject it
+	if req.Temperature != nil && !isReasoningModel(c.model) {
+		chatReq.Temperature = float32(*req.Temperature)
+	}
 
-	if dbType != "" {
-		prompt += fmt.Sprintf("\n\nTarget database: %s", dbType)
+	if c.maxTokens > 0 {
+		chatReq.MaxTokens = c.maxTokens
+	}
+
+	if c.reasoning != "" {
+		chatReq.ReasoningEffort = c.reasoning
+	}
+
+	chatReq.ResponseFormat = c.buildResponseFormat(responseFormats[c.responseFormat.Load()])
+
+	return chatReq, nil
+}
+
+// buildResponseFormat returns the response format asked for by the name of one of
+// responseFormats: a strict JSON object matching the structured response schema, any
+// JSON object, or nothing (the reply is parsed leniently, see ai.ParseStructuredResponse)
+func (c *Client) buildResponseFormat(name string) *openai.ChatCompletionResponseFormat {
+	switch name {
+	case "json_schema":
+		return &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   ai.StructuredResponseName,
+				Schema: c.responseSchema,
+				Strict: true,
+			},
+		}
+	case "json_object":
+		return &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+	default:
+		return nil
 	}
+}
 
-	if schema != "" {
-		prompt += fmt.Sprintf("\n\n%s", schema)
+// withFormatFallback runs send with chatReq, and again with the next of responseFormats as
+// long as the endpoint rejects the response format: OpenAI-compatible servers and older
+// Azure API versions may not support structured outputs. The format the endpoint took is
+// kept for the next requests.
+func (c *Client) withFormatFallback(chatReq *openai.ChatCompletionRequest, send func() error) error {
+	for {
+		err := send()
+		current := c.responseFormat.Load()
+		if err == nil || c.fixedResponseFormat || chatReq.ResponseFormat == nil || !rejectsResponseFormat(err) {
+			return err
+		}
+
+		// Requests running in parallel may have moved on already
+		next := max(current+1, c.responseFormat.Load())
+		if int(next) >= len(responseFormats) {
+			return err
+		}
+		c.responseFormat.CompareAndSwap(current, next)
+		chatReq.ResponseFormat = c.buildResponseFormat(responseFormats[next])
 	}
+}
 
-	if context != "" {
-		prompt += fmt.Sprintf("\n\n%s", context)
+// rejectsResponseFormat reports whether err is an endpoint refusing the response format
+// of the request (e.g. HTTP 400 "response_format json_schema is not supported")
+func rejectsResponseFormat(err error) bool {
+	var message string
+	var respErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &respErr) && (respErr.HTTPStatusCode == http.StatusBadRequest || respErr.HTTPStatusCode == http.StatusUnprocessableEntity):
+		message = respErr.Message
+	case errors.As(err, &reqErr) && (reqErr.HTTPStatusCode == http.StatusBadRequest || reqErr.HTTPStatusCode == http.StatusUnprocessableEntity):
+		message = string(reqErr.Body)
+	default:
+		return false
 	}
 
-	return prompt
+	message = strings.ToLower(message)
+	return strings.Contains(message, "response_format") || strings.Contains(message, "json_schema") ||
+		strings.Contains(message, "json_object") || strings.Contains(message, "structured output")
 }
 
-// cleanSQLResponse removes markdown formatting from AI response
-func cleanSQLResponse(response string) string {
-	// Remove markdown code blocks
-	query := strings.TrimSpace(response)
+// generateWithTools lets the model call the tools of toolset, for up to ai.MaxToolRounds
+// rounds, before it answers with the structured response
+func (c *Client) generateWithTools(ctx context.Context, toolset ai.Toolset, chatReq openai.ChatCompletionRequest) (*ai.GenerateResponse, error) {
+	for _, tool := range toolset.Tools() {
+		chatReq.Tools = append(chatReq.Tools, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Schema(),
+			},
+		})
+	}
 
-	// Check for opening code block with language identifier
-	if strings.HasPrefix(query, "```") {
-		lines := strings.Split(query, "\n")
-		if len(lines) > 2 {
-			// Remove first line (```sql or ```)
-			query = strings.Join(lines[1:], "\n")
+	ctx, retryAfter := withRetryAfter(ctx)
+
+	var usage ai.UsageMetadata
+	var calls []ai.ToolCall
+	var reasoning []string
+	for round := 0; ; round++ {
+		// Out of rounds: the model must answer now
+		if round == ai.MaxToolRounds {
+			chatReq.ToolChoice = "none"
 		}
+
+		var resp openai.ChatCompletionResponse
+		err := c.withFormatFallback(&chatReq, func() (err error) {
+			resp, err = c.client.CreateChatCompletion(ctx, chatReq)
+			return err
+		})
+		if err != nil {
+			return nil, newAPIError(err, *retryAfter)
+		}
+		if len(resp.Choices) == 0 {
+			return nil, ai.ErrGenerationFailed
+		}
+
+		roundUsage := c.buildUsage(resp.Model, resp.Usage)
+		if round == 0 {
+			usage = roundUsage
+		} else {
+			usage.Add(roundUsage)
+		}
+
+		message := resp.Choices[0].Message
+		if len(message.ToolCalls) == 0 || round == ai.MaxToolRounds {
+			response := c.buildResponse(message.Content, message.ReasoningContent, resp.Model, resp.Usage)
+			response.Usage = usage
+			response.ToolCalls = calls
+			response.Reasoning = ai.JoinReasoning(append(reasoning, response.Reasoning)...)
+			return response, nil
+		}
+		reasoning = append(reasoning, message.ReasoningContent)
+
+		// Send every result back with the id of the call it answers
+		chatReq.Messages = append(chatReq.Messages, message)
+		for _, toolCall := range message.ToolCalls {
+			result, call := ai.RunTool(ctx, toolset, toolCall.Function.Name, json.RawMessage(toolCall.Function.Arguments))
+			calls = append(calls, call)
+			chatReq.Messages = append(chatReq.Messages, openai.ChatCompletionMessage{
+				Role:       openai.ChatMessageRoleTool,
+				Content:    result,
+				ToolCallID: toolCall.ID,
+			})
+		}
+	}
+}
+
+// buildResponse converts the raw completion text, the reasoning reported next to it and
+// usage into an ai.GenerateResponse
+func (c *Client) buildResponse(content, reasoning, model string, usage openai.Usage) *ai.GenerateResponse {
+	structured := ai.ParseStructuredResponse(content)
+
+	return &ai.GenerateResponse{
+		Query:         prompt.CleanSQL(structured.SQL),
+		Confidence:    structured.Confidence,
+		Explanation:   structured.Explanation,
+		Assumptions:   structured.Assumptions,
+		Clarification: structured.Clarification,
+		Reasoning:     ai.JoinReasoning(reasoning, structured.Reasoning),
+		Usage:         c.buildUsage(model, usage),
+	}
+}
+
+// buildUsage converts OpenAI token usage into ai.UsageMetadata
+func (c *Client) buildUsage(model string, usage openai.Usage) ai.UsageMetadata {
+	// Cached prompt tokens are reported for prompts of 1024+ tokens only
+	cachedTokens := 0
+	if usage.PromptTokensDetails != nil {
+		cachedTokens = usage.PromptTokensDetails.CachedTokens
 	}
 
-	// Remove closing code block - TrimSuffix is idempotent
-	query = strings.TrimSuffix(query, "```")
+	return ai.UsageMetadata{
+		Provider:       "openai",
+		Model:          model,
+		PromptTokens:   usage.PromptTokens,
+		ResponseTokens: usage.CompletionTokens,
+		TotalTokens:    usage.TotalTokens,
+		CachedTokens:   cachedTokens,
+	}
+}
+
+// newAPIError wraps an SDK error into an ai.APIError carrying the HTTP status
+func newAPIError(err error, retryAfter time.Duration) error {
+	apiErr := &ai.APIError{Provider: "openai", RetryAfter: retryAfter, Err: err}
+
+	var respErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &respErr):
+		apiErr.StatusCode = respErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		apiErr.StatusCode = reqErr.HTTPStatusCode
+	}
 
-	return strings.TrimSpace(query)
+	return apiErr
+}
+
+// retryAfterKey is the context key of the Retry-After delay recorded for a request
+type retryAfterKey struct{}
+
+// withRetryAfter returns a context in which retryAfterRecorder stores the
+// Retry-After delay of a failed response
+func withRetryAfter(ctx context.Context) (context.Context, *time.Duration) {
+	retryAfter := new(time.Duration)
+	return context.WithValue(ctx, retryAfterKey{}, retryAfter), retryAfter
+}
+
+// retryAfterRecorder is an openai.HTTPDoer recording the Retry-After header of
+// error responses into the request context (see withRetryAfter)
+type retryAfterRecorder struct {
+	doer openai.HTTPDoer
+}
+
+func (r retryAfterRecorder) Do(req *http.Request) (*http.Response, error) {
+	resp, err := r.doer.Do(req)
+	if err == nil && resp.StatusCode >= http.StatusBadRequest {
+		if retryAfter, ok := req.Context().Value(retryAfterKey{}).(*time.Duration); ok {
+			*retryAfter = ai.ParseRetryAfter(resp.Header.Get("Retry-After"))
+		}
+	}
+	return resp, err
 }
@@ -0,0 +1,276 @@
+// Package prompt builds the prompts sent to AI providers from text/template files.
+// Built-in templates are embedded in the binary; users can override any of them by
+// dropping a file with the same name in ~/.config/asqli/prompts/ or in a profile's
+// prompts directory (~/.config/asqli/profiles/<profile>/prompts/).
+package prompt
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/alessandrolattao/asqli/internal/infrastructure/config"
+)
+
+// Template names (file names in the templates directory)
+const (
+	// SystemTemplate renders the static, cacheable part of the system prompt
+	SystemTemplate = "system.tmpl"
+
+	// ContextTemplate renders the volatile, per-request part of the system prompt
+	ContextTemplate = "context.tmpl"
+
+	// RulesTemplate renders house rules included by the system prompt (empty by default)
+	RulesTemplate = "rules.tmpl"
+
+	// AnswerTemplate renders the system prompt for summarizing a query result
+	AnswerTemplate = "answer.tmpl"
+
+	// QuestionTemplate renders the user message with the question and result to summarize
+	QuestionTemplate = "question.tmpl"
+
+	// ExplainTemplate renders the system prompt for explaining a SQL query
+	ExplainTemplate = "explain.tmpl"
+
+	// StatementTemplate renders the user message with the SQL query to explain
+	StatementTemplate = "statement.tmpl"
+)
+
+//go:embed templates/*.tmpl
+var builtin embed.FS
+
+// Data is the input available to prompt templates
+type Data struct {
+	// Target database type (postgres, mysql, sqlite), may be empty
+	DatabaseType string
+
+	// Database schema description
+	Schema string
+
+	// Additional context (selected cell, history, failed attempts)
+	Context string
+}
+
+// AnswerData is the input available to the answer mode templates
+type AnswerData struct {
+	// Database type the query ran on (postgres, mysql, sqlite), may be empty
+	DatabaseType string
+
+	// User's natural language question
+	Question string
+
+	// SQL query that produced the result
+	Query string
+
+	// Digest of the result (row count, columns, sample rows)
+	Result string
+}
+
+// ExplainData is the input available to the explain mode templates
+type ExplainData struct {
+	// Database type the query targets (postgres, mysql, sqlite), may be empty
+	DatabaseType string
+
+	// SQL query to explain
+	Query string
+
+	// Execution plan reported by the database for the query, may be empty
+	Plan string
+}
+
+// ChatPrompt is a rendered system prompt and user message pair
+type ChatPrompt struct {
+	System string
+	User   string
+}
+
+// SystemPrompt is a rendered system prompt split for provider prompt caching
+type SystemPrompt struct {
+	// Static is identical across requests for the same schema (instructions, rules, schema)
+	Static string
+
+	// Volatile changes with every request (selected cell, history, failed attempts)
+	Volatile string
+}
+
+// String returns the complete system prompt, static part first
+func (p SystemPrompt) String() string {
+	if p.Volatile == "" {
+		return p.Static
+	}
+	return p.Static + "\n\n" + p.Volatile
+}
+
+// Templates is a set of parsed prompt templates
+type Templates struct {
+	tmpl *template.Template
+}
+
+// Default returns the built-in templates
+func Default() *Templates {
+	t, err := parseBuiltin()
+	if err != nil {
+		// Built-in templates are compiled into the binary, failing to parse them is a bug
+		panic(err)
+	}
+	return t
+}
+
+// Load returns the built-in templates overridden by the *.tmpl files found in dirs.
+// Directories are applied in order, so later ones win; missing directories are ignored.
+func Load(dirs ...string) (*Templates, error) {
+	t, err := parseBuiltin()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dir := range dirs {
+		files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read prompt template: %w", err)
+			}
+			if _, err := t.tmpl.New(filepath.Base(file)).Parse(string(content)); err != nil {
+				return nil, fmt.Errorf("invalid prompt template %s: %w", file, err)
+			}
+		}
+	}
+
+	// Catch errors such as unknown fields now rather than on the first request
+	if _, err := t.System(Data{DatabaseType: "postgres", Schema: "-", Context: "-"}); err != nil {
+		return nil, err
+	}
+	if _, err := t.Answer(AnswerData{DatabaseType: "postgres", Question: "-", Query: "-", Result: "-"}); err != nil {
+		return nil, err
+	}
+	if _, err := t.Explain(ExplainData{DatabaseType: "postgres", Query: "-", Plan: "-"}); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Dirs returns the user override directories for a connection profile, in order of
+// precedence: ~/.config/asqli/prompts, then ~/.config/asqli/profiles/<profile>/prompts.
+// The profile directory is omitted when profile is empty.
+func Dirs(profile string) ([]string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+	dirs := []string{filepath.Join(dir, "prompts")}
+
+	if profile != "" {
+		profileDir, err := config.ProfileDir(profile)
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, filepath.Join(profileDir, "prompts"))
+	}
+
+	return dirs, nil
+}
+
+// System renders the system prompt for SQL generation.
+// The static part never sees data.Context, so it stays byte-identical between requests.
+func (t *Templates) System(data Data) (SystemPrompt, error) {
+	static := data
+	static.Context = ""
+
+	staticPrompt, err := t.execute(SystemTemplate, static)
+	if err != nil {
+		return SystemPrompt{}, err
+	}
+
+	volatilePrompt, err := t.execute(ContextTemplate, data)
+	if err != nil {
+		return SystemPrompt{}, err
+	}
+
+	return SystemPrompt{Static: staticPrompt, Volatile: volatilePrompt}, nil
+}
+
+// Answer renders the prompt asking the model to answer a question from a query result
+func (t *Templates) Answer(data AnswerData) (ChatPrompt, error) {
+	return t.chat(AnswerTemplate, QuestionTemplate, data)
+}
+
+// Explain renders the prompt asking the model to explain a SQL query step by step
+func (t *Templates) Explain(data ExplainData) (ChatPrompt, error) {
+	return t.chat(ExplainTemplate, StatementTemplate, data)
+}
+
+// chat renders the system and user templates of a chat prompt with the same data
+func (t *Templates) chat(systemTemplate, userTemplate string, data any) (ChatPrompt, error) {
+	system, err := t.execute(systemTemplate, data)
+	if err != nil {
+		return ChatPrompt{}, err
+	}
+
+	user, err := t.execute(userTemplate, data)
+	if err != nil {
+		return ChatPrompt{}, err
+	}
+
+	return ChatPrompt{System: system, User: user}, nil
+}
+
+// execute renders the named template with data
+func (t *Templates) execute(name string, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s: %w", name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// parseBuiltin parses the embedded templates together with the template functions
+func parseBuiltin() (*Templates, error) {
+	t := &Templates{}
+	t.tmpl = template.New("prompts").Funcs(template.FuncMap{
+		// include renders another template to a string so it can be piped (Helm-style)
+		"include": func(name string, data any) (string, error) {
+			var buf bytes.Buffer
+			err := t.tmpl.ExecuteTemplate(&buf, name, data)
+			return buf.String(), err
+		},
+		"trim":  strings.TrimSpace,
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+	})
+
+	if _, err := t.tmpl.ParseFS(builtin, "templates/*.tmpl"); err != nil {
+		return nil, fmt.Errorf("invalid built-in prompt templates: %w", err)
+	}
+
+	return t, nil
+}
+
+// CleanSQL removes markdown formatting (```sql fences) from a SQL response
+func CleanSQL(response string) string {
+	// Remove markdown code blocks
+	query := strings.TrimSpace(response)
+
+	// Check for opening code block with language identifier
+	if strings.HasPrefix(query, "```") {
+		lines := strings.Split(query, "\n")
+		if len(lines) > 2 {
+			// Remove first line (```sql or ```)
+			query = strings.Join(lines[1:], "\n")
+		}
+	}
+
+	// Remove closing code block - TrimSuffix is idempotent
+	query = strings.TrimSuffix(query, "```")
+
+	return strings.TrimSpace(query)
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"maps"
 	"slices"
+
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai/prompt"
 )
 
 // ============================================
@@ -17,6 +19,16 @@ type Provider interface {
 	// GenerateSQL generates a SQL query from natural language prompt
 	GenerateSQL(ctx context.Context, req *GenerateRequest) (*GenerateResponse, error)
 
+	// GenerateSQLStream generates a SQL query like GenerateSQL, invoking onChunk
+	// with each partial piece of response text as soon as the provider emits it
+	GenerateSQLStream(ctx context.Context, req *GenerateRequest, onChunk StreamHandler) (*GenerateResponse, error)
+
+	// Answer answers the user's question in plain language from a query result
+	Answer(ctx context.Context, req *AnswerRequest) (*AnswerResponse, error)
+
+	// ExplainSQL explains an existing SQL query step by step in plain language
+	ExplainSQL(ctx context.Context, req *ExplainRequest) (*ExplainResponse, error)
+
 	// Name returns the provider name (e.g., "openai", "claude")
 	Name() string
 
@@ -24,6 +36,15 @@ type Provider interface {
 	Close() error
 }
 
+// StreamHandler receives partial response text while a provider is streaming.
+// Chunks arrive in order and concatenate to the raw response text, except that
+// a StreamReset chunk discards everything received before it.
+type StreamHandler func(chunk string)
+
+// StreamReset is passed to a StreamHandler when the output streamed so far is abandoned,
+// e.g. because another provider takes over after a failure. It never occurs in response text.
+const StreamReset = "\x00"
+
 // ============================================
 // Request/Response Types
 // ============================================
@@ -41,6 +62,37 @@ type GenerateRequest struct {
 
 	// Optional: Additional context or examples
 	Context string
+
+	// Optional: Schema holds only the tables selected for this prompt (schema retrieval),
+	// so it changes from prompt to prompt: providers don't cache it, as every prompt
+	// would pay for a cache write that is never read
+	SchemaSelected bool
+
+	// Optional: previous conversation turns, oldest first, sent to the provider
+	// as native user and assistant turns before Prompt
+	Messages []Message
+
+	// Optional: sampling temperature overriding Config.Temperature for this request
+	// (providers that don't support temperature ignore it)
+	Temperature *float64
+
+	// Optional: read-only tools the model can call to explore the database before
+	// answering. Tool rounds are not streamed: GenerateSQLStream behaves like GenerateSQL
+	Toolset Toolset
+}
+
+// MessageRole identifies the author of a conversation turn
+type MessageRole string
+
+const (
+	RoleUser      MessageRole = "user"
+	RoleAssistant MessageRole = "assistant"
+)
+
+// Message is a previous turn of the conversation
+type Message struct {
+	Role    MessageRole
+	Content string
 }
 
 // GenerateResponse contains the AI-generated SQL
@@ -54,6 +106,64 @@ type GenerateResponse struct {
 	// Explanation of what the query does (optional)
 	Explanation string
 
+	// Assumptions the model made about ambiguous parts of the prompt (optional)
+	Assumptions []string
+
+	// Question for the user when the prompt is too ambiguous to answer (Query is empty then)
+	Clarification string
+
+	// Reasoning the model went through before answering (optional): Claude thinking blocks,
+	// the reasoning field of OpenAI-compatible servers or <think> tags of local models
+	Reasoning string
+
+	// Tools the model called before answering, in order (GenerateRequest.Toolset only)
+	ToolCalls []ToolCall
+
+	// Usage metadata, summed over every tool round
+	Usage UsageMetadata
+}
+
+// AnswerRequest contains the input for answering a question from a query result
+type AnswerRequest struct {
+	// User's natural language question
+	Question string
+
+	// SQL query that produced the result
+	Query string
+
+	// Digest of the result set (row count, columns, sample rows)
+	Result string
+
+	// Optional: Database type (postgres, mysql, sqlite)
+	DatabaseType string
+}
+
+// AnswerResponse contains the AI-generated answer
+type AnswerResponse struct {
+	// Plain-language answer, one paragraph
+	Answer string
+
+	// Usage metadata
+	Usage UsageMetadata
+}
+
+// ExplainRequest contains the input for explaining a SQL query
+type ExplainRequest struct {
+	// SQL query to explain
+	Query string
+
+	// Optional: Execution plan reported by the database for the query
+	Plan string
+
+	// Optional: Database type (postgres, mysql, sqlite)
+	DatabaseType string
+}
+
+// ExplainResponse contains the AI-generated explanation of a SQL query
+type ExplainResponse struct {
+	// Step-by-step explanation in plain language
+	Explanation string
+
 	// Usage metadata
 	Usage UsageMetadata
 }
@@ -75,8 +185,14 @@ type UsageMetadata struct {
 	// Total tokens used (prompt + response)
 	TotalTokens int
 
-	// Number of cached tokens (if applicable)
+	// Number of prompt tokens read from the provider's prompt cache (if applicable)
 	CachedTokens int
+
+	// Number of prompt tokens written to the provider's prompt cache (if applicable)
+	CacheWriteTokens int
+
+	// Providers that failed transiently before Provider answered (fallback chain only)
+	FallbackFrom []string
 }
 
 // ============================================
@@ -91,6 +207,7 @@ const (
 	ProviderClaude ProviderType = "claude"
 	ProviderGemini ProviderType = "gemini"
 	ProviderOllama ProviderType = "ollama"
+	ProviderReplay ProviderType = "replay"
 )
 
 // ============================================
@@ -119,6 +236,21 @@ type Config struct {
 
 	// Provider-specific options
 	Options map[string]any
+
+	// Prompt templates used to build the system prompt (nil = built-in defaults)
+	Prompts *prompt.Templates
+
+	// Times a transient failure (rate limit, timeout, 5xx) is retried with backoff before giving up
+	MaxRetries int
+
+	// Client-side limit of requests per minute sent to the provider (0 = unlimited)
+	RequestsPerMinute int
+
+	// Path of a cassette file recording every response of the provider (empty = disabled)
+	RecordTo string
+
+	// Fallbacks are tried in order when this provider fails transiently (rate limit, timeout, 5xx)
+	Fallbacks []Config
 }
 
 // ============================================
@@ -135,14 +267,76 @@ func RegisterProvider(providerType ProviderType, factory ProviderFactory) {
 	providerRegistry[providerType] = factory
 }
 
-// NewProvider creates a new AI provider based on config
+// RecorderFactory wraps a provider so that its responses are recorded to a cassette file
+type RecorderFactory func(provider Provider, path string) (Provider, error)
+
+var recorderFactory RecorderFactory
+
+// RegisterRecorder registers the decorator used when Config.RecordTo is set
+func RegisterRecorder(factory RecorderFactory) {
+	recorderFactory = factory
+}
+
+// NewProvider creates a new AI provider based on config.
+// Each provider retries transient failures on its own (see RetryProvider) before a
+// FallbackProvider, built when config has fallbacks, moves on to the next one.
+// When config.RecordTo is set, the resulting provider is wrapped by the registered recorder.
 func NewProvider(config Config) (Provider, error) {
+	provider, err := newChain(config)
+	if err != nil || config.RecordTo == "" {
+		return provider, err
+	}
+
+	if recorderFactory == nil {
+		_ = provider.Close()
+		return nil, fmt.Errorf("recording is not available: %w", ErrInvalidConfig)
+	}
+
+	return recorderFactory(provider, config.RecordTo)
+}
+
+// newChain creates the provider for config, with its fallbacks if any
+func newChain(config Config) (Provider, error) {
+	if len(config.Fallbacks) == 0 {
+		return newRegisteredProvider(config)
+	}
+
+	primary := config
+	primary.Fallbacks = nil
+	configs := append([]Config{primary}, config.Fallbacks...)
+
+	providers := make([]Provider, 0, len(configs))
+	for _, c := range configs {
+		provider, err := newRegisteredProvider(c)
+		if err != nil {
+			// Release the providers created so far (best effort)
+			_ = NewFallbackProvider(providers...).Close()
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+
+	return NewFallbackProvider(providers...), nil
+}
+
+// newRegisteredProvider creates a single provider using its registered factory,
+// wrapped with retries and rate limiting when configured
+func newRegisteredProvider(config Config) (Provider, error) {
 	factory, exists := providerRegistry[config.Type]
 	if !exists {
 		return nil, fmt.Errorf("%w: %s", ErrUnsupportedProvider, config.Type)
 	}
 
-	return factory(config)
+	provider, err := factory(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.MaxRetries > 0 || config.RequestsPerMinute > 0 {
+		return NewRetryProvider(provider, config.MaxRetries, config.RequestsPerMinute), nil
+	}
+
+	return provider, nil
 }
 
 // ListProviders returns a list of registered provider types
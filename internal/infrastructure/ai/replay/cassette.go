@@ -0,0 +1,152 @@
+// Package replay implements a provider that serves recorded AI responses from a cassette
+// file, and a recorder that writes such cassettes while a real provider is used.
+package replay
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+)
+
+// CassetteVersion is the current cassette file format version
+const CassetteVersion = 1
+
+// Interaction is one recorded request/response pair
+type Interaction struct {
+	// Key identifies the request (see RequestKey, AnswerKey and ExplainKey)
+	Key string `json:"key"`
+
+	// Prompt is the user's prompt, question or query, kept to make cassettes readable
+	Prompt string `json:"prompt"`
+
+	// Chunks is the streamed response text, in order (empty for non-streaming requests)
+	Chunks []string `json:"chunks,omitempty"`
+
+	// Response is the provider's final response to a SQL generation request
+	Response ai.GenerateResponse `json:"response,omitzero"`
+
+	// Answer is the provider's response to an answer request
+	Answer *ai.AnswerResponse `json:"answer,omitempty"`
+
+	// Explanation is the provider's response to an explain request
+	Explanation *ai.ExplainResponse `json:"explanation,omitempty"`
+}
+
+// Cassette is a set of recorded interactions stored as a JSON file
+type Cassette struct {
+	mu   sync.Mutex
+	path string
+
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// LoadCassette reads the cassette at path. If create is true, a missing file
+// yields an empty cassette that will be written on the first Save.
+func LoadCassette(path string, create bool) (*Cassette, error) {
+	cassette := &Cassette{path: path, Version: CassetteVersion}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && create {
+		return cassette, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	if err := json.Unmarshal(data, cassette); err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %w", path, err)
+	}
+	if cassette.Version != CassetteVersion {
+		return nil, fmt.Errorf("unsupported cassette version %d in %s", cassette.Version, path)
+	}
+
+	return cassette, nil
+}
+
+// Find returns the recorded interactions for key, in recording order
+func (c *Cassette) Find(key string) []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var found []Interaction
+	for _, interaction := range c.Interactions {
+		if interaction.Key == key {
+			found = append(found, interaction)
+		}
+	}
+	return found
+}
+
+// Append adds an interaction and writes the cassette to disk
+func (c *Cassette) Append(interaction Interaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Interactions = append(c.Interactions, interaction)
+	return c.save()
+}
+
+// save writes the cassette atomically (temporary file + rename).
+// The caller must hold c.mu.
+func (c *Cassette) save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), ".cassette-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), c.path)
+}
+
+// RequestKey returns the cassette key of a request: a hash of everything the
+// provider sees (prompt, schema, context, database type, temperature override and
+// conversation turns)
+func RequestKey(req *ai.GenerateRequest) string {
+	fields := []string{req.Prompt, req.Schema, req.Context, req.DatabaseType}
+	if req.Temperature != nil {
+		fields = append(fields, "temperature", strconv.FormatFloat(*req.Temperature, 'g', -1, 64))
+	}
+	for _, message := range req.Messages {
+		fields = append(fields, string(message.Role), message.Content)
+	}
+	return hashKey(fields)
+}
+
+// AnswerKey returns the cassette key of an answer request
+func AnswerKey(req *ai.AnswerRequest) string {
+	return hashKey([]string{"answer", req.Question, req.Query, req.Result, req.DatabaseType})
+}
+
+// ExplainKey returns the cassette key of an explain request
+func ExplainKey(req *ai.ExplainRequest) string {
+	return hashKey([]string{"explain", req.Query, req.Plan, req.DatabaseType})
+}
+
+// hashKey hashes the fields identifying a request
+func hashKey(fields []string) string {
+	data, _ := json.Marshal(fields)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
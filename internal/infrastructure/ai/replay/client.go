@@ -0,0 +1,173 @@
+// Package replay implements the AI provider interface by replaying recorded responses.
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+)
+
+// OptionCassette is the ai.Config.Options key holding the cassette file path
+const OptionCassette = "cassette"
+
+// ErrNotRecorded is returned when the cassette has no response for a request
+var ErrNotRecorded = errors.New("no recorded response for this request")
+
+// ============================================
+// Replay Provider Implementation
+// ============================================
+
+// Client implements the ai.Provider interface by serving responses from a cassette.
+// Requests are matched by RequestKey; when the same request was recorded several times,
+// the recordings are replayed in order and the last one is repeated afterwards.
+type Client struct {
+	cassette *Cassette
+
+	mu     sync.Mutex
+	served map[string]int // number of responses served per key
+}
+
+// Ensure Client implements ai.Provider interface
+var _ ai.Provider = (*Client)(nil)
+
+// ============================================
+// Factory Registration
+// ============================================
+
+func init() {
+	// Auto-register this provider and the cassette recorder on package import
+	ai.RegisterProvider(ai.ProviderReplay, New)
+	ai.RegisterRecorder(NewRecorder)
+}
+
+// New creates a new replay provider (implements ai.ProviderFactory)
+func New(config ai.Config) (ai.Provider, error) {
+	path, _ := config.Options[OptionCassette].(string)
+	if path == "" {
+		return nil, fmt.Errorf("replay cassette file is required: %w", ai.ErrInvalidConfig)
+	}
+
+	cassette, err := LoadCassette(path, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		cassette: cassette,
+		served:   make(map[string]int),
+	}, nil
+}
+
+// ============================================
+// Interface Implementation
+// ============================================
+
+// GenerateSQL returns the recorded response for the request
+func (c *Client) GenerateSQL(ctx context.Context, req *ai.GenerateRequest) (*ai.GenerateResponse, error) {
+	return c.GenerateSQLStream(ctx, req, nil)
+}
+
+// GenerateSQLStream returns the recorded response, replaying its recorded chunks to onChunk
+func (c *Client) GenerateSQLStream(ctx context.Context, req *ai.GenerateRequest, onChunk ai.StreamHandler) (*ai.GenerateResponse, error) {
+	if req.Prompt == "" {
+		return nil, ai.ErrEmptyPrompt
+	}
+
+	interaction, err := c.next(RequestKey(req))
+	if err != nil {
+		return nil, fmt.Errorf("%w (prompt %q)", err, req.Prompt)
+	}
+
+	if onChunk != nil {
+		for _, chunk := range interaction.Chunks {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			onChunk(chunk)
+		}
+	}
+
+	resp := interaction.Response
+
+	// Replayed responses cost nothing: report them as such
+	resp.Usage.Provider = c.Name()
+	resp.Usage.FallbackFrom = nil
+
+	return &resp, nil
+}
+
+// Answer returns the recorded answer for the request
+func (c *Client) Answer(ctx context.Context, req *ai.AnswerRequest) (*ai.AnswerResponse, error) {
+	if req.Question == "" {
+		return nil, ai.ErrEmptyPrompt
+	}
+
+	interaction, err := c.next(AnswerKey(req))
+	if err != nil {
+		return nil, fmt.Errorf("%w (question %q)", err, req.Question)
+	}
+	if interaction.Answer == nil {
+		return nil, ai.ErrGenerationFailed
+	}
+
+	resp := *interaction.Answer
+	resp.Usage.Provider = c.Name()
+	resp.Usage.FallbackFrom = nil
+
+	return &resp, nil
+}
+
+// ExplainSQL returns the recorded explanation for the request
+func (c *Client) ExplainSQL(ctx context.Context, req *ai.ExplainRequest) (*ai.ExplainResponse, error) {
+	if req.Query == "" {
+		return nil, ai.ErrEmptyPrompt
+	}
+
+	interaction, err := c.next(ExplainKey(req))
+	if err != nil {
+		return nil, fmt.Errorf("%w (query %q)", err, req.Query)
+	}
+	if interaction.Explanation == nil {
+		return nil, ai.ErrGenerationFailed
+	}
+
+	resp := *interaction.Explanation
+	resp.Usage.Provider = c.Name()
+	resp.Usage.FallbackFrom = nil
+
+	return &resp, nil
+}
+
+// Name returns the provider name
+func (c *Client) Name() string {
+	return string(ai.ProviderReplay)
+}
+
+// Close releases any resources held by the provider
+func (c *Client) Close() error {
+	// Cassettes are read once, nothing to release
+	return nil
+}
+
+// ============================================
+// Helper Functions
+// ============================================
+
+// next returns the next recorded interaction for key
+func (c *Client) next(key string) (Interaction, error) {
+	recorded := c.cassette.Find(key)
+	if len(recorded) == 0 {
+		return Interaction{}, ErrNotRecorded
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	i := min(c.served[key], len(recorded)-1)
+	c.served[key]++
+
+	return recorded[i], nil
+}
@@ -0,0 +1,125 @@
+// Package replay provides a provider decorator recording responses to a cassette.
+package replay
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+)
+
+// Recorder wraps a provider and appends every successful response to a cassette,
+// which can later be served by the replay provider
+type Recorder struct {
+	provider ai.Provider
+	cassette *Cassette
+}
+
+// Ensure Recorder implements ai.Provider interface
+var _ ai.Provider = (*Recorder)(nil)
+
+// NewRecorder wraps provider, recording to the cassette at path (implements ai.RecorderFactory).
+// Recordings are appended to an existing cassette.
+func NewRecorder(provider ai.Provider, path string) (ai.Provider, error) {
+	cassette, err := LoadCassette(path, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Recorder{
+		provider: provider,
+		cassette: cassette,
+	}, nil
+}
+
+// GenerateSQL generates SQL with the wrapped provider and records the response
+func (r *Recorder) GenerateSQL(ctx context.Context, req *ai.GenerateRequest) (*ai.GenerateResponse, error) {
+	resp, err := r.provider.GenerateSQL(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.record(req, nil, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GenerateSQLStream streams SQL from the wrapped provider and records the chunks and response
+func (r *Recorder) GenerateSQLStream(ctx context.Context, req *ai.GenerateRequest, onChunk ai.StreamHandler) (*ai.GenerateResponse, error) {
+	var chunks []string
+	resp, err := r.provider.GenerateSQLStream(ctx, req, func(chunk string) {
+		chunks = append(chunks, chunk)
+		if onChunk != nil {
+			onChunk(chunk)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.record(req, chunks, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Answer answers with the wrapped provider and records the answer
+func (r *Recorder) Answer(ctx context.Context, req *ai.AnswerRequest) (*ai.AnswerResponse, error) {
+	resp, err := r.provider.Answer(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.cassette.Append(Interaction{
+		Key:    AnswerKey(req),
+		Prompt: req.Question,
+		Answer: resp,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record response: %w", err)
+	}
+	return resp, nil
+}
+
+// ExplainSQL explains SQL with the wrapped provider and records the explanation
+func (r *Recorder) ExplainSQL(ctx context.Context, req *ai.ExplainRequest) (*ai.ExplainResponse, error) {
+	resp, err := r.provider.ExplainSQL(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.cassette.Append(Interaction{
+		Key:         ExplainKey(req),
+		Prompt:      req.Query,
+		Explanation: resp,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record response: %w", err)
+	}
+	return resp, nil
+}
+
+// Name returns the wrapped provider name
+func (r *Recorder) Name() string {
+	return r.provider.Name()
+}
+
+// Close releases the wrapped provider
+func (r *Recorder) Close() error {
+	return r.provider.Close()
+}
+
+// record appends the interaction to the cassette
+func (r *Recorder) record(req *ai.GenerateRequest, chunks []string, resp *ai.GenerateResponse) error {
+	err := r.cassette.Append(Interaction{
+		Key:      RequestKey(req),
+		Prompt:   req.Prompt,
+		Chunks:   chunks,
+		Response: *resp,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+// Package ai provides a provider decorator that retries transient failures and rate limits requests.
+package ai
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRetryBaseDelay is the backoff before the first retry
+	DefaultRetryBaseDelay = 1 * time.Second
+
+	// DefaultRetryMaxDelay caps the backoff between two attempts
+	DefaultRetryMaxDelay = 30 * time.Second
+)
+
+// RetryProvider wraps a provider and retries transient failures (see IsTransient)
+// with jittered exponential backoff, honouring the Retry-After delay sent by the provider.
+// It can also space out requests to stay under a requests-per-minute limit.
+type RetryProvider struct {
+	provider   Provider
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	limiter    *rateLimiter
+}
+
+// Ensure RetryProvider implements Provider interface
+var _ Provider = (*RetryProvider)(nil)
+
+// NewRetryProvider creates a provider retrying up to maxRetries times and sending at most
+// requestsPerMinute requests per minute (0 = unlimited)
+func NewRetryProvider(provider Provider, maxRetries, requestsPerMinute int) *RetryProvider {
+	r := &RetryProvider{
+		provider:   provider,
+		maxRetries: max(maxRetries, 0),
+		baseDelay:  DefaultRetryBaseDelay,
+		maxDelay:   DefaultRetryMaxDelay,
+	}
+
+	if requestsPerMinute > 0 {
+		r.limiter = newRateLimiter(requestsPerMinute, time.Minute)
+	}
+
+	return r
+}
+
+// GenerateSQL generates SQL with the wrapped provider, retrying transient failures
+func (r *RetryProvider) GenerateSQL(ctx context.Context, req *GenerateRequest) (*GenerateResponse, error) {
+	return do(ctx, r, func() (*GenerateResponse, error) {
+		return r.provider.GenerateSQL(ctx, req)
+	})
+}
+
+// GenerateSQLStream streams SQL from the wrapped provider. A failed stream is only retried
+// if no chunk was delivered yet, otherwise the caller would see duplicated output.
+func (r *RetryProvider) GenerateSQLStream(ctx context.Context, req *GenerateRequest, onChunk StreamHandler) (*GenerateResponse, error) {
+	streamed := false
+	handler := func(chunk string) {
+		streamed = true
+		if onChunk != nil {
+			onChunk(chunk)
+		}
+	}
+
+	return do(ctx, r, func() (*GenerateResponse, error) {
+		resp, err := r.provider.GenerateSQLStream(ctx, req, handler)
+		if err != nil && streamed {
+			return nil, permanentError{err}
+		}
+		return resp, err
+	})
+}
+
+// Answer answers with the wrapped provider, retrying transient failures
+func (r *RetryProvider) Answer(ctx context.Context, req *AnswerRequest) (*AnswerResponse, error) {
+	return do(ctx, r, func() (*AnswerResponse, error) {
+		return r.provider.Answer(ctx, req)
+	})
+}
+
+// ExplainSQL explains SQL with the wrapped provider, retrying transient failures
+func (r *RetryProvider) ExplainSQL(ctx context.Context, req *ExplainRequest) (*ExplainResponse, error) {
+	return do(ctx, r, func() (*ExplainResponse, error) {
+		return r.provider.ExplainSQL(ctx, req)
+	})
+}
+
+// Name returns the wrapped provider name
+func (r *RetryProvider) Name() string {
+	return r.provider.Name()
+}
+
+// Close releases the wrapped provider
+func (r *RetryProvider) Close() error {
+	return r.provider.Close()
+}
+
+// do runs call until it succeeds, fails permanently or the retries of r are exhausted
+func do[T any](ctx context.Context, r *RetryProvider, call func() (T, error)) (T, error) {
+	var zero T
+
+	for attempt := 0; ; attempt++ {
+		if r.limiter != nil {
+			if err := r.limiter.wait(ctx); err != nil {
+				return zero, err
+			}
+		}
+
+		resp, err := call()
+		if err == nil {
+			return resp, nil
+		}
+
+		if permanent, ok := err.(permanentError); ok {
+			return zero, permanent.err
+		}
+		if !IsTransient(err) || attempt >= r.maxRetries {
+			if attempt > 0 {
+				return zero, fmt.Errorf("%w (gave up after %d attempts)", err, attempt+1)
+			}
+			return zero, err
+		}
+
+		delay := r.backoff(attempt, RetryAfter(err))
+
+		// Don't sleep past the caller's deadline, the retry would fail anyway
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return zero, err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return zero, err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the delay before retry number attempt+1. A Retry-After requested
+// by the provider wins; otherwise the exponential delay is jittered to avoid
+// synchronized retries ("equal jitter": half fixed, half random).
+func (r *RetryProvider) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+
+	// Doubled up to maxDelay, which shifting by attempt could overflow
+	delay := min(r.baseDelay, r.maxDelay)
+	for range attempt {
+		if delay >= r.maxDelay/2 {
+			delay = r.maxDelay
+			break
+		}
+		delay *= 2
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// permanentError marks an error that must not be retried even if it is transient
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+// ============================================
+// Rate Limiter
+// ============================================
+
+// rateLimiter allows at most limit requests in any sliding window of the given length
+type rateLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	sent   []time.Time // start times of the requests in the current window, oldest first
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:  limit,
+		window: window,
+	}
+}
+
+// wait blocks until a request may be sent, or ctx is done; the slot reserved for
+// the request is then given back
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+
+	// Forget requests that left the window
+	for len(l.sent) > 0 && now.Sub(l.sent[0]) >= l.window {
+		l.sent = l.sent[1:]
+	}
+
+	// Reserve the earliest slot: now, or when the oldest request leaves the window
+	slot := now
+	if len(l.sent) >= l.limit {
+		slot = l.sent[len(l.sent)-l.limit].Add(l.window)
+	}
+	l.sent = append(l.sent, slot)
+	l.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		if i := slices.Index(l.sent, slot); i >= 0 {
+			l.sent = slices.Delete(l.sent, i, i+1)
+		}
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,178 @@
+// Package ai provides structured (JSON) response handling shared by all AI providers.
+package ai
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// StructuredResponseName is the name used when registering the response schema
+// with providers (OpenAI json_schema name, Claude tool name, etc.)
+const StructuredResponseName = "submit_sql"
+
+// StructuredResponse is the JSON object providers are instructed to return
+type StructuredResponse struct {
+	// SQL query answering the user's request
+	SQL string `json:"sql"`
+
+	// Short explanation of why the query looks the way it does (joins, filters, ...)
+	Explanation string `json:"explanation"`
+
+	// Self-reported confidence (0.0-1.0)
+	Confidence float64 `json:"confidence"`
+
+	// Assumptions the model made about ambiguous parts of the request
+	Assumptions []string `json:"assumptions"`
+
+	// Question for the user when the request is too ambiguous to answer (SQL is empty then)
+	Clarification string `json:"clarification"`
+
+	// Reasoning found in <think> blocks around the JSON object (not part of the schema)
+	Reasoning string `json:"-"`
+}
+
+// ResponseSchema returns the JSON schema describing StructuredResponse.
+// The schema is strict-mode compatible: every property is required and no extra properties are allowed.
+func ResponseSchema() map[string]any {
+	return map[string]any{
+		"type":                 "object",
+		"properties":           ResponseSchemaProperties(),
+		"required":             ResponseSchemaRequired(),
+		"additionalProperties": false,
+	}
+}
+
+// ResponseSchemaProperties returns the property definitions of the response schema
+func ResponseSchemaProperties() map[string]any {
+	return map[string]any{
+		"sql": map[string]any{
+			"type":        "string",
+			"description": "The SQL query, without markdown formatting or comments",
+		},
+		"explanation": map[string]any{
+			"type":        "string",
+			"description": "Brief explanation of the chosen tables, joins and filters",
+		},
+		"confidence": map[string]any{
+			"type":        "number",
+			"description": "Confidence that the query answers the request, from 0.0 to 1.0",
+		},
+		"assumptions": map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string"},
+			"description": "Assumptions made about ambiguous parts of the request",
+		},
+		"clarification": map[string]any{
+			"type":        "string",
+			"description": "A question for the user when the request is too ambiguous to answer, with an empty sql; empty otherwise",
+		},
+	}
+}
+
+// ResponseSchemaRequired returns the required property names of the response schema
+func ResponseSchemaRequired() []string {
+	return []string{"sql", "explanation", "confidence", "assumptions", "clarification"}
+}
+
+// ParseStructuredResponse decodes a provider response into a StructuredResponse.
+// Models that ignore the requested format are tolerated: if the text is not a JSON
+// object, it is returned as the SQL with zero confidence and no explanation.
+// A JSON object with neither SQL nor a clarification question is treated the same way.
+// The <think> blocks of reasoning models are left out of the SQL and kept as Reasoning.
+func ParseStructuredResponse(text string) *StructuredResponse {
+	reasoning, text := SplitThinking(text)
+	trimmed := strings.TrimSpace(text)
+
+	// Some models wrap JSON in a markdown code block despite instructions
+	if strings.HasPrefix(trimmed, "```") {
+		if newline := strings.Index(trimmed, "\n"); newline != -1 {
+			trimmed = strings.TrimSpace(strings.TrimSuffix(trimmed[newline+1:], "```"))
+		}
+	}
+
+	var structured StructuredResponse
+	if strings.HasPrefix(trimmed, "{") && json.Unmarshal([]byte(trimmed), &structured) == nil && (structured.SQL != "" || structured.Clarification != "") {
+		structured.Confidence = min(max(structured.Confidence, 0), 1)
+		structured.Reasoning = reasoning
+		return &structured
+	}
+
+	return &StructuredResponse{SQL: text, Reasoning: reasoning}
+}
+
+// PartialSQL extracts the (possibly incomplete) value of the "sql" field from a
+// partially streamed JSON response. It is intended for live previews only.
+// If the text does not look like JSON, it is returned without markdown fences.
+// Nothing is previewed while a reasoning model is still in its <think> block.
+func PartialSQL(partial string) string {
+	_, partial = SplitThinking(partial)
+	trimmed := strings.TrimSpace(partial)
+	if strings.HasPrefix(trimmed, "```") {
+		newline := strings.Index(trimmed, "\n")
+		if newline == -1 {
+			return ""
+		}
+		trimmed = strings.TrimSpace(trimmed[newline+1:])
+	}
+
+	if !strings.HasPrefix(trimmed, "{") {
+		return strings.TrimSuffix(trimmed, "```")
+	}
+
+	// Locate the start of the "sql" string value
+	key := strings.Index(trimmed, `"sql"`)
+	if key == -1 {
+		return ""
+	}
+	rest := strings.TrimLeft(trimmed[key+len(`"sql"`):], " \t\r\n")
+	if !strings.HasPrefix(rest, ":") {
+		return ""
+	}
+	rest = strings.TrimLeft(rest[1:], " \t\r\n")
+	if !strings.HasPrefix(rest, `"`) {
+		return ""
+	}
+	rest = rest[1:]
+
+	// Decode the string value up to the closing quote or the end of the input
+	var sb strings.Builder
+	for i := 0; i < len(rest); {
+		c := rest[i]
+		switch {
+		case c == '"':
+			return sb.String()
+		case c != '\\':
+			r, size := utf8.DecodeRuneInString(rest[i:])
+			sb.WriteRune(r)
+			i += size
+		case i+1 >= len(rest):
+			// Incomplete escape sequence at the end of the stream
+			return sb.String()
+		default:
+			switch rest[i+1] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case 'u':
+				if i+6 > len(rest) {
+					return sb.String()
+				}
+				if code, err := strconv.ParseUint(rest[i+2:i+6], 16, 32); err == nil {
+					sb.WriteRune(rune(code))
+				}
+				i += 4
+			default:
+				// \" \\ \/ and friends map to the escaped character itself
+				sb.WriteByte(rest[i+1])
+			}
+			i += 2
+		}
+	}
+
+	return sb.String()
+}
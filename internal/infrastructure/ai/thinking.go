@@ -0,0 +1,59 @@
+// Package ai provides the handling of reasoning that models emit along with their answer.
+package ai
+
+import "strings"
+
+const (
+	thinkOpen  = "<think>"
+	thinkClose = "</think>"
+)
+
+// SplitThinking separates the <think>…</think> blocks that reasoning models such as
+// deepseek-r1 and qwen3 emit before their answer. It returns the text of the blocks
+// (joined by blank lines) and the answer without them. A closing tag without an opening
+// one ends a block opened by the chat template; an unclosed block runs to the end of
+// the text, as in a partially streamed response. Text without tags is returned unchanged.
+func SplitThinking(text string) (reasoning, answer string) {
+	if !strings.Contains(text, thinkOpen) && !strings.Contains(text, thinkClose) {
+		return "", text
+	}
+
+	var thoughts []string
+	var rest strings.Builder
+	for text != "" {
+		start := strings.Index(text, thinkOpen)
+		end := strings.Index(text, thinkClose)
+		switch {
+		case end != -1 && (start == -1 || end < start):
+			thoughts = append(thoughts, text[:end])
+			text = text[end+len(thinkClose):]
+		case start != -1:
+			rest.WriteString(text[:start])
+			text = text[start+len(thinkOpen):]
+			if end = strings.Index(text, thinkClose); end == -1 {
+				thoughts = append(thoughts, text)
+				text = ""
+			} else {
+				thoughts = append(thoughts, text[:end])
+				text = text[end+len(thinkClose):]
+			}
+		default:
+			rest.WriteString(text)
+			text = ""
+		}
+	}
+
+	return JoinReasoning(thoughts...), strings.TrimSpace(rest.String())
+}
+
+// JoinReasoning joins reasoning captured from different parts of a response
+// (e.g. a provider's reasoning field and <think> blocks), skipping empty parts
+func JoinReasoning(parts ...string) string {
+	var blocks []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			blocks = append(blocks, part)
+		}
+	}
+	return strings.Join(blocks, "\n\n")
+}
@@ -0,0 +1,94 @@
+// Package ai provides the tool-calling contract shared by providers that let the model
+// explore the database before answering.
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// MaxToolRounds is how many rounds of tool calls a provider allows per request.
+// After that the model must answer with SQL.
+const MaxToolRounds = 8
+
+// Tool describes a function the model can call while generating SQL
+type Tool struct {
+	// Function name (e.g. "list_tables")
+	Name string
+
+	// What the function does, shown to the model
+	Description string
+
+	// JSON schema definitions of the arguments, by argument name
+	Properties map[string]any
+
+	// Names of the required arguments
+	Required []string
+}
+
+// Schema returns the JSON schema of the arguments object
+func (t Tool) Schema() map[string]any {
+	properties := t.Properties
+	if properties == nil {
+		properties = map[string]any{}
+	}
+	required := t.Required
+	if required == nil {
+		required = []string{}
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// Toolset provides the tools offered to the model and runs the calls it makes
+type Toolset interface {
+	// Tools returns the tools the model may call
+	Tools() []Tool
+
+	// Call runs the named tool with its JSON-encoded arguments and returns the result as text
+	Call(ctx context.Context, name string, arguments json.RawMessage) (string, error)
+}
+
+// ToolCall records a tool called by the model while generating SQL
+type ToolCall struct {
+	// Tool name
+	Name string
+
+	// JSON-encoded arguments
+	Arguments string
+
+	// Error returned by the tool, empty when the call succeeded
+	Error string
+}
+
+// RunTool runs a tool call requested by the model. Failures are returned as text for the
+// model rather than as errors, so it can correct itself (e.g. after a misspelled table name).
+func RunTool(ctx context.Context, toolset Toolset, name string, arguments json.RawMessage) (string, ToolCall) {
+	if len(arguments) == 0 {
+		arguments = json.RawMessage("{}")
+	}
+	call := ToolCall{Name: name, Arguments: string(arguments)}
+
+	result, err := toolset.Call(ctx, name, arguments)
+	if err != nil {
+		call.Error = err.Error()
+		return fmt.Sprintf("error: %v", err), call
+	}
+	return result, call
+}
+
+// Add accumulates the token counts of another request made for the same response
+// (e.g. the rounds of a tool-calling exchange)
+func (u *UsageMetadata) Add(other UsageMetadata) {
+	u.PromptTokens += other.PromptTokens
+	u.ResponseTokens += other.ResponseTokens
+	u.TotalTokens += other.TotalTokens
+	u.CachedTokens += other.CachedTokens
+	u.CacheWriteTokens += other.CacheWriteTokens
+}
@@ -0,0 +1,75 @@
+package config
+
+// GenerationConfig holds settings that control how SQL is generated from natural language.
+type GenerationConfig struct {
+	// MaxRepairAttempts is how many times failing AI-generated SQL is sent back to the
+	// provider, together with the database error, before the error is shown (0 disables repair)
+	MaxRepairAttempts int
+
+	// MaxClarifications is how many clarifying questions the provider may ask the user about
+	// an ambiguous prompt before it must answer with SQL (0 disables clarifying questions)
+	MaxClarifications int
+
+	// SchemaCheck checks the tables and columns named by generated SQL against the schema
+	// before it runs; unknown names are sent back to the provider like database errors
+	SchemaCheck bool
+
+	// Candidates is how many candidate queries are generated per prompt. Above 1, candidates
+	// are checked with EXPLAIN and the one most of them agree on is selected
+	Candidates int
+
+	// ConversationTokens is the approximate token window for previous prompts and their SQL,
+	// sent as conversation turns. Older turns are summarized instead (0 = no limit)
+	ConversationTokens int
+
+	// ResultSampleRows is how many rows of the last result are sent to the AI, together with
+	// its columns and row count, so follow-ups can refer to it (0 = send no row values)
+	ResultSampleRows int
+
+	// Explore offers read-only tools to the provider (list_tables, describe_table, sample_rows,
+	// count_distinct) so it can inspect the database before writing SQL
+	Explore bool
+
+	// ExploreSchemaTables is how many tables the schema sent with prompts may describe when
+	// Explore is enabled. Larger databases only send table names and the provider describes
+	// the tables it needs with tools (0 = always send the full schema)
+	ExploreSchemaTables int
+
+	// RetrievalTables is how many tables a database may have before only the RelevantTables
+	// tables most relevant to each prompt, and their foreign-key neighbours, are sent
+	// (0 = retrieve on every database)
+	RetrievalTables int
+
+	// RelevantTables is how many tables are retrieved per prompt on large databases
+	// (0 disables retrieval)
+	RelevantTables int
+
+	// EmbeddingModel is the Ollama embedding model used to match prompts with tables by
+	// meaning during retrieval (empty = lexical matching only)
+	EmbeddingModel string
+
+	// ContextWindow is the context window of the provider's model in tokens. Requests that
+	// don't fit are cut down: older conversation turns are summarized, then constraint
+	// details and the least relevant tables are left out of the schema (0 = no limit)
+	ContextWindow int
+
+	// CharsPerToken is the average characters per token of the model's tokenizer, used to
+	// estimate the size of requests
+	CharsPerToken float64
+}
+
+// DefaultGeneration returns the default generation configuration
+func DefaultGeneration() GenerationConfig {
+	return GenerationConfig{
+		MaxRepairAttempts:   2,
+		MaxClarifications:   2,
+		SchemaCheck:         true,
+		Candidates:          1,
+		ConversationTokens:  2000,
+		ResultSampleRows:    5,
+		ExploreSchemaTables: 50,
+		RetrievalTables:     100,
+		RelevantTables:      15,
+		CharsPerToken:       4,
+	}
+}
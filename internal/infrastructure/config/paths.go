@@ -0,0 +1,33 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Dir returns the asqli configuration directory: $XDG_CONFIG_HOME/asqli,
+// or ~/.config/asqli when XDG_CONFIG_HOME is not set
+func Dir() (string, error) {
+	if xdgConfig := os.Getenv("XDG_CONFIG_HOME"); xdgConfig != "" {
+		return filepath.Join(xdgConfig, "asqli"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory: %w", err)
+	}
+
+	return filepath.Join(home, ".config", "asqli"), nil
+}
+
+// ProfileDir returns the configuration directory of a connection profile
+// (e.g. ~/.config/asqli/profiles/production)
+func ProfileDir(profile string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "profiles", profile), nil
+}
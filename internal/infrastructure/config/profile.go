@@ -0,0 +1,48 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ProfileSettingsFile is the name of the settings file in a profile's configuration directory
+const ProfileSettingsFile = "settings.json"
+
+// ProfileSettings are the settings of a connection profile, read from its settings.json
+// (e.g. ~/.config/asqli/profiles/production/settings.json)
+type ProfileSettings struct {
+	// ReadOnly makes every session of the profile read-only, whatever the command line says
+	ReadOnly bool `json:"read_only"`
+}
+
+// LoadProfileSettings reads the settings of a connection profile. A profile without
+// a settings file (or no profile at all) has the zero settings.
+func LoadProfileSettings(profile string) (ProfileSettings, error) {
+	var settings ProfileSettings
+	if profile == "" {
+		return settings, nil
+	}
+
+	dir, err := ProfileDir(profile)
+	if err != nil {
+		return settings, err
+	}
+
+	path := filepath.Join(dir, ProfileSettingsFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, fmt.Errorf("failed to read profile settings: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return settings, fmt.Errorf("invalid profile settings %s: %w", path, err)
+	}
+
+	return settings, nil
+}
@@ -19,4 +19,7 @@ type Adapter interface {
 
 	// GetDatabaseSchema retrieves schema information for all tables
 	GetDatabaseSchema(ctx context.Context, db *sql.DB) (string, error)
+
+	// ExplainQuery plans a single statement with EXPLAIN without executing it
+	ExplainQuery(ctx context.Context, db *sql.DB, query string) (*QueryPlan, error)
 }
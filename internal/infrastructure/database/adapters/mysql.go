@@ -3,10 +3,14 @@ package adapters
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
-	_ "github.com/go-sql-driver/mysql" // Import the MySQL driver
+	"github.com/go-sql-driver/mysql"
 )
 
 // MySQLAdapter implements the Adapter interface for MySQL
@@ -15,27 +19,71 @@ type MySQLAdapter struct{}
 // Ensure MySQLAdapter implements Adapter interface
 var _ Adapter = (*MySQLAdapter)(nil)
 
-// Connect establishes a connection to a MySQL database
+// Connect establishes a connection to a MySQL database. Read-only sessions make every
+// connection read-only as soon as it is opened, so statements that commit implicitly,
+// such as DDL, can't write either once the read-only transaction has ended.
 func (a *MySQLAdapter) Connect(config Config) (*sql.DB, error) {
-	if config.ConnectionString != "" {
-		return sql.Open("mysql", config.ConnectionString)
+	connStr := config.ConnectionString
+	if connStr == "" {
+		// MySQL connection string: username:password@tcp(host:port)/dbname
+		connStr = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s",
+			config.User, config.Password, config.Host, config.Port, config.DBName)
+
+		// Add parameters like parseTime if needed
+		if config.ParseTime {
+			connStr += "?parseTime=true"
+		}
+	}
+
+	if !config.ReadOnly {
+		return sql.Open("mysql", connStr)
+	}
+
+	dsn, err := mysql.ParseDSN(connStr)
+	if err != nil {
+		return nil, err
+	}
+	connector, err := mysql.NewConnector(dsn)
+	if err != nil {
+		return nil, err
 	}
 
-	// MySQL connection string: username:password@tcp(host:port)/dbname
-	connStr := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s",
-		config.User, config.Password, config.Host, config.Port, config.DBName)
+	return sql.OpenDB(readOnlyConnector{connector}), nil
+}
+
+// erUnknownSystemVariable is the MySQL error number for SET on a variable the server lacks
+const erUnknownSystemVariable = 1193
+
+// readOnlyConnector opens MySQL connections whose session is read-only
+type readOnlyConnector struct {
+	driver.Connector
+}
+
+// Connect opens a connection and sets transaction_read_only on it. MySQL before 5.7.20
+// and MariaDB before 11.1 only know the variable as tx_read_only.
+func (c readOnlyConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	// Add parameters like parseTime if needed
-	params := []string{}
-	if config.ParseTime {
-		params = append(params, "parseTime=true")
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		_ = conn.Close()
+		return nil, fmt.Errorf("mysql connection can't execute statements")
 	}
 
-	if len(params) > 0 {
-		connStr = connStr + "?" + strings.Join(params, "&")
+	_, err = execer.ExecContext(ctx, "SET SESSION transaction_read_only = 1", nil)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == erUnknownSystemVariable {
+		_, err = execer.ExecContext(ctx, "SET SESSION tx_read_only = 1", nil)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to make the session read-only: %w", err)
 	}
 
-	return sql.Open("mysql", connStr)
+	return conn, nil
 }
 
 // GetTableNames retrieves all table names from a MySQL database
@@ -70,7 +118,8 @@ func (a *MySQLAdapter) GetTableDefinition(ctx context.Context, db *sql.DB, table
 			IS_NULLABLE,
 			COLUMN_DEFAULT,
 			COLUMN_KEY = 'PRI' AS is_primary,
-			EXTRA = 'auto_increment' AS is_autoincrement
+			EXTRA = 'auto_increment' AS is_autoincrement,
+			EXTRA LIKE '%VIRTUAL GENERATED%' OR EXTRA LIKE '%STORED GENERATED%' AS is_generated
 		FROM
 			INFORMATION_SCHEMA.COLUMNS
 		WHERE
@@ -93,6 +142,7 @@ func (a *MySQLAdapter) GetTableDefinition(ctx context.Context, db *sql.DB, table
 		var defaultValue sql.NullString
 		var isPrimary bool
 		var isAutoIncr bool
+		var isGenerated bool
 
 		if err := rows.Scan(
 			&column.Name,
@@ -101,6 +151,7 @@ func (a *MySQLAdapter) GetTableDefinition(ctx context.Context, db *sql.DB, table
 			&defaultValue,
 			&isPrimary,
 			&isAutoIncr,
+			&isGenerated,
 		); err != nil {
 			return nil, err
 		}
@@ -111,6 +162,7 @@ func (a *MySQLAdapter) GetTableDefinition(ctx context.Context, db *sql.DB, table
 		}
 		column.IsPrimary = isPrimary
 		column.IsAutoIncr = isAutoIncr
+		column.IsGenerated = isGenerated
 
 		columns = append(columns, column)
 	}
@@ -134,7 +186,9 @@ func (a *MySQLAdapter) GetTableDefinition(ctx context.Context, db *sql.DB, table
 			tc.TABLE_NAME = ? AND
 			tc.CONSTRAINT_TYPE != 'CHECK'
 		ORDER BY
-			tc.CONSTRAINT_TYPE
+			tc.CONSTRAINT_TYPE,
+			tc.CONSTRAINT_NAME,
+			kcu.ORDINAL_POSITION
 	`
 
 	constraintRows, err := db.QueryContext(ctx, constraintsQuery, tableName)
@@ -229,3 +283,30 @@ func (a *MySQLAdapter) GetDatabaseSchema(ctx context.Context, db *sql.DB) (strin
 	// Use shared formatter
 	return FormatDatabaseSchema(tableDefs), nil
 }
+
+// ExplainQuery plans a query with MySQL's EXPLAIN FORMAT=JSON, which never executes it.
+// The cost is read from query_block.cost_info (MariaDB and some statements don't report one).
+func (a *MySQLAdapter) ExplainQuery(ctx context.Context, db *sql.DB, query string) (*QueryPlan, error) {
+	var planJSON string
+	if err := db.QueryRowContext(ctx, "EXPLAIN FORMAT=JSON "+query).Scan(&planJSON); err != nil {
+		return nil, err
+	}
+
+	plan := &QueryPlan{Plan: planJSON}
+
+	var parsed struct {
+		QueryBlock struct {
+			CostInfo struct {
+				QueryCost string `json:"query_cost"`
+			} `json:"cost_info"`
+		} `json:"query_block"`
+	}
+	if err := json.Unmarshal([]byte(planJSON), &parsed); err == nil && parsed.QueryBlock.CostInfo.QueryCost != "" {
+		if cost, err := strconv.ParseFloat(parsed.QueryBlock.CostInfo.QueryCost, 64); err == nil {
+			plan.Cost = cost
+			plan.HasCost = true
+		}
+	}
+
+	return plan, nil
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 
 	_ "github.com/lib/pq" // Import the PostgreSQL driver
 )
@@ -68,7 +71,8 @@ func (a *PostgresAdapter) GetTableDefinition(ctx context.Context, db *sql.DB, ta
 			c.is_nullable,
 			c.column_default,
 			CASE WHEN pk.column_name IS NOT NULL THEN true ELSE false END AS is_primary,
-			CASE WHEN c.column_default LIKE '%nextval%' THEN true ELSE false END AS is_autoincrement
+			CASE WHEN c.column_default LIKE '%nextval%' THEN true ELSE false END AS is_autoincrement,
+			CASE WHEN c.is_generated = 'ALWAYS' OR c.identity_generation = 'ALWAYS' THEN true ELSE false END AS is_generated
 		FROM
 			information_schema.columns c
 		LEFT JOIN (
@@ -99,6 +103,7 @@ func (a *PostgresAdapter) GetTableDefinition(ctx context.Context, db *sql.DB, ta
 		var defaultValue sql.NullString
 		var isPrimary bool
 		var isAutoIncr bool
+		var isGenerated bool
 
 		if err := rows.Scan(
 			&column.Name,
@@ -107,6 +112,7 @@ func (a *PostgresAdapter) GetTableDefinition(ctx context.Context, db *sql.DB, ta
 			&defaultValue,
 			&isPrimary,
 			&isAutoIncr,
+			&isGenerated,
 		); err != nil {
 			return nil, err
 		}
@@ -117,6 +123,7 @@ func (a *PostgresAdapter) GetTableDefinition(ctx context.Context, db *sql.DB, ta
 		}
 		column.IsPrimary = isPrimary
 		column.IsAutoIncr = isAutoIncr
+		column.IsGenerated = isGenerated
 
 		columns = append(columns, column)
 	}
@@ -146,7 +153,8 @@ func (a *PostgresAdapter) GetTableDefinition(ctx context.Context, db *sql.DB, ta
 			cl.relname = $1 AND
 			cl.relkind = 'r'
 		ORDER BY
-			c.contype
+			c.contype,
+			c.conname
 	`
 
 	constraintRows, err := db.QueryContext(ctx, constraintsQuery, tableName)
@@ -196,3 +204,40 @@ func (a *PostgresAdapter) GetDatabaseSchema(ctx context.Context, db *sql.DB) (st
 	// Use shared formatter
 	return FormatDatabaseSchema(tableDefs), nil
 }
+
+// postgresCostRegex extracts the total cost from the root node of a text EXPLAIN plan
+// (e.g. "Seq Scan on users  (cost=0.00..35.50 rows=2550 width=36)")
+var postgresCostRegex = regexp.MustCompile(`cost=[0-9.]+\.\.([0-9.]+)`)
+
+// ExplainQuery plans a query with PostgreSQL's EXPLAIN (without ANALYZE, so nothing is executed)
+func (a *PostgresAdapter) ExplainQuery(ctx context.Context, db *sql.DB, query string) (*QueryPlan, error) {
+	rows, err := db.QueryContext(ctx, "EXPLAIN "+query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	plan := &QueryPlan{Plan: strings.Join(lines, "\n")}
+	if len(lines) > 0 {
+		if match := postgresCostRegex.FindStringSubmatch(lines[0]); match != nil {
+			if cost, err := strconv.ParseFloat(match[1], 64); err == nil {
+				plan.Cost = cost
+				plan.HasCost = true
+			}
+		}
+	}
+
+	return plan, nil
+}
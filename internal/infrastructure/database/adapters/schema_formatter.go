@@ -3,6 +3,7 @@ package adapters
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -38,8 +39,13 @@ func FormatTableDefinition(tableDef *TableDefinition) string {
 			autoIncr = " AUTO_INCREMENT"
 		}
 
-		sb.WriteString(fmt.Sprintf("  %s %s %s%s%s%s\n",
-			col.Name, col.Type, nullable, defaultVal, primaryKey, autoIncr))
+		generated := ""
+		if col.IsGenerated {
+			generated = " GENERATED"
+		}
+
+		sb.WriteString(fmt.Sprintf("  %s %s %s%s%s%s%s\n",
+			col.Name, col.Type, nullable, defaultVal, primaryKey, autoIncr, generated))
 	}
 
 	// Constraints
@@ -62,12 +68,19 @@ func FormatTableDefinition(tableDef *TableDefinition) string {
 }
 
 // FormatDatabaseSchema formats all table definitions into a complete schema string
-// This is shared across all database adapters to ensure consistent formatting
+// This is shared across all database adapters to ensure consistent formatting.
+// Tables are sorted by name so the output is byte-identical across runs, which
+// lets AI providers reuse their prompt cache for the schema.
 func FormatDatabaseSchema(tables []*TableDefinition) string {
 	var sb strings.Builder
 	sb.WriteString("DATABASE SCHEMA:\n\n")
 
-	for _, tableDef := range tables {
+	sorted := slices.Clone(tables)
+	slices.SortFunc(sorted, func(a, b *TableDefinition) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	for _, tableDef := range sorted {
 		sb.WriteString(FormatTableDefinition(tableDef))
 	}
 
@@ -19,20 +19,43 @@ var _ Adapter = (*SQLiteAdapter)(nil)
 
 // Connect establishes a connection to a SQLite database
 func (a *SQLiteAdapter) Connect(config Config) (*sql.DB, error) {
-	if config.ConnectionString != "" {
-		return sql.Open("sqlite3", config.ConnectionString)
+	var dsn string
+	switch {
+	case config.ConnectionString != "":
+		dsn = config.ConnectionString
+	case config.FilePath != "":
+		dsn = config.FilePath
+	case config.DBName != "":
+		// If only DBName is provided, use it as the file path
+		dsn = config.DBName
+	default:
+		return nil, errors.New("invalid database configuration")
 	}
 
-	if config.FilePath != "" {
-		return sql.Open("sqlite3", config.FilePath)
+	if config.ReadOnly {
+		dsn = readOnlyDSN(dsn)
 	}
 
-	if config.DBName != "" {
-		// If only DBName is provided, use it as the file path
-		return sql.Open("sqlite3", config.DBName)
+	return sql.Open("sqlite3", dsn)
+}
+
+// readOnlyDSN turns a SQLite file path or URI into a URI opening the database read-only
+// (mode=ro) with writes refused on every connection (query_only). Parameters are only
+// passed to SQLite for file: URIs, so plain paths become one.
+func readOnlyDSN(dsn string) string {
+	if !strings.HasPrefix(dsn, "file:") {
+		path, params, _ := strings.Cut(dsn, "?")
+		dsn = "file:" + strings.NewReplacer("%", "%25", "#", "%23").Replace(path)
+		if params != "" {
+			dsn += "?" + params
+		}
 	}
 
-	return nil, errors.New("invalid database configuration")
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	return dsn + separator + "mode=ro&_query_only=1"
 }
 
 // GetTableNames retrieves all table names from a SQLite database
@@ -68,9 +91,9 @@ func (a *SQLiteAdapter) GetTableDefinition(ctx context.Context, db *sql.DB, tabl
 		return nil, fmt.Errorf("invalid table name: %w", err)
 	}
 
-	// Get pragma info for columns
+	// Get pragma info for columns (table_xinfo also lists generated columns)
 	// Note: PRAGMA statements don't support parameter binding, so we validate the input strictly
-	pragmaQuery := fmt.Sprintf("PRAGMA table_info(%s)", tableName)
+	pragmaQuery := fmt.Sprintf("PRAGMA table_xinfo(%s)", tableName)
 
 	rows, err := db.QueryContext(ctx, pragmaQuery)
 	if err != nil {
@@ -82,18 +105,25 @@ func (a *SQLiteAdapter) GetTableDefinition(ctx context.Context, db *sql.DB, tabl
 	for rows.Next() {
 		var cid int
 		var name, dataType string
-		var notNull, isPrimary int
+		var notNull, isPrimary, hidden int
 		var defaultValue sql.NullString
 
-		if err := rows.Scan(&cid, &name, &dataType, &notNull, &defaultValue, &isPrimary); err != nil {
+		if err := rows.Scan(&cid, &name, &dataType, &notNull, &defaultValue, &isPrimary, &hidden); err != nil {
 			return nil, err
 		}
 
+		// Hidden columns of virtual tables; 2 and 3 are virtual and stored generated columns
+		if hidden == 1 {
+			continue
+		}
+
+		// pk is the position of the column in the primary key, 0 outside of it
 		column := ColumnDefinition{
-			Name:      name,
-			Type:      dataType,
-			Nullable:  notNull == 0,
-			IsPrimary: isPrimary == 1,
+			Name:        name,
+			Type:        dataType,
+			Nullable:    notNull == 0,
+			IsPrimary:   isPrimary > 0,
+			IsGenerated: hidden == 2 || hidden == 3,
 		}
 
 		if defaultValue.Valid {
@@ -101,7 +131,7 @@ func (a *SQLiteAdapter) GetTableDefinition(ctx context.Context, db *sql.DB, tabl
 		}
 
 		// Check if it's an autoincrement column
-		if isPrimary == 1 {
+		if isPrimary > 0 {
 			// In SQLite, autoincrement is only applicable to INTEGER PRIMARY KEY columns
 			if strings.ToUpper(dataType) == "INTEGER" {
 				// Get the SQL that created the table
@@ -200,15 +230,15 @@ func (a *SQLiteAdapter) GetTableDefinition(ctx context.Context, db *sql.DB, tabl
 
 	for indexRows.Next() {
 		var seq int
-		var name string
-		var unique, origin, partial int
+		var name, origin string // origin: "c" (CREATE INDEX), "u" (UNIQUE) or "pk"
+		var unique, partial int
 
 		if err := indexRows.Scan(&seq, &name, &unique, &origin, &partial); err != nil {
 			return nil, err
 		}
 
 		// Only process unique constraints
-		if unique == 1 && origin == 'u' {
+		if unique == 1 && origin == "u" {
 			// Validate index name before using it in query
 			if err := ValidateIndexName(name); err != nil {
 				// Skip invalid index names instead of failing
@@ -276,3 +306,36 @@ func (a *SQLiteAdapter) GetDatabaseSchema(ctx context.Context, db *sql.DB) (stri
 	// Use shared formatter
 	return FormatDatabaseSchema(tableDefs), nil
 }
+
+// ExplainQuery plans a query with SQLite's EXPLAIN QUERY PLAN, which never executes it.
+// SQLite doesn't expose cost estimates, so only the plan tree is returned.
+func (a *SQLiteAdapter) ExplainQuery(ctx context.Context, db *sql.DB, query string) (*QueryPlan, error) {
+	rows, err := db.QueryContext(ctx, "EXPLAIN QUERY PLAN "+query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	// Rows form a tree through their parent id; indent each step under its parent
+	depth := make(map[int]int)
+	var lines []string
+	for rows.Next() {
+		var id, parent, notUsed int
+		var detail string
+		if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+			return nil, err
+		}
+
+		level := 0
+		if d, ok := depth[parent]; ok {
+			level = d + 1
+		}
+		depth[id] = level
+		lines = append(lines, strings.Repeat("  ", level)+detail)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &QueryPlan{Plan: strings.Join(lines, "\n")}, nil
+}
@@ -32,6 +32,11 @@ type Config struct {
 	SSLMode   string // For PostgreSQL
 	FilePath  string // For SQLite
 	ParseTime bool   // For MySQL
+
+	// ReadOnly makes the session unable to write: every query runs in a read-only
+	// transaction, MySQL connections set transaction_read_only and SQLite databases
+	// are opened with mode=ro and query_only
+	ReadOnly bool
 }
 
 // TableDefinition contains information about a database table
@@ -49,6 +54,10 @@ type ColumnDefinition struct {
 	Default    string
 	IsPrimary  bool
 	IsAutoIncr bool
+
+	// IsGenerated is set for columns whose values the database computes and won't take
+	// from INSERT or UPDATE: generated (computed) columns and GENERATED ALWAYS identities
+	IsGenerated bool
 }
 
 // ConstraintDefinition contains information about table constraints
@@ -59,3 +68,13 @@ type ConstraintDefinition struct {
 	ReferencedTable   string
 	ReferencedColumns []string
 }
+
+// QueryPlan contains the execution plan chosen by the database for a query that was not run
+type QueryPlan struct {
+	// Plan is the plan as reported by the database (text tree or JSON, depending on the driver)
+	Plan string
+
+	// Cost is the planner's estimated total cost in database-specific units (valid when HasCost)
+	Cost    float64
+	HasCost bool
+}
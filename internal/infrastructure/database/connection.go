@@ -4,9 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/alessandrolattao/asqli/internal/infrastructure/config"
 	"github.com/alessandrolattao/asqli/internal/infrastructure/database/adapters"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/sqlparse"
 )
 
 // init registers all built-in database adapters
@@ -32,6 +34,9 @@ type Connection struct {
 
 	// Driver-specific adapter
 	adapter adapters.Adapter
+
+	// Every query runs in a read-only transaction
+	readOnly bool
 }
 
 // Open establishes a connection to the database using the specified configuration
@@ -67,6 +72,7 @@ func Open(dbConfig adapters.Config, timeoutConfig config.TimeoutConfig) (*Connec
 		DB:         db,
 		DriverType: dbConfig.DriverType,
 		adapter:    adapter,
+		readOnly:   dbConfig.ReadOnly,
 	}, nil
 }
 
@@ -79,9 +85,54 @@ func (c *Connection) Close() error {
 }
 
 // ExecuteQuery runs a SQL query with the given context and returns the result.
-// The context is used for cancellation and timeout control.
+// The context is used for cancellation and timeout control. In a read-only session the
+// query runs in a read-only transaction, and only one statement at a time: a second
+// statement could end the transaction and run outside of it. Statements that aren't
+// plain reads are refused too, since some, such as DDL on MySQL, commit implicitly.
 func (c *Connection) ExecuteQuery(ctx context.Context, query string) ([]map[string]any, []string, error) {
-	return ExecuteQuery(ctx, c.DB, query)
+	if !c.readOnly {
+		return ExecuteQuery(ctx, c.DB, query)
+	}
+
+	statements, err := sqlparse.Parse(query, c.DriverType)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrReadOnly, err)
+	}
+	if len(statements) > 1 {
+		return nil, nil, fmt.Errorf("%w: run one statement at a time", ErrReadOnly)
+	}
+	if risks := sqlparse.Risks(statements); len(risks) > 0 {
+		return nil, nil, fmt.Errorf("%w: the query %s", ErrReadOnly, strings.Join(risks, ", "))
+	}
+
+	return c.QueryReadOnly(ctx, query, 0)
+}
+
+// ExecInTransaction runs statements in a single transaction, committed only when all of them
+// succeed, and returns the number of rows they changed. Read-only sessions return ErrReadOnly.
+func (c *Connection) ExecInTransaction(ctx context.Context, statements []string) (int64, error) {
+	if c.readOnly {
+		return 0, ErrReadOnly
+	}
+
+	tx, err := c.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var affected int64
+	for _, statement := range statements {
+		result, err := tx.ExecContext(ctx, statement)
+		if err != nil {
+			return 0, err
+		}
+		if n, err := result.RowsAffected(); err == nil {
+			affected += n
+		}
+	}
+
+	return affected, tx.Commit()
 }
 
 // GetTableNames retrieves all table names from the database using the given context.
@@ -94,6 +145,25 @@ func (c *Connection) GetTableDefinition(ctx context.Context, tableName string) (
 	return c.adapter.GetTableDefinition(ctx, c.DB, tableName)
 }
 
+// GetTableDefinitions retrieves the definitions of all tables using the given context.
+func (c *Connection) GetTableDefinitions(ctx context.Context) ([]*adapters.TableDefinition, error) {
+	tables, err := c.GetTableNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	definitions := make([]*adapters.TableDefinition, 0, len(tables))
+	for _, table := range tables {
+		definition, err := c.GetTableDefinition(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, definition)
+	}
+
+	return definitions, nil
+}
+
 // GetDatabaseSchema retrieves schema information for all tables using the given context.
 func (c *Connection) GetDatabaseSchema(ctx context.Context) (string, error) {
 	return c.adapter.GetDatabaseSchema(ctx, c.DB)
@@ -0,0 +1,313 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alessandrolattao/asqli/internal/infrastructure/sqlparse"
+)
+
+// dryRunStatements are the statement types that can be dry run
+var dryRunStatements = []string{"INSERT", "UPDATE", "DELETE", "REPLACE"}
+
+// DryRun is a data-modifying statement executed in a transaction that is left open,
+// so that its impact can be looked at before it is committed or rolled back.
+// One of Commit and Rollback must be called: until then, the transaction holds a
+// connection and the locks the statement took.
+type DryRun struct {
+	tx *sql.Tx
+
+	// Rows the statement inserted, updated or deleted (-1 when the driver can't tell)
+	RowsAffected int64
+
+	// Sample of the rows the statement changed, with their column names (empty when the
+	// dialect can't tell which rows they are, e.g. MySQL inserts)
+	Rows    []map[string]any
+	Columns []string
+
+	// Rows are the rows the statement matched before it changed them (MySQL, without
+	// RETURNING), rather than the rows as the statement left them
+	Before bool
+
+	// Rows may not be the ones the statement changes: it has a LIMIT without ORDER BY,
+	// and the database is free to pick other rows when it runs (with Before)
+	Approximate bool
+
+	// Copy of the rows an UPDATE or DELETE statement changed, taken before it ran; nil
+	// for other statements, and for those whose rows can't be copied (see SnapshotErr)
+	Snapshot *Snapshot
+
+	// Why an UPDATE or DELETE statement has no snapshot (wraps ErrNoSnapshot)
+	SnapshotErr error
+}
+
+// DryRun executes query in a transaction left open and returns its impact, with up to
+// sampleRows of the changed rows. PostgreSQL and SQLite return the changed rows with
+// RETURNING (added to the statement when it has none); MySQL updates and deletes of a
+// single table are sampled with a SELECT of the rows they match, before they run.
+// Updates and deletes of a single table also get an undo snapshot of the rows they match.
+// Statements with a WITH clause are dry run too, without sample on MySQL nor snapshot.
+// Anything but a single INSERT, UPDATE, DELETE or REPLACE statement returns ErrNoDryRun,
+// and so does any query in a read-only session, which could never be committed.
+func (c *Connection) DryRun(ctx context.Context, query string, sampleRows int) (*DryRun, error) {
+	statements, err := sqlparse.Parse(query, c.DriverType)
+	if err != nil || c.readOnly || len(statements) != 1 {
+		return nil, ErrNoDryRun
+	}
+	statement := statements[0]
+	keyword, at := mainKeyword(statement)
+	if !slices.Contains(dryRunStatements, keyword) {
+		return nil, ErrNoDryRun
+	}
+
+	// Rows an update or delete matches, and its table, looked up before the transaction
+	// holds any lock
+	dryRun := &DryRun{}
+	var match string
+	switch {
+	case statement.Keyword == "UPDATE" || statement.Keyword == "DELETE":
+		match = matchQuery(statement, maxSnapshotRows+1)
+		dryRun.Snapshot, dryRun.SnapshotErr = c.newSnapshot(ctx, statement, match)
+		dryRun.Approximate = c.DriverType == MySQL && match != "" && unordered(statement.Tokens)
+	case keyword == "UPDATE" || keyword == "DELETE":
+		dryRun.SnapshotErr = fmt.Errorf("%w: the statement has a WITH clause", ErrNoSnapshot)
+	}
+
+	// The transaction outlives the context of the statement: it ends with Commit or Rollback
+	tx, err := c.DB.BeginTx(context.WithoutCancel(ctx), nil)
+	if err != nil {
+		return nil, err
+	}
+	dryRun.tx = tx
+
+	if match != "" && (dryRun.Snapshot != nil || c.DriverType == MySQL) {
+		err = dryRun.match(ctx, c.lockRows(match), c.DriverType, sampleRows, c.DriverType == MySQL)
+	}
+	if err == nil && c.DriverType == MySQL {
+		err = dryRun.exec(ctx, statement)
+	} else if err == nil {
+		err = dryRun.returning(ctx, statement, keyword, at, sampleRows)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	return dryRun, nil
+}
+
+// Commit makes the changes of the statement permanent
+func (d *DryRun) Commit() error {
+	return d.tx.Commit()
+}
+
+// Rollback discards the changes of the statement
+func (d *DryRun) Rollback() error {
+	return d.tx.Rollback()
+}
+
+// match selects the rows the statement matches, before it runs, into the snapshot and,
+// when sample is set, the first sampleRows of them into the sample
+func (d *DryRun) match(ctx context.Context, query string, dialect DriverType, sampleRows int, sample bool) error {
+	rows, columns, err := queryBinaryRows(ctx, d.tx, query, dialect, maxSnapshotRows+1)
+	if err != nil {
+		return fmt.Errorf("failed to select the rows to change: %w", err)
+	}
+
+	if sample {
+		d.Rows, d.Columns, d.Before = textRows(rows[:min(len(rows), sampleRows)]), columns, true
+	}
+
+	if d.Snapshot == nil {
+		return nil
+	}
+	if len(rows) > maxSnapshotRows {
+		d.Snapshot = nil
+		d.SnapshotErr = fmt.Errorf("%w: more than %d rows", ErrNoSnapshot, maxSnapshotRows)
+		return nil
+	}
+	if column := d.Snapshot.unknownColumn(columns); column != "" {
+		d.Snapshot = nil
+		d.SnapshotErr = fmt.Errorf("%w: column %s isn't in the schema", ErrNoSnapshot, column)
+		return nil
+	}
+	d.Snapshot.Rows, d.Snapshot.Columns = rows, columns
+	return nil
+}
+
+// returning runs statement, whose main keyword is tokens[at], with a RETURNING clause
+// and counts the rows it returns
+func (d *DryRun) returning(ctx context.Context, statement sqlparse.Statement, keyword string, at, sampleRows int) error {
+	query := statement.Text
+	if tokens := statement.Tokens; topLevel(tokens, "RETURNING") < 0 {
+		// SQLite takes RETURNING before the ORDER BY and LIMIT of updates and deletes
+		end := len(tokens)
+		if keyword == "UPDATE" || keyword == "DELETE" {
+			end = firstOf(tokens, at, "ORDER", "LIMIT")
+		}
+		if end < len(tokens) {
+			cut := tokens[end].Start - tokens[0].Start
+			query = query[:cut] + "RETURNING * " + query[cut:]
+		} else {
+			query += " RETURNING *"
+		}
+	}
+
+	rows, err := d.tx.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer closeRows(rows)
+
+	if d.Columns, err = rows.Columns(); err != nil {
+		return err
+	}
+
+	// Every row is read to count them, only the first sampleRows are kept
+	for rows.Next() {
+		d.RowsAffected++
+		if len(d.Rows) == sampleRows {
+			continue
+		}
+		row, err := scanRow(rows, d.Columns, nil)
+		if err != nil {
+			return err
+		}
+		d.Rows = append(d.Rows, row)
+	}
+
+	return rows.Err()
+}
+
+// exec runs statement and records how many rows it changed
+func (d *DryRun) exec(ctx context.Context, statement sqlparse.Statement) error {
+	d.RowsAffected = -1
+
+	result, err := d.tx.ExecContext(ctx, statement.Text)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil {
+		d.RowsAffected = affected
+	}
+
+	return nil
+}
+
+// matchQuery returns a SELECT of up to limit rows a single-table UPDATE or DELETE statement
+// matches, in its order and within its LIMIT, or an empty string for other statements
+// (inserts, updates and deletes of several tables, or skipping rows with an OFFSET)
+func matchQuery(statement sqlparse.Statement, limit int) string {
+	tokens := statement.Tokens
+
+	// Table span: DELETE [modifiers] FROM table [WHERE ...], UPDATE [modifiers] table SET ...
+	var begin, end int
+	switch statement.Keyword {
+	case "DELETE":
+		begin = topLevel(tokens, "FROM") + 1
+		for _, t := range tokens[1:max(begin-1, 1)] {
+			if !t.Is("LOW_PRIORITY") && !t.Is("QUICK") && !t.Is("IGNORE") {
+				// DELETE t1, t2 FROM ...
+				return ""
+			}
+		}
+		end = firstOf(tokens, begin, "WHERE", "ORDER", "LIMIT", "RETURNING")
+	case "UPDATE":
+		begin = 1
+		for begin < len(tokens) && (tokens[begin].Is("LOW_PRIORITY") || tokens[begin].Is("IGNORE")) {
+			begin++
+		}
+		if begin+1 < len(tokens) && tokens[begin].Is("OR") {
+			// SQLite: UPDATE OR REPLACE, OR IGNORE, ...
+			begin += 2
+		}
+		end = topLevel(tokens, "SET")
+		if from := firstOf(tokens, max(end, 0), "FROM", "WHERE"); from < len(tokens) && tokens[from].Is("FROM") {
+			// UPDATE ... SET ... FROM other tables
+			return ""
+		}
+	default:
+		return ""
+	}
+	if begin <= 0 || end <= begin || end > len(tokens) {
+		return ""
+	}
+	for _, t := range tokens[begin:end] {
+		if t.Is(",") || t.Is("JOIN") || t.Is("USING") {
+			return ""
+		}
+	}
+
+	text := func(from, to int) string {
+		offset := tokens[0].Start
+		return statement.Text[tokens[from].Start-offset : tokens[to-1].End-offset]
+	}
+
+	query := "SELECT * FROM " + text(begin, end)
+	limitAt := firstOf(tokens, end, "LIMIT")
+	if firstOf(tokens, end, "OFFSET") < len(tokens) || limitAt+2 < len(tokens) && tokens[limitAt+2].Is(",") {
+		// LIMIT ... OFFSET ..., LIMIT offset, count (SQLite)
+		return ""
+	}
+	if where := firstOf(tokens, end, "WHERE"); where < len(tokens) {
+		query += " " + text(where, firstOf(tokens, where, "ORDER", "LIMIT", "RETURNING"))
+	}
+	if order := firstOf(tokens, end, "ORDER"); order < len(tokens) {
+		query += " " + text(order, firstOf(tokens, order, "LIMIT", "RETURNING"))
+	}
+
+	if limitAt+1 < len(tokens) {
+		if n, err := strconv.Atoi(tokens[limitAt+1].Text); err == nil {
+			limit = min(limit, n)
+		}
+	}
+	return query + " LIMIT " + strconv.Itoa(limit)
+}
+
+// mainKeyword returns the keyword of the statement a WITH clause introduces, and its
+// index, or the statement's own keyword
+func mainKeyword(statement sqlparse.Statement) (string, int) {
+	if statement.Keyword != "WITH" {
+		return statement.Keyword, 0
+	}
+	i := firstOf(statement.Tokens, 1, "SELECT", "VALUES", "TABLE", "INSERT", "UPDATE", "DELETE", "REPLACE")
+	if i == len(statement.Tokens) {
+		return "", i
+	}
+	return strings.ToUpper(statement.Tokens[i].Text), i
+}
+
+// unordered reports whether a statement has a LIMIT without ORDER BY, which picks rows
+// in no set order
+func unordered(tokens []sqlparse.Token) bool {
+	return topLevel(tokens, "LIMIT") >= 0 && topLevel(tokens, "ORDER") < 0
+}
+
+// topLevel returns the index of the first occurrence of keyword outside parentheses, or -1
+func topLevel(tokens []sqlparse.Token, keyword string) int {
+	if i := firstOf(tokens, 0, keyword); i < len(tokens) {
+		return i
+	}
+	return -1
+}
+
+// firstOf returns the index of the first of keywords outside parentheses from tokens[from],
+// or len(tokens) when there is none
+func firstOf(tokens []sqlparse.Token, from int, keywords ...string) int {
+	depth := 0
+	for i := from; i < len(tokens); i++ {
+		switch {
+		case tokens[i].Is("("):
+			depth++
+		case tokens[i].Is(")"):
+			depth--
+		case depth == 0 && slices.ContainsFunc(keywords, tokens[i].Is):
+			return i
+		}
+	}
+	return len(tokens)
+}
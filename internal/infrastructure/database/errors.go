@@ -13,4 +13,18 @@ var (
 
 	// ErrConnectionFailed is returned when database connection fails
 	ErrConnectionFailed = errors.New("database connection failed")
+
+	// ErrReadOnly is returned when a read-only session is asked to run what it can't guard
+	ErrReadOnly = errors.New("read-only session")
+
+	// ErrNotExplainable is returned when a query can't be planned with EXPLAIN
+	// (statement types without a plan, or several statements at once)
+	ErrNotExplainable = errors.New("query cannot be explained")
+
+	// ErrNoDryRun is returned when a query can't be dry run (anything but a single INSERT,
+	// UPDATE, DELETE or REPLACE statement, or any query in a read-only session)
+	ErrNoDryRun = errors.New("query cannot be dry run")
+
+	// ErrNoSnapshot tells why no undo snapshot was taken of the rows a statement changes
+	ErrNoSnapshot = errors.New("no undo snapshot")
 )
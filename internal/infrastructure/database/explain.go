@@ -0,0 +1,25 @@
+package database
+
+import (
+	"context"
+	"slices"
+
+	"github.com/alessandrolattao/asqli/internal/infrastructure/sqlparse"
+)
+
+// explainableStatements are the statement types every supported database can plan with EXPLAIN
+var explainableStatements = []string{"SELECT", "WITH", "INSERT", "UPDATE", "DELETE", "REPLACE", "VALUES"}
+
+// Explain returns the plan the database would use for query, without executing it.
+// Only a single SELECT, WITH, INSERT, UPDATE, DELETE, REPLACE or VALUES statement can be
+// explained; anything else (DDL, several statements at once) returns ErrNotExplainable.
+// Several statements matter because prefixing "EXPLAIN" only covers the first one,
+// and some drivers would execute the rest.
+func (c *Connection) Explain(ctx context.Context, query string) (*QueryPlan, error) {
+	statements, err := sqlparse.Parse(query, c.DriverType)
+	if err != nil || len(statements) != 1 || !slices.Contains(explainableStatements, statements[0].Keyword) {
+		return nil, ErrNotExplainable
+	}
+
+	return c.adapter.ExplainQuery(ctx, c.DB, statements[0].Text)
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryReadOnly runs query in a read-only transaction that is always rolled back and
+// returns at most maxRows rows (0 = all). PostgreSQL and MySQL reject writes in a read-only
+// transaction (BEGIN READ ONLY, START TRANSACTION READ ONLY); SQLite, whose driver ignores
+// the read-only option, is switched to query_only mode on the connection for the duration
+// of the query, unless the whole session is read-only already.
+func (c *Connection) QueryReadOnly(ctx context.Context, query string, maxRows int) ([]map[string]any, []string, error) {
+	conn, err := c.DB.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	if c.DriverType == SQLite && !c.readOnly {
+		if _, err := conn.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+			return nil, nil, err
+		}
+		defer func() {
+			// The pragma outlives the transaction: reset it, or drop the connection from the pool
+			if _, err := conn.ExecContext(context.WithoutCancel(ctx), "PRAGMA query_only = OFF"); err != nil {
+				_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+			}
+		}()
+	}
+
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	return queryRows(ctx, tx, query, maxRows)
+}
+
+// QuoteIdentifier quotes a table or column name for use in SQL built by the application
+func (c *Connection) QuoteIdentifier(name string) string {
+	if c.DriverType == MySQL {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// QuoteLiteral renders a value scanned from the database as a SQL literal, for use in SQL
+// built by the application. Times at midnight UTC are rendered as dates, and bytes as
+// binary literals (X'...', or decode('...', 'hex') on PostgreSQL).
+func (c *Connection) QuoteLiteral(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		return strings.ToUpper(strconv.FormatBool(v))
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v)
+	case float32:
+		return c.quoteFloat(float64(v), 32)
+	case float64:
+		return c.quoteFloat(v, 64)
+	case time.Time:
+		if v.Equal(v.Truncate(24*time.Hour)) && v.Location() == time.UTC {
+			return c.quoteString(v.Format(time.DateOnly))
+		}
+		if c.DriverType == MySQL {
+			// DATETIME and TIMESTAMP literals take no time zone
+			return c.quoteString(v.Format("2006-01-02 15:04:05.999999"))
+		}
+		return c.quoteString(v.Format("2006-01-02 15:04:05.999999999-07:00"))
+	case string:
+		return c.quoteString(v)
+	case []byte:
+		if c.DriverType == PostgreSQL {
+			return "decode('" + hex.EncodeToString(v) + "', 'hex')"
+		}
+		return "X'" + hex.EncodeToString(v) + "'"
+	default:
+		return c.quoteString(fmt.Sprint(v))
+	}
+}
+
+// quoteFloat renders a floating-point value, quoting the special values (NaN, Infinity)
+func (c *Connection) quoteFloat(v float64, bitSize int) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return c.quoteString(strconv.FormatFloat(v, 'g', -1, bitSize))
+	}
+	return strconv.FormatFloat(v, 'g', -1, bitSize)
+}
+
+// quoteString quotes a string literal; MySQL also escapes backslashes
+func (c *Connection) quoteString(s string) string {
+	if c.DriverType == MySQL {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,210 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/alessandrolattao/asqli/internal/infrastructure/sqlparse"
+)
+
+// maxSnapshotRows is the number of rows an undo snapshot can hold: statements changing
+// more rows run without one
+const maxSnapshotRows = 100000
+
+// binaryTypes are the column types whose values are bytes rather than text, by dialect.
+// SQLite returns bytes for BLOB values only, whatever the column type.
+var binaryTypes = map[DriverType][]string{
+	PostgreSQL: {"BYTEA"},
+	MySQL:      {"BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY", "BIT"},
+}
+
+// Snapshot is a copy of the rows an UPDATE or DELETE statement changes, taken in its
+// transaction before it runs, from which the rows can be restored
+type Snapshot struct {
+	// Statement the snapshot was taken for, and its keyword (UPDATE or DELETE)
+	Statement string
+	Keyword   string
+
+	// Table the rows belong to, with its schema when the statement names one
+	Schema string
+	Table  string
+
+	// Columns of the table's primary key (empty for deletes from tables without one)
+	PrimaryKey []string
+
+	// Columns the database computes (generated columns, GENERATED ALWAYS identities),
+	// left out of the statements restoring the rows
+	Generated []string
+
+	// Columns of the table in the schema, which the snapshot's columns must all be
+	columns []string
+
+	// Rows as they were before the statement, with their column names; values of
+	// binary columns are []byte
+	Rows    []map[string]any
+	Columns []string
+}
+
+// newSnapshot returns the snapshot, still without rows, of the rows an UPDATE or DELETE
+// statement changes, selected by match (see matchQuery). It returns ErrNoSnapshot with the
+// reason when the rows can't be told apart: updates are restored by primary key.
+func (c *Connection) newSnapshot(ctx context.Context, statement sqlparse.Statement, match string) (*Snapshot, error) {
+	refs := statement.References()
+	if match == "" || len(refs.Tables) == 0 {
+		return nil, fmt.Errorf("%w: the statement changes several tables", ErrNoSnapshot)
+	}
+	table := refs.Tables[0]
+
+	definition, err := c.GetTableDefinition(ctx, table.Name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoSnapshot, err)
+	}
+	if len(definition.Columns) == 0 {
+		return nil, fmt.Errorf("%w: table %s not found in the schema", ErrNoSnapshot, table.Name)
+	}
+
+	var primaryKey, generated, columns []string
+	for _, column := range definition.Columns {
+		if column.IsPrimary {
+			primaryKey = append(primaryKey, column.Name)
+		}
+		if column.IsGenerated {
+			generated = append(generated, column.Name)
+		}
+		columns = append(columns, column.Name)
+	}
+	if statement.Keyword == "UPDATE" && len(primaryKey) == 0 {
+		return nil, fmt.Errorf("%w: %s has no primary key", ErrNoSnapshot, table.Name)
+	}
+
+	// Deleted rows would come back with new keys
+	if statement.Keyword == "DELETE" && slices.ContainsFunc(primaryKey, func(key string) bool { return slices.Contains(generated, key) }) {
+		return nil, fmt.Errorf("%w: the database generates the primary key of %s", ErrNoSnapshot, table.Name)
+	}
+
+	// The rows matched before the statement runs may not be the ones it changes
+	if unordered(statement.Tokens) {
+		return nil, fmt.Errorf("%w: the statement has a LIMIT without ORDER BY", ErrNoSnapshot)
+	}
+
+	// Rows are found by the key they had before the update: a changed key would restore
+	// old values over other rows
+	if statement.Keyword == "UPDATE" {
+		for _, column := range assignedColumns(statement.Tokens) {
+			if slices.ContainsFunc(primaryKey, func(key string) bool { return strings.EqualFold(key, column) }) {
+				return nil, fmt.Errorf("%w: the statement changes the primary key of %s", ErrNoSnapshot, table.Name)
+			}
+		}
+	}
+
+	return &Snapshot{
+		Statement:  statement.Text,
+		Keyword:    statement.Keyword,
+		Schema:     table.Schema,
+		Table:      table.Name,
+		PrimaryKey: primaryKey,
+		Generated:  generated,
+		columns:    columns,
+	}, nil
+}
+
+// unknownColumn returns the first of columns that isn't a column of the snapshot's table
+// in the schema, empty if there is none: whether the database computes it isn't known
+func (s *Snapshot) unknownColumn(columns []string) string {
+	for _, column := range columns {
+		if !slices.ContainsFunc(s.columns, func(known string) bool { return strings.EqualFold(known, column) }) {
+			return column
+		}
+	}
+	return ""
+}
+
+// lockRows locks the rows a SELECT returns until the end of the transaction, so that
+// they can't change between the snapshot and the statement (SQLite locks whole databases)
+func (c *Connection) lockRows(query string) string {
+	if c.DriverType == SQLite {
+		return query
+	}
+	return query + " FOR UPDATE"
+}
+
+// assignedColumns returns the columns the SET clause of an UPDATE statement assigns,
+// without their qualifiers: a = 1, t.b = 2, (c, d) = (3, 4)
+func assignedColumns(tokens []sqlparse.Token) []string {
+	set := topLevel(tokens, "SET")
+	if set < 0 {
+		return nil
+	}
+	end := firstOf(tokens, set+1, "WHERE", "FROM", "ORDER", "LIMIT", "RETURNING")
+
+	var columns []string
+	for i := set + 1; i < end; i = firstOf(tokens[:end], i, ",") + 1 {
+		target := tokens[i:end]
+		if target[0].Is("(") {
+			target = target[1:]
+		}
+		for j, t := range target {
+			if t.Is("=") || t.Is(")") {
+				break
+			}
+			if (t.Kind == sqlparse.Word || t.Kind == sqlparse.QuotedIdentifier) && !(j+1 < len(target) && target[j+1].Is(".")) {
+				columns = append(columns, t.Name())
+			}
+		}
+	}
+	return columns
+}
+
+// queryBinaryRows runs query on q and returns up to maxRows rows (0 = all) with their column
+// names, like queryRows, but keeps the values of binary columns as []byte
+func queryBinaryRows(ctx context.Context, q querier, query string, dialect DriverType, maxRows int) ([]map[string]any, []string, error) {
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeRows(rows)
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, nil, err
+	}
+	binary := make([]bool, len(columns))
+	for i, columnType := range types {
+		binary[i] = dialect == SQLite || slices.Contains(binaryTypes[dialect], strings.ToUpper(columnType.DatabaseTypeName()))
+	}
+
+	var result []map[string]any
+	for rows.Next() {
+		if maxRows > 0 && len(result) == maxRows {
+			break
+		}
+		row, err := scanRow(rows, columns, binary)
+		if err != nil {
+			return nil, nil, err
+		}
+		result = append(result, row)
+	}
+
+	return result, columns, rows.Err()
+}
+
+// textRows returns copies of rows with bytes converted to strings, for display
+func textRows(rows []map[string]any) []map[string]any {
+	converted := make([]map[string]any, len(rows))
+	for i, row := range rows {
+		converted[i] = make(map[string]any, len(row))
+		for column, value := range row {
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+			converted[i][column] = value
+		}
+	}
+	return converted
+}
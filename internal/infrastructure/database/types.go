@@ -15,6 +15,7 @@ type (
 	TableDefinition      = adapters.TableDefinition
 	ColumnDefinition     = adapters.ColumnDefinition
 	ConstraintDefinition = adapters.ConstraintDefinition
+	QueryPlan            = adapters.QueryPlan
 )
 
 // Re-export constants
@@ -27,8 +28,18 @@ const (
 // ExecuteQuery runs a SQL query with the given context and returns the result in a tabular format.
 // Returns a slice of row maps, column names, and any error encountered.
 func ExecuteQuery(ctx context.Context, db *sql.DB, query string) ([]map[string]any, []string, error) {
+	return queryRows(ctx, db, query, 0)
+}
+
+// querier is implemented by *sql.DB and *sql.Tx
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// queryRows runs query on q and returns up to maxRows rows (0 = all) with their column names
+func queryRows(ctx context.Context, q querier, query string, maxRows int) ([]map[string]any, []string, error) {
 	// Execute the query with context
-	rows, err := db.QueryContext(ctx, query)
+	rows, err := q.QueryContext(ctx, query)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -40,38 +51,20 @@ func ExecuteQuery(ctx context.Context, db *sql.DB, query string) ([]map[string]a
 		return nil, nil, err
 	}
 
-	// Create a slice of interface{} to hold the values
-	values := make([]any, len(columns))
-	valuePtrs := make([]any, len(columns))
-
 	// Create a slice to store the result
 	var result []map[string]any
 
 	// Iterate through the result set
 	for rows.Next() {
-		// Initialize the pointers (Go 1.22+ range style)
-		for i := range columns {
-			valuePtrs[i] = &values[i]
+		if maxRows > 0 && len(result) == maxRows {
+			break
 		}
 
-		// Scan the row into the valuePtrs
-		if err := rows.Scan(valuePtrs...); err != nil {
+		row, err := scanRow(rows, columns, nil)
+		if err != nil {
 			return nil, nil, err
 		}
 
-		// Create a map for this row
-		row := make(map[string]any)
-		for i, col := range columns {
-			val := values[i]
-
-			b, ok := val.([]byte)
-			if !ok {
-				row[col] = val
-				continue
-			}
-			row[col] = string(b)
-		}
-
 		result = append(result, row)
 	}
 
@@ -81,3 +74,36 @@ func ExecuteQuery(ctx context.Context, db *sql.DB, query string) ([]map[string]a
 
 	return result, columns, nil
 }
+
+// scanRow scans the current row of rows into a map of column names to values. Bytes
+// are converted to strings, except in the columns marked in binary (nil for none).
+func scanRow(rows *sql.Rows, columns []string, binary []bool) (map[string]any, error) {
+	// Create a slice of interface{} to hold the values
+	values := make([]any, len(columns))
+	valuePtrs := make([]any, len(columns))
+
+	// Initialize the pointers (Go 1.22+ range style)
+	for i := range columns {
+		valuePtrs[i] = &values[i]
+	}
+
+	// Scan the row into the valuePtrs
+	if err := rows.Scan(valuePtrs...); err != nil {
+		return nil, err
+	}
+
+	// Create a map for this row
+	row := make(map[string]any)
+	for i, col := range columns {
+		val := values[i]
+
+		b, ok := val.([]byte)
+		if !ok || (binary != nil && binary[i]) {
+			row[col] = val
+			continue
+		}
+		row[col] = string(b)
+	}
+
+	return row, nil
+}
@@ -0,0 +1,10 @@
+// Package sqlparse defines errors related to SQL tokenizing.
+package sqlparse
+
+import "errors"
+
+// Sentinel errors returned by the tokenizer.
+var (
+	// ErrUnterminated is returned when a string, quoted identifier or comment is never closed
+	ErrUnterminated = errors.New("unterminated string, quoted identifier or comment")
+)
@@ -0,0 +1,63 @@
+package sqlparse
+
+import "strings"
+
+// keywords are the words that never name a column: SQL keywords of the three dialects,
+// type names, date and time units and functions called without parentheses. A column
+// named like one of them is left unchecked rather than reported by mistake.
+var keywords = newWordSet(`
+	ACTION ADD AFTER AGAINST ALL ALTER ALWAYS ANALYZE AND ANY ARRAY AS ASC ASYMMETRIC AT
+	AUTOINCREMENT AUTO_INCREMENT BEFORE BEGIN BERNOULLI BETWEEN BIGINT BIGSERIAL BINARY BIT BLOB
+	BOOL BOOLEAN BOTH BY BYTEA CALL CASCADE CASE CAST CHAR CHARACTER CHARSET CHECK COLLATE COLUMN
+	COLUMNS COMMIT CONFLICT CONSTRAINT CONTINUE CONVERT CREATE CROSS CUBE CURRENT CURRENT_DATE
+	CURRENT_ROLE CURRENT_SCHEMA CURRENT_TIME CURRENT_TIMESTAMP CURRENT_USER CURSOR DATABASE DATE
+	DATETIME DEC DECIMAL DECLARE DEFAULT DEFERRABLE DEFERRED DELAYED DELETE DESC DESCRIBE DISTINCT
+	DISTINCTROW DIV DO DOUBLE DROP DUAL DUPLICATE EACH ELSE ELSEIF END ENUM ESCAPE EXCEPT EXCLUDE
+	EXCLUSIVE EXISTS EXPANSION EXPLAIN FALSE FETCH FILTER FIRST FLOAT FLOAT4 FLOAT8 FOLLOWING FOR
+	FORCE FOREIGN FROM FULL FULLTEXT GENERATED GLOB GRANT GROUP GROUPING GROUPS HAVING
+	HIGH_PRIORITY IF IGNORE ILIKE IMMEDIATE IN INDEX INDEXED INNER INOUT INSERT INT INT2 INT4 INT8
+	INTEGER INTERSECT INTERVAL INTO IS ISNULL JOIN JSON JSONB KEY KEYS LANGUAGE LAST LATERAL
+	LEADING LEFT LIKE LIMIT LOCAL LOCALTIME LOCALTIMESTAMP LOCK LOCKED LONGBLOB LONGTEXT
+	LOW_PRIORITY MATCH MATCHED MATERIALIZED MEDIUMINT MEDIUMTEXT MERGE MINUS MOD MODE NATIONAL
+	NATURAL NCHAR NEXT NO NOT NOTHING NOTNULL NOWAIT NULL NULLS NUMERIC NVARCHAR OF OFFSET ON ONLY
+	OR ORDER ORDINALITY OTHERS OUT OUTER OVER OVERLAPS OVERRIDING PARTITION PERCENT PLACING
+	PRECEDING PRECISION PRIMARY QUERY QUICK RANGE REAL RECURSIVE REFERENCES REGEXP RELEASE RENAME
+	REPEATABLE REPLACE RESTRICT RETURNING RIGHT RLIKE ROLLBACK ROLLUP ROW ROWS SAVEPOINT SCHEMA
+	SELECT SEPARATOR SERIAL SESSION_USER SET SETS SHARE SHOW SIGNED SIMILAR SKIP SMALLINT
+	SMALLSERIAL SOME SOUNDS SQL_BIG_RESULT SQL_BUFFER_RESULT SQL_CALC_FOUND_ROWS SQL_NO_CACHE
+	SQL_SMALL_RESULT STORED STRAIGHT_JOIN SYMMETRIC SYSTEM SYSTEM_USER TABLE TABLESAMPLE TEMP
+	TEMPORARY TEXT THEN TIES TIME TIMESTAMP TIMESTAMPTZ TIMETZ TINYBLOB TINYINT TINYTEXT TO TOP
+	TRAILING TRIGGER TRUE TRUNCATE UNBOUNDED UNION UNIQUE UNKNOWN UNNEST UNSIGNED UPDATE USE USER
+	USING UUID VALUES VARBINARY VARCHAR VARIADIC VARYING VIEW VIRTUAL WHEN WHERE WINDOW WITH WITHIN
+	WITHOUT XOR YEAR ZEROFILL ZONE
+
+	MICROSECOND MICROSECONDS MILLISECOND MILLISECONDS SECOND SECONDS MINUTE MINUTES HOUR HOURS
+	DAY DAYS WEEK WEEKS MONTH MONTHS QUARTER YEARS DECADE CENTURY MILLENNIUM EPOCH DOW DOY
+	ISODOW ISOYEAR JULIAN TIMEZONE TIMEZONE_HOUR TIMEZONE_MINUTE SECOND_MICROSECOND
+	MINUTE_MICROSECOND MINUTE_SECOND HOUR_MICROSECOND HOUR_SECOND HOUR_MINUTE DAY_MICROSECOND
+	DAY_SECOND DAY_MINUTE DAY_HOUR YEAR_MONTH
+`)
+
+// expressionEnds are the keywords that can end an expression, so that a name right after
+// them is an alias: CASE ... END total, NULL AS missing
+var expressionEnds = newWordSet(`
+	END NULL TRUE FALSE UNKNOWN CURRENT_DATE CURRENT_TIME CURRENT_TIMESTAMP LOCALTIME
+	LOCALTIMESTAMP CURRENT_USER CURRENT_ROLE CURRENT_SCHEMA SESSION_USER SYSTEM_USER USER
+`)
+
+// wordSet is a set of upper-case keywords
+type wordSet map[string]bool
+
+// newWordSet creates the set of the whitespace-separated words of list
+func newWordSet(list string) wordSet {
+	set := make(wordSet)
+	for _, word := range strings.Fields(list) {
+		set[word] = true
+	}
+	return set
+}
+
+// has reports whether the token is a Word in the set
+func (s wordSet) has(t Token) bool {
+	return t.Kind == Word && s[strings.ToUpper(t.Text)]
+}
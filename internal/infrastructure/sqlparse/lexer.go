@@ -0,0 +1,319 @@
+// Package sqlparse tokenizes SQL in the PostgreSQL, MySQL and SQLite dialects, splits it into
+// statements and tells what the statements do, without a full grammar of each dialect.
+package sqlparse
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alessandrolattao/asqli/internal/infrastructure/database/adapters"
+)
+
+// TokenKind is the lexical class of a token
+type TokenKind int
+
+const (
+	// Word is a keyword or an unquoted identifier
+	Word TokenKind = iota
+
+	// QuotedIdentifier is an identifier in double quotes, backticks (MySQL, SQLite) or brackets (SQLite)
+	QuotedIdentifier
+
+	// String is a string literal, including prefixes such as E'...' and dollar-quoted bodies
+	String
+
+	// Number is a numeric literal
+	Number
+
+	// Parameter is a placeholder or variable: ?, $1, :name, @name, @@name
+	Parameter
+
+	// Punct is an operator or punctuation character, including the ; between statements
+	Punct
+)
+
+// Token is a lexical unit of a query. Comments and whitespace are not tokens.
+type Token struct {
+	Kind TokenKind
+
+	// Text of the token as written in the query
+	Text string
+
+	// Byte offsets of the token in the query
+	Start, End int
+}
+
+// Is reports whether the token is the keyword (case-insensitive) or punctuation text
+func (t Token) Is(text string) bool {
+	switch t.Kind {
+	case Word:
+		return strings.EqualFold(t.Text, text)
+	case Punct:
+		return t.Text == text
+	default:
+		return false
+	}
+}
+
+// Name returns the identifier a Word or QuotedIdentifier token names, without quotes
+// (empty for other tokens)
+func (t Token) Name() string {
+	switch t.Kind {
+	case Word:
+		return t.Text
+	case QuotedIdentifier:
+		quote := t.Text[:1]
+		if quote == "[" {
+			return t.Text[1 : len(t.Text)-1]
+		}
+		return strings.ReplaceAll(t.Text[1:len(t.Text)-1], quote+quote, quote)
+	default:
+		return ""
+	}
+}
+
+// Tokenize splits query into tokens following the quoting and comment rules of dialect:
+//   - PostgreSQL: "identifiers", E'escaped strings', $tag$dollar-quoted strings$tag$, nested /* */ comments
+//   - MySQL: `identifiers`, "strings", backslash escapes, # comments, -- comments followed by
+//     a space, and /*! executable comments */ whose content is tokenized as SQL
+//   - SQLite: "identifiers", `identifiers` and [identifiers]
+//
+// Unclosed strings, quoted identifiers and comments return ErrUnterminated.
+func Tokenize(query string, dialect adapters.DriverType) ([]Token, error) {
+	l := &lexer{query: query, dialect: dialect}
+	for l.pos < len(l.query) {
+		if err := l.next(); err != nil {
+			return nil, err
+		}
+	}
+	return l.tokens, nil
+}
+
+// lexer tokenizes a query one token at a time
+type lexer struct {
+	query   string
+	dialect adapters.DriverType
+	pos     int
+	tokens  []Token
+
+	// Open MySQL executable comments, whose closing */ is a token
+	executable int
+}
+
+// next skips whitespace or a comment, or reads the token at the current position
+func (l *lexer) next() error {
+	q, start := l.query, l.pos
+	ch := q[start]
+	rest := q[start:]
+
+	switch {
+	case isSpace(ch):
+		l.pos++
+	case strings.HasPrefix(rest, "--") && (l.dialect != adapters.MySQL || len(rest) == 2 || isSpace(rest[2])):
+		// MySQL needs whitespace after --, "1--1" is a subtraction there
+		l.skipLine()
+	case ch == '#' && l.dialect == adapters.MySQL:
+		l.skipLine()
+	case strings.HasPrefix(rest, "/*"):
+		return l.blockComment()
+	case l.executable > 0 && strings.HasPrefix(rest, "*/"):
+		l.executable--
+		l.emit(Punct, start+2)
+	case ch == '\'':
+		return l.quoted(String, start, l.dialect == adapters.MySQL)
+	case ch == '"' && l.dialect == adapters.MySQL:
+		return l.quoted(String, start, true)
+	case ch == '"', ch == '`' && l.dialect != adapters.PostgreSQL:
+		return l.quoted(QuotedIdentifier, start, false)
+	case ch == '[' && l.dialect == adapters.SQLite:
+		end := strings.IndexByte(rest, ']')
+		if end < 0 {
+			return l.unterminated()
+		}
+		l.emit(QuotedIdentifier, start+end+1)
+	case ch == '$' && l.dialect == adapters.PostgreSQL:
+		return l.dollar()
+	case isDigit(ch), ch == '.' && len(rest) > 1 && isDigit(rest[1]):
+		l.number()
+	case isWordStart(ch):
+		return l.word()
+	case strings.HasPrefix(rest, "::"):
+		// PostgreSQL cast, not a :name parameter
+		l.emit(Punct, start+2)
+	case ch == '?', ch == '$', (ch == ':' || ch == '@') && len(rest) > 1 && (isWordChar(rest[1]) || rest[1] == '@'):
+		end := start + 1
+		for end < len(q) && (isWordChar(q[end]) || q[end] == '@') {
+			end++
+		}
+		l.emit(Parameter, end)
+	default:
+		_, size := utf8.DecodeRuneInString(rest)
+		l.emit(Punct, start+size)
+	}
+
+	return nil
+}
+
+// emit adds the token from the current position to end, and moves past it
+func (l *lexer) emit(kind TokenKind, end int) {
+	l.tokens = append(l.tokens, Token{Kind: kind, Text: l.query[l.pos:end], Start: l.pos, End: end})
+	l.pos = end
+}
+
+// unterminated returns the error for a string, identifier or comment left open at the current position
+func (l *lexer) unterminated() error {
+	return fmt.Errorf("%w at offset %d", ErrUnterminated, l.pos)
+}
+
+// skipLine skips a line comment
+func (l *lexer) skipLine() {
+	if end := strings.IndexByte(l.query[l.pos:], '\n'); end >= 0 {
+		l.pos += end + 1
+	} else {
+		l.pos = len(l.query)
+	}
+}
+
+// blockComment skips a /* */ comment. PostgreSQL comments nest; the content of MySQL
+// /*! executable comments */ runs as SQL, so it is tokenized, between Punct tokens for
+// the delimiters (the opening one with the optional minimum server version, e.g. /*!50100).
+func (l *lexer) blockComment() error {
+	if l.dialect == adapters.MySQL && strings.HasPrefix(l.query[l.pos:], "/*!") {
+		end := l.pos + 3
+		for end < len(l.query) && isDigit(l.query[end]) {
+			end++
+		}
+		l.executable++
+		l.emit(Punct, end)
+		return nil
+	}
+
+	depth := 0
+	for i := l.pos; i+1 < len(l.query); i++ {
+		switch {
+		case l.query[i] == '/' && l.query[i+1] == '*' && (depth == 0 || l.dialect == adapters.PostgreSQL):
+			depth++
+			i++
+		case l.query[i] == '*' && l.query[i+1] == '/':
+			depth--
+			i++
+			if depth == 0 {
+				l.pos = i + 1
+				return nil
+			}
+		}
+	}
+
+	return l.unterminated()
+}
+
+// quoted reads a string or quoted identifier opened at position open (after any prefix).
+// Doubled quotes escape themselves; with backslash, so does a backslash.
+func (l *lexer) quoted(kind TokenKind, open int, backslash bool) error {
+	quote := l.query[open]
+	for i := open + 1; i < len(l.query); i++ {
+		switch {
+		case backslash && l.query[i] == '\\':
+			i++
+		case l.query[i] == quote && i+1 < len(l.query) && l.query[i+1] == quote:
+			i++
+		case l.query[i] == quote:
+			l.emit(kind, i+1)
+			return nil
+		}
+	}
+
+	return l.unterminated()
+}
+
+// dollar reads a PostgreSQL $1 parameter or $tag$ dollar-quoted string
+func (l *lexer) dollar() error {
+	rest := l.query[l.pos:]
+
+	end := 1
+	for end < len(rest) && isDigit(rest[end]) {
+		end++
+	}
+	if end > 1 {
+		l.emit(Parameter, l.pos+end)
+		return nil
+	}
+
+	if tagEnd := strings.IndexByte(rest[1:], '$'); tagEnd >= 0 {
+		if tag := rest[:tagEnd+2]; isDollarTag(tag) {
+			body := strings.Index(rest[len(tag):], tag)
+			if body < 0 {
+				return l.unterminated()
+			}
+			l.emit(String, l.pos+len(tag)+body+len(tag))
+			return nil
+		}
+	}
+
+	l.emit(Punct, l.pos+1)
+	return nil
+}
+
+// number reads a numeric literal (decimal, exponent or hexadecimal)
+func (l *lexer) number() {
+	end := l.pos
+	for end < len(l.query) {
+		ch := l.query[end]
+		exponentSign := (ch == '+' || ch == '-') && (l.query[end-1] == 'e' || l.query[end-1] == 'E') &&
+			!strings.HasPrefix(strings.ToLower(l.query[l.pos:end]), "0x")
+		if !isWordChar(ch) && ch != '.' && !exponentSign {
+			break
+		}
+		end++
+	}
+	l.emit(Number, end)
+}
+
+// word reads a keyword or identifier. A single letter directly followed by a quote
+// prefixes a string (E'...', N'...', X'...', B'...'); PostgreSQL E strings take backslash escapes.
+func (l *lexer) word() error {
+	end := l.pos
+	for end < len(l.query) && isWordChar(l.query[end]) {
+		end++
+	}
+
+	if end-l.pos == 1 && end < len(l.query) && l.query[end] == '\'' {
+		escaped := l.dialect == adapters.MySQL || (l.dialect == adapters.PostgreSQL && (l.query[l.pos] == 'e' || l.query[l.pos] == 'E'))
+		return l.quoted(String, end, escaped)
+	}
+
+	l.emit(Word, end)
+	return nil
+}
+
+// isDollarTag reports whether tag is a PostgreSQL dollar-quote delimiter ($$ or $name$)
+func isDollarTag(tag string) bool {
+	if len(tag) < 2 || tag[0] != '$' || tag[len(tag)-1] != '$' {
+		return false
+	}
+	for i := 1; i < len(tag)-1; i++ {
+		if !isWordChar(tag[i]) || tag[i] == '$' || (i == 1 && isDigit(tag[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == '\f' || ch == '\v'
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+// isWordStart reports whether ch starts a keyword or identifier (bytes of non-ASCII letters included)
+func isWordStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || ch >= utf8.RuneSelf
+}
+
+// isWordChar reports whether ch continues a keyword, identifier or number
+func isWordChar(ch byte) bool {
+	return isWordStart(ch) || isDigit(ch) || ch == '$'
+}
@@ -0,0 +1,204 @@
+package sqlparse
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/alessandrolattao/asqli/internal/infrastructure/database/adapters"
+)
+
+// kindNames name token kinds in test expectations
+var kindNames = map[TokenKind]string{
+	Word:             "word",
+	QuotedIdentifier: "ident",
+	String:           "string",
+	Number:           "number",
+	Parameter:        "param",
+	Punct:            "punct",
+}
+
+// describe formats tokens as "kind text" for comparison
+func describe(tokens []Token) []string {
+	var out []string
+	for _, t := range tokens {
+		out = append(out, fmt.Sprintf("%s %s", kindNames[t.Kind], t.Text))
+	}
+	return out
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect adapters.DriverType
+		query   string
+		want    []string
+	}{
+		{
+			name:    "line and block comments",
+			dialect: adapters.PostgreSQL,
+			query:   "SELECT 1 -- one\n, 2 /* two */ FROM t",
+			want:    []string{"word SELECT", "number 1", "punct ,", "number 2", "word FROM", "word t"},
+		},
+		{
+			name:    "nested block comments on PostgreSQL",
+			dialect: adapters.PostgreSQL,
+			query:   "SELECT /* a /* b */ c */ 1",
+			want:    []string{"word SELECT", "number 1"},
+		},
+		{
+			name:    "block comments don't nest on MySQL",
+			dialect: adapters.MySQL,
+			query:   "SELECT /* a /* b */ 1",
+			want:    []string{"word SELECT", "number 1"},
+		},
+		{
+			name:    "hash comments on MySQL",
+			dialect: adapters.MySQL,
+			query:   "SELECT 1 # ; DROP TABLE t",
+			want:    []string{"word SELECT", "number 1"},
+		},
+		{
+			name:    "double dash without space is a subtraction on MySQL",
+			dialect: adapters.MySQL,
+			query:   "SELECT 1--1",
+			want:    []string{"word SELECT", "number 1", "punct -", "punct -", "number 1"},
+		},
+		{
+			name:    "MySQL executable comments are tokenized",
+			dialect: adapters.MySQL,
+			query:   "/*!50100 SELECT 1 */",
+			want:    []string{"punct /*!50100", "word SELECT", "number 1", "punct */"},
+		},
+		{
+			name:    "comment markers inside strings",
+			dialect: adapters.PostgreSQL,
+			query:   "SELECT '-- no', '/* no */'",
+			want:    []string{"word SELECT", "string '-- no'", "punct ,", "string '/* no */'"},
+		},
+		{
+			name:    "doubled quotes",
+			dialect: adapters.PostgreSQL,
+			query:   `SELECT 'it''s' AS "a ""b"""`,
+			want:    []string{"word SELECT", "string 'it''s'", "word AS", `ident "a ""b"""`},
+		},
+		{
+			name:    "other quotes inside quotes",
+			dialect: adapters.SQLite,
+			query:   "SELECT 'say \"hi\"', \"it's\", `a'b`, [x\"y]",
+			want:    []string{"word SELECT", `string 'say "hi"'`, "punct ,", `ident "it's"`, "punct ,", "ident `a'b`", "punct ,", `ident [x"y]`},
+		},
+		{
+			name:    "backslash escapes on MySQL",
+			dialect: adapters.MySQL,
+			query:   `SELECT 'it\'s', "say \"hi\""`,
+			want:    []string{"word SELECT", `string 'it\'s'`, "punct ,", `string "say \"hi\""`},
+		},
+		{
+			name:    "escape strings on PostgreSQL",
+			dialect: adapters.PostgreSQL,
+			query:   `SELECT E'it\'s', 'a\'`,
+			want:    []string{"word SELECT", `string E'it\'s'`, "punct ,", `string 'a\'`},
+		},
+		{
+			name:    "dollar-quoted strings",
+			dialect: adapters.PostgreSQL,
+			query:   "SELECT $$it's; -- x$$, $fn$ a $$ b $fn$",
+			want:    []string{"word SELECT", "string $$it's; -- x$$", "punct ,", "string $fn$ a $$ b $fn$"},
+		},
+		{
+			name:    "dollar parameters and casts",
+			dialect: adapters.PostgreSQL,
+			query:   "SELECT $1::int",
+			want:    []string{"word SELECT", "param $1", "punct ::", "word int"},
+		},
+		{
+			name:    "dollar signs in identifiers",
+			dialect: adapters.PostgreSQL,
+			query:   "SELECT a$b FROM t",
+			want:    []string{"word SELECT", "word a$b", "word FROM", "word t"},
+		},
+		{
+			name:    "parameters and variables",
+			dialect: adapters.MySQL,
+			query:   "SELECT ?, @x, @@version",
+			want:    []string{"word SELECT", "param ?", "punct ,", "param @x", "punct ,", "param @@version"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := Tokenize(tt.query, tt.dialect)
+			if err != nil {
+				t.Fatalf("Tokenize() error = %v", err)
+			}
+			if got := describe(tokens); !slices.Equal(got, tt.want) {
+				t.Errorf("Tokenize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenizeOffsets(t *testing.T) {
+	query := "SELECT /* c */ 'a''b'"
+	tokens, err := Tokenize(query, adapters.PostgreSQL)
+	if err != nil {
+		t.Fatalf("Tokenize() error = %v", err)
+	}
+	for _, token := range tokens {
+		if query[token.Start:token.End] != token.Text {
+			t.Errorf("token %q has offsets %d:%d covering %q", token.Text, token.Start, token.End, query[token.Start:token.End])
+		}
+	}
+}
+
+func TestTokenizeUnterminated(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect adapters.DriverType
+		query   string
+	}{
+		{"string", adapters.PostgreSQL, "SELECT 'abc"},
+		{"escaped closing quote", adapters.MySQL, `SELECT 'abc\'`},
+		{"quoted identifier", adapters.PostgreSQL, `SELECT "abc`},
+		{"bracket identifier", adapters.SQLite, "SELECT [abc"},
+		{"block comment", adapters.MySQL, "SELECT 1 /* abc"},
+		{"nested block comment", adapters.PostgreSQL, "SELECT 1 /* a /* b */"},
+		{"dollar-quoted string", adapters.PostgreSQL, "SELECT $tag$abc$$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Tokenize(tt.query, tt.dialect); !errors.Is(err, ErrUnterminated) {
+				t.Errorf("Tokenize() error = %v, want %v", err, ErrUnterminated)
+			}
+		})
+	}
+}
+
+func TestTokenName(t *testing.T) {
+	tests := []struct {
+		dialect adapters.DriverType
+		query   string
+		want    string
+	}{
+		{adapters.PostgreSQL, "users", "users"},
+		{adapters.PostgreSQL, `"a ""b"""`, `a "b"`},
+		{adapters.MySQL, "`a``b`", "a`b"},
+		{adapters.SQLite, "[a b]", "a b"},
+		{adapters.PostgreSQL, "'users'", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			tokens, err := Tokenize(tt.query, tt.dialect)
+			if err != nil || len(tokens) != 1 {
+				t.Fatalf("Tokenize() = %v, %v, want one token", tokens, err)
+			}
+			if got := tokens[0].Name(); got != tt.want {
+				t.Errorf("Name() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package sqlparse
+
+import "strings"
+
+// TableRef is a table a statement reads or writes, in a FROM, JOIN, USING, UPDATE or
+// INSERT INTO clause
+type TableRef struct {
+	// Qualifier before the table name (e.g. "public" in public.users), empty when unqualified
+	Schema string
+
+	// Table name, without quotes
+	Name string
+
+	// Alias given to the table in the statement, empty when none
+	Alias string
+}
+
+// ColumnRef is a column a statement names
+type ColumnRef struct {
+	// Table name or alias before the column name (e.g. "u" in u.email), empty when unqualified
+	Qualifier string
+
+	// Column name, without quotes
+	Name string
+}
+
+// References are the names a statement uses for tables and columns. They are told apart
+// by position, without a grammar of each dialect: what can't be told is left out.
+type References struct {
+	// Tables the statement names as row sources, in order
+	Tables []TableRef
+
+	// Columns the statement names, in order and possibly repeated
+	Columns []ColumnRef
+
+	// Row sources whose columns aren't those of a table: CTE names, and the aliases of
+	// subqueries, table functions and tables with renamed columns (empty for unaliased ones)
+	Derived []string
+
+	// Names the statement gives to expressions and windows (SELECT count(*) AS total),
+	// which unqualified column names may refer to
+	Aliases []string
+}
+
+// frame is the state of a parenthesis level of a statement
+type frame struct {
+	// The level holds a query (SELECT, UPDATE or DELETE), whose FROM lists row sources,
+	// rather than e.g. the arguments of EXTRACT(YEAR FROM ...)
+	query bool
+
+	// A comma-separated list of row sources is being read
+	list bool
+}
+
+// References returns the tables and columns the statement names
+func (s Statement) References() References {
+	var refs References
+	tokens := s.Tokens
+
+	// Tokens that name row sources and their aliases, rather than columns
+	consumed := make([]bool, len(tokens))
+
+	frames := []frame{{}}
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		top := &frames[len(frames)-1]
+
+		switch {
+		case consumed[i]:
+			continue
+		case t.Is("("):
+			frames = append(frames, frame{})
+			continue
+		case t.Is(")"):
+			if len(frames) > 1 {
+				frames = frames[:len(frames)-1]
+			}
+			continue
+		case t.Is(",") && top.list:
+			i = refs.source(tokens, i+1, consumed) - 1
+			continue
+		case t.Kind != Word || isQualified(tokens, i):
+			continue
+		}
+
+		top.list = false
+		switch keyword := strings.ToUpper(t.Text); keyword {
+		case "SELECT", "DELETE":
+			top.query = true
+		case "WITH", "RECURSIVE":
+			refs.commonTables(tokens, i+1, consumed)
+		case "UPDATE":
+			// Not FOR UPDATE, DO UPDATE, ON DUPLICATE KEY UPDATE
+			if i == 0 || tokens[i-1].Kind != Word {
+				top.query = true
+				top.list = true
+				i = refs.source(tokens, i+1, consumed) - 1
+			}
+		case "FROM", "JOIN", "USING":
+			switch {
+			case keyword != "JOIN" && !top.query, keyword == "FROM" && i > 0 && tokens[i-1].Is("DISTINCT"):
+				// EXTRACT(YEAR FROM ...), CONVERT(... USING utf8mb4), IS DISTINCT FROM
+			case keyword == "USING" && i+1 < len(tokens) && tokens[i+1].Is("("):
+				// JOIN ... USING (column, ...)
+			default:
+				top.list = keyword != "JOIN"
+				i = refs.source(tokens, i+1, consumed) - 1
+			}
+		case "INTO":
+			// INSERT INTO, REPLACE INTO, INSERT IGNORE INTO, INSERT OR REPLACE INTO, MERGE INTO;
+			// not SELECT ... INTO a new table or variables
+			if i > 0 && (tokens[i-1].Is("INSERT") || tokens[i-1].Is("REPLACE") || tokens[i-1].Is("IGNORE") || tokens[i-1].Is("MERGE")) {
+				i = refs.source(tokens, i+1, consumed) - 1
+			}
+		}
+	}
+
+	refs.columns(tokens, consumed)
+	return refs
+}
+
+// source reads the row source at tokens[i] (a table, a subquery or a table function) and its
+// alias, marking their tokens as consumed. It returns the index after the table or function
+// name and alias; the content of parentheses is left to be read as part of the statement.
+func (r *References) source(tokens []Token, i int, consumed []bool) int {
+	for i < len(tokens) && (tokens[i].Is("ONLY") || tokens[i].Is("LATERAL") || tokens[i].Is("LOW_PRIORITY") || tokens[i].Is("IGNORE")) {
+		i++
+	}
+	if i >= len(tokens) {
+		return i
+	}
+
+	if tokens[i].Is("(") {
+		// Subquery or VALUES list: the alias follows the closing parenthesis
+		alias, _ := readAlias(tokens, closing(tokens, i)+1, consumed)
+		r.Derived = append(r.Derived, alias)
+		return i
+	}
+
+	var parts []string
+	start := i
+	for i < len(tokens) && isName(tokens[i]) {
+		parts = append(parts, tokens[i].Name())
+		consumed[i] = true
+		if i+2 < len(tokens) && tokens[i+1].Is(".") && isName(tokens[i+2]) {
+			consumed[i+1] = true
+			i += 2
+			continue
+		}
+		i++
+		break
+	}
+	if len(parts) == 0 {
+		return start
+	}
+
+	// Table functions (generate_series(...) AS g, json_each(...)); INSERT INTO t (columns) isn't one
+	if i < len(tokens) && tokens[i].Is("(") && !(start > 0 && tokens[start-1].Is("INTO")) {
+		alias, _ := readAlias(tokens, closing(tokens, i)+1, consumed)
+		r.Derived = append(r.Derived, alias)
+		return i
+	}
+
+	alias, next := readAlias(tokens, i, consumed)
+	if next < len(tokens) && tokens[next].Is("(") && alias != "" {
+		// Columns renamed by the alias: t AS x (a, b)
+		r.Derived = append(r.Derived, alias)
+		return next
+	}
+	r.Tables = append(r.Tables, TableRef{
+		Schema: strings.Join(parts[:len(parts)-1], "."),
+		Name:   parts[len(parts)-1],
+		Alias:  alias,
+	})
+
+	// MySQL index hints: USE INDEX (name), FORCE KEY FOR JOIN (name)
+	for next+1 < len(tokens) && (tokens[next].Is("USE") || tokens[next].Is("FORCE") || tokens[next].Is("IGNORE")) &&
+		(tokens[next+1].Is("INDEX") || tokens[next+1].Is("KEY")) {
+		end := next + 2
+		for end < len(tokens) && !tokens[end].Is("(") {
+			end++
+		}
+		end = closing(tokens, end) + 1
+		for j := next; j < end && j < len(tokens); j++ {
+			consumed[j] = true
+		}
+		next = end
+	}
+
+	return next
+}
+
+// commonTables reads the CTE definitions of a WITH clause starting at tokens[i]
+// (name [(columns)] AS [NOT] [MATERIALIZED] (query), ...), marking the names and column lists as consumed
+func (r *References) commonTables(tokens []Token, i int, consumed []bool) {
+	for i < len(tokens) && isName(tokens[i]) && !tokens[i].Is("RECURSIVE") {
+		name := i
+		i++
+		if i < len(tokens) && tokens[i].Is("(") {
+			i = closing(tokens, i) + 1
+		}
+		if i >= len(tokens) || !tokens[i].Is("AS") {
+			return
+		}
+
+		for j := name; j < i; j++ {
+			consumed[j] = true
+		}
+		r.Derived = append(r.Derived, tokens[name].Name())
+
+		// Skip the CTE's query to the comma before the next definition
+		for i < len(tokens) && !tokens[i].Is("(") {
+			i++
+		}
+		i = closing(tokens, i) + 1
+		if i >= len(tokens) || !tokens[i].Is(",") {
+			return
+		}
+		i++
+	}
+}
+
+// columns collects the column names and aliases of the tokens not consumed by row sources
+func (r *References) columns(tokens []Token, consumed []bool) {
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		if consumed[i] || !isName(t) || (i > 0 && tokens[i-1].Is(".")) {
+			continue
+		}
+
+		// Qualified names: table.column, schema.table.column, alias.*, schema.function()
+		if i+2 < len(tokens) && tokens[i+1].Is(".") {
+			end := i
+			for end+2 < len(tokens) && tokens[end+1].Is(".") && (isName(tokens[end+2]) || tokens[end+2].Is("*")) {
+				end += 2
+			}
+			last := tokens[end]
+			if isName(last) && !consumed[end] && !(end+1 < len(tokens) && tokens[end+1].Is("(")) {
+				r.Columns = append(r.Columns, ColumnRef{Qualifier: tokens[end-2].Name(), Name: last.Name()})
+			}
+			i = end
+			continue
+		}
+
+		var prev, next Token
+		if i > 0 {
+			prev = tokens[i-1]
+		}
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+
+		switch {
+		case prev.Is("AS") || prev.Is("WINDOW") || prev.Is("OVER") && !next.Is("("):
+			r.Aliases = append(r.Aliases, t.Name())
+		case keywords.has(t):
+		case prev.Is("::") || prev.Is("COLLATE") || prev.Is("CONSTRAINT") || prev.Is("USING"):
+			// Type, collation, constraint and character set names
+		case next.Is("(") || next.Kind == String:
+			// Function calls and typed literals (DATE '2024-01-01')
+		case next.Is("=") && i+2 < len(tokens) && tokens[i+2].Is(">"):
+			// Named function arguments (name => value)
+		case i > 0 && endsExpression(prev):
+			// Implicit alias: count(*) total
+			r.Aliases = append(r.Aliases, t.Name())
+		default:
+			r.Columns = append(r.Columns, ColumnRef{Name: t.Name()})
+		}
+	}
+}
+
+// readAlias reads the optional alias of a row source at tokens[i] ([AS] alias), marking its
+// tokens as consumed. It returns the alias (empty when none) and the index after it.
+func readAlias(tokens []Token, i int, consumed []bool) (string, int) {
+	if i >= len(tokens) {
+		return "", i
+	}
+	if tokens[i].Is("AS") && i+1 < len(tokens) && isName(tokens[i+1]) {
+		consumed[i], consumed[i+1] = true, true
+		return tokens[i+1].Name(), i + 2
+	}
+	if isName(tokens[i]) && !keywords.has(tokens[i]) {
+		consumed[i] = true
+		return tokens[i].Name(), i + 1
+	}
+	return "", i
+}
+
+// closing returns the index of the parenthesis closing the one at tokens[open]
+// (the last index when it is never closed)
+func closing(tokens []Token, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		switch {
+		case tokens[i].Is("("):
+			depth++
+		case tokens[i].Is(")"):
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(tokens) - 1
+}
+
+// isName reports whether the token can name a table or column
+func isName(t Token) bool {
+	return t.Kind == Word || t.Kind == QuotedIdentifier
+}
+
+// isQualified reports whether tokens[i] is part of a dotted name
+func isQualified(tokens []Token, i int) bool {
+	return (i > 0 && tokens[i-1].Is(".")) || (i+1 < len(tokens) && tokens[i+1].Is("."))
+}
+
+// endsExpression reports whether an expression can end with the token, so that a name
+// right after it is an alias rather than a column
+func endsExpression(t Token) bool {
+	switch t.Kind {
+	case Word:
+		return !keywords.has(t) || expressionEnds.has(t)
+	case QuotedIdentifier, String, Number, Parameter:
+		return true
+	default:
+		return t.Is(")")
+	}
+}
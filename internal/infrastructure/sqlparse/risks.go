@@ -0,0 +1,212 @@
+package sqlparse
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// readStatements are the statement keywords of queries that only read
+var readStatements = []string{"SELECT", "WITH", "VALUES", "TABLE", "SHOW", "DESCRIBE", "DESC", "EXPLAIN", "PRAGMA"}
+
+// writeKeywords change data, objects or privileges wherever they appear in a statement,
+// including data-modifying WITH clauses and subqueries
+var writeKeywords = map[string]string{
+	"INSERT":   "inserts rows",
+	"UPDATE":   "updates rows",
+	"DELETE":   "deletes rows",
+	"MERGE":    "merges rows",
+	"REPLACE":  "replaces rows",
+	"TRUNCATE": "empties tables",
+	"DROP":     "drops objects",
+	"ALTER":    "alters objects",
+	"CREATE":   "creates objects",
+	"GRANT":    "changes privileges",
+	"REVOKE":   "changes privileges",
+}
+
+// statementRisks describe statement keywords that aren't reads; other keywords are
+// reported as "runs a <KEYWORD> statement"
+var statementRisks = map[string]string{
+	"CALL":      "calls a stored procedure",
+	"DO":        "runs a code block",
+	"EXECUTE":   "runs a prepared statement",
+	"COPY":      "copies data to or from files",
+	"LOAD":      "loads data or extensions",
+	"SET":       "changes settings",
+	"RESET":     "changes settings",
+	"USE":       "switches the database",
+	"ATTACH":    "attaches a database",
+	"BEGIN":     "controls transactions",
+	"START":     "controls transactions",
+	"COMMIT":    "controls transactions",
+	"END":       "controls transactions",
+	"ROLLBACK":  "controls transactions",
+	"SAVEPOINT": "controls transactions",
+	"LOCK":      "locks tables",
+	"KILL":      "kills connections",
+}
+
+// readOnlyPragmas are the SQLite pragmas that only read, even with an argument
+var readOnlyPragmas = []string{
+	"table_info", "table_xinfo", "table_list", "index_list", "index_info", "index_xinfo",
+	"foreign_key_list", "foreign_key_check", "integrity_check", "quick_check",
+	"database_list", "collation_list", "function_list", "module_list", "pragma_list", "compile_options",
+}
+
+// riskyFunctions have side effects beyond reading: they administer the server, read or write
+// its files, run SQL from strings, change sequences and settings, or hold the connection
+var riskyFunctions = map[string]string{
+	// PostgreSQL
+	"pg_terminate_backend":       "terminates connections",
+	"pg_cancel_backend":          "cancels queries",
+	"pg_reload_conf":             "reloads the server configuration",
+	"pg_rotate_logfile":          "rotates the server log",
+	"pg_promote":                 "promotes a standby server",
+	"pg_switch_wal":              "switches the WAL file",
+	"pg_create_restore_point":    "writes to the WAL",
+	"pg_read_file":               "reads server files",
+	"pg_read_binary_file":        "reads server files",
+	"pg_ls_dir":                  "reads server files",
+	"pg_stat_file":               "reads server files",
+	"pg_file_write":              "writes server files",
+	"pg_file_rename":             "writes server files",
+	"pg_file_unlink":             "writes server files",
+	"lo_import":                  "reads server files",
+	"lo_export":                  "writes server files",
+	"lo_unlink":                  "deletes large objects",
+	"dblink":                     "runs SQL on another database",
+	"dblink_exec":                "runs SQL on another database",
+	"query_to_xml":               "runs SQL from a string",
+	"query_to_xml_and_xmlschema": "runs SQL from a string",
+	"query_to_xmlschema":         "runs SQL from a string",
+	"set_config":                 "changes settings",
+	"nextval":                    "advances a sequence",
+	"setval":                     "changes a sequence",
+	"pg_advisory_lock":           "takes an advisory lock",
+	"pg_advisory_xact_lock":      "takes an advisory lock",
+	"pg_try_advisory_lock":       "takes an advisory lock",
+	"pg_sleep":                   "holds the connection",
+
+	// MySQL
+	"sleep":        "holds the connection",
+	"benchmark":    "holds the connection",
+	"load_file":    "reads server files",
+	"get_lock":     "takes a named lock",
+	"release_lock": "releases a named lock",
+
+	// SQLite
+	"load_extension": "loads an extension",
+	"writefile":      "writes files",
+	"readfile":       "reads files",
+}
+
+// Risks returns why running statements needs the user's confirmation, e.g. "deletes rows (DELETE)":
+// writes anywhere in a statement, several statements at once, statements other than reads,
+// row locks and functions with side effects. Reads have no risks; EXPLAIN and DESCRIBE have
+// none unless they ANALYZE, which runs the statement.
+func Risks(statements []Statement) []string {
+	var risks []string
+	add := func(risk string) {
+		if !slices.Contains(risks, risk) {
+			risks = append(risks, risk)
+		}
+	}
+
+	if len(statements) > 1 {
+		add(fmt.Sprintf("runs %d statements", len(statements)))
+	}
+
+	for _, statement := range statements {
+		for _, risk := range statement.risks() {
+			add(risk)
+		}
+	}
+
+	return risks
+}
+
+// risks returns the risks of a single statement, possibly repeated
+func (s Statement) risks() []string {
+	var risks []string
+	tokens := s.Tokens
+
+	switch {
+	case s.Keyword == "EXPLAIN" || s.Keyword == "DESCRIBE" || s.Keyword == "DESC":
+		// Plans are made without running the statement, unless analyzed
+		if !slices.ContainsFunc(tokens, func(t Token) bool { return t.Is("ANALYZE") }) {
+			return nil
+		}
+	case s.Keyword == "PRAGMA":
+		if risk := s.pragmaRisk(); risk != "" {
+			risks = append(risks, risk)
+		}
+	case slices.Contains(readStatements, s.Keyword):
+	case writeKeywords[s.Keyword] != "":
+		// Reported with the keywords below
+	case statementRisks[s.Keyword] != "":
+		risks = append(risks, fmt.Sprintf("%s (%s)", statementRisks[s.Keyword], s.Keyword))
+	case s.Keyword != "":
+		risks = append(risks, fmt.Sprintf("runs a %s statement", s.Keyword))
+	default:
+		risks = append(risks, "runs an unrecognized statement")
+	}
+
+	word := func(i int) string {
+		if i < 0 || i >= len(tokens) || tokens[i].Kind != Word {
+			return ""
+		}
+		return strings.ToUpper(tokens[i].Text)
+	}
+
+	writes := false
+	for i, token := range tokens {
+		call := i+1 < len(tokens) && tokens[i+1].Is("(")
+		if name := strings.ToLower(token.Name()); call && riskyFunctions[name] != "" {
+			risks = append(risks, fmt.Sprintf("%s (%s)", riskyFunctions[name], name))
+		}
+
+		// Column and table names qualified with a dot aren't keywords
+		if token.Kind != Word || (i > 0 && tokens[i-1].Is(".")) || (i+1 < len(tokens) && tokens[i+1].Is(".")) {
+			continue
+		}
+
+		keyword := strings.ToUpper(token.Text)
+		switch {
+		case (keyword == "UPDATE" || keyword == "SHARE") && (word(i-1) == "FOR" || word(i-1) == "KEY" && word(i-2) == "NO" || word(i-1) == "KEY" && word(i-2) == "FOR"):
+			// FOR UPDATE, FOR NO KEY UPDATE, FOR SHARE, FOR KEY SHARE
+			risks = append(risks, fmt.Sprintf("locks rows (FOR %s)", keyword))
+		case keyword == "LOCK" && word(i+1) == "IN":
+			risks = append(risks, "locks rows (LOCK IN SHARE MODE)")
+		case (keyword == "INSERT" || keyword == "REPLACE") && call:
+			// MySQL INSERT() and REPLACE() string functions
+		case writeKeywords[keyword] != "":
+			writes = true
+			risks = append(risks, fmt.Sprintf("%s (%s)", writeKeywords[keyword], keyword))
+		case keyword == "INTO" && !writes:
+			risks = append(risks, "writes the results to a table, file or variable (SELECT ... INTO)")
+		case keyword == "PROGRAM" && s.Keyword == "COPY" && (word(i-1) == "TO" || word(i-1) == "FROM"):
+			risks = append(risks, "runs a shell command (COPY ... PROGRAM)")
+		}
+	}
+
+	return risks
+}
+
+// pragmaRisk returns the risk of a SQLite PRAGMA statement that sets a value
+// ("PRAGMA name = value" or "PRAGMA name(value)"), empty for pragmas that only read
+func (s Statement) pragmaRisk() string {
+	name := ""
+	for _, token := range s.Tokens[1:] {
+		switch {
+		case token.Is("=") || token.Is("("):
+			if slices.Contains(readOnlyPragmas, strings.ToLower(name)) {
+				return ""
+			}
+			return "changes settings (PRAGMA)"
+		case token.Kind == Word || token.Kind == QuotedIdentifier:
+			name = token.Name()
+		}
+	}
+	return ""
+}
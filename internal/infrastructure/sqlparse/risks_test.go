@@ -0,0 +1,157 @@
+package sqlparse
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/alessandrolattao/asqli/internal/infrastructure/database/adapters"
+)
+
+func TestRisks(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect adapters.DriverType
+		query   string
+		want    []string
+	}{
+		{
+			name:    "read",
+			dialect: adapters.PostgreSQL,
+			query:   "SELECT * FROM t WHERE a = 1",
+		},
+		{
+			name:    "write keywords in strings, comments and identifiers",
+			dialect: adapters.PostgreSQL,
+			query:   `SELECT 'DELETE FROM t', t.update, "drop" FROM t -- DROP TABLE t`,
+		},
+		{
+			name:    "MySQL INSERT() string function",
+			dialect: adapters.MySQL,
+			query:   "SELECT INSERT('abc', 1, 1, 'x')",
+		},
+		{
+			name:    "multiple statements",
+			dialect: adapters.PostgreSQL,
+			query:   "SELECT 1; DROP TABLE t;",
+			want:    []string{"runs 2 statements", "drops objects (DROP)"},
+		},
+		{
+			name:    "statement hidden after a MySQL comment",
+			dialect: adapters.MySQL,
+			query:   "SELECT 1 # comment\n; DELETE FROM t",
+			want:    []string{"runs 2 statements", "deletes rows (DELETE)"},
+		},
+		{
+			name:    "WITH ... DELETE",
+			dialect: adapters.PostgreSQL,
+			query:   "WITH old AS (SELECT id FROM t) DELETE FROM t WHERE id IN (SELECT id FROM old)",
+			want:    []string{"deletes rows (DELETE)"},
+		},
+		{
+			name:    "data-modifying WITH clause",
+			dialect: adapters.PostgreSQL,
+			query:   "WITH gone AS (DELETE FROM t RETURNING *) SELECT * FROM gone",
+			want:    []string{"deletes rows (DELETE)"},
+		},
+		{
+			name:    "SELECT ... FOR UPDATE",
+			dialect: adapters.PostgreSQL,
+			query:   "SELECT * FROM t FOR UPDATE",
+			want:    []string{"locks rows (FOR UPDATE)"},
+		},
+		{
+			name:    "SELECT ... FOR NO KEY UPDATE",
+			dialect: adapters.PostgreSQL,
+			query:   "SELECT * FROM t FOR NO KEY UPDATE SKIP LOCKED",
+			want:    []string{"locks rows (FOR UPDATE)"},
+		},
+		{
+			name:    "SELECT ... FOR SHARE",
+			dialect: adapters.PostgreSQL,
+			query:   "SELECT * FROM t FOR KEY SHARE",
+			want:    []string{"locks rows (FOR SHARE)"},
+		},
+		{
+			name:    "SELECT ... LOCK IN SHARE MODE",
+			dialect: adapters.MySQL,
+			query:   "SELECT * FROM t LOCK IN SHARE MODE",
+			want:    []string{"locks rows (LOCK IN SHARE MODE)"},
+		},
+		{
+			name:    "SELECT ... INTO a table",
+			dialect: adapters.PostgreSQL,
+			query:   "SELECT * INTO backup FROM t",
+			want:    []string{"writes the results to a table, file or variable (SELECT ... INTO)"},
+		},
+		{
+			name:    "SELECT ... INTO a variable",
+			dialect: adapters.MySQL,
+			query:   "SELECT COUNT(*) FROM t INTO @n",
+			want:    []string{"writes the results to a table, file or variable (SELECT ... INTO)"},
+		},
+		{
+			name:    "INSERT ... SELECT",
+			dialect: adapters.PostgreSQL,
+			query:   "INSERT INTO backup SELECT * FROM t",
+			want:    []string{"inserts rows (INSERT)"},
+		},
+		{
+			name:    "EXPLAIN",
+			dialect: adapters.PostgreSQL,
+			query:   "EXPLAIN DELETE FROM t",
+		},
+		{
+			name:    "EXPLAIN ANALYZE",
+			dialect: adapters.PostgreSQL,
+			query:   "EXPLAIN ANALYZE DELETE FROM t",
+			want:    []string{"deletes rows (DELETE)"},
+		},
+		{
+			name:    "function with side effects",
+			dialect: adapters.PostgreSQL,
+			query:   "SELECT pg_sleep(10)",
+			want:    []string{"holds the connection (pg_sleep)"},
+		},
+		{
+			name:    "function in a MySQL executable comment",
+			dialect: adapters.MySQL,
+			query:   "SELECT 1 /*!50000 , SLEEP(5) */",
+			want:    []string{"holds the connection (sleep)"},
+		},
+		{
+			name:    "COPY ... PROGRAM",
+			dialect: adapters.PostgreSQL,
+			query:   "COPY t TO PROGRAM 'gzip > /tmp/t.gz'",
+			want:    []string{"copies data to or from files (COPY)", "runs a shell command (COPY ... PROGRAM)"},
+		},
+		{
+			name:    "reading pragma",
+			dialect: adapters.SQLite,
+			query:   "PRAGMA table_info(t)",
+		},
+		{
+			name:    "setting pragma",
+			dialect: adapters.SQLite,
+			query:   "PRAGMA journal_mode = WAL",
+			want:    []string{"changes settings (PRAGMA)"},
+		},
+		{
+			name:    "other statement",
+			dialect: adapters.SQLite,
+			query:   "VACUUM",
+			want:    []string{"runs a VACUUM statement"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := Parse(tt.query, tt.dialect)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := Risks(statements); !slices.Equal(got, tt.want) {
+				t.Errorf("Risks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
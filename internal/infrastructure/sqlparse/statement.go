@@ -0,0 +1,64 @@
+package sqlparse
+
+import (
+	"strings"
+
+	"github.com/alessandrolattao/asqli/internal/infrastructure/database/adapters"
+)
+
+// Statement is one of the semicolon-separated statements of a query
+type Statement struct {
+	// First keyword of the statement, upper-cased, after any opening parentheses and
+	// MySQL executable comments (e.g. "SELECT", "WITH", "DELETE"); empty when the
+	// statement starts otherwise
+	Keyword string
+
+	// Statement as written in the query, without the comments around it and the semicolon
+	Text string
+
+	// Tokens of the statement
+	Tokens []Token
+}
+
+// Parse tokenizes query (see Tokenize) and splits it into statements.
+// Empty statements, such as after a trailing semicolon, are left out.
+func Parse(query string, dialect adapters.DriverType) ([]Statement, error) {
+	tokens, err := Tokenize(query, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []Statement
+	begin := 0
+	for i := 0; i <= len(tokens); i++ {
+		if i < len(tokens) && !tokens[i].Is(";") {
+			continue
+		}
+		if i > begin {
+			statements = append(statements, newStatement(query, tokens[begin:i]))
+		}
+		begin = i + 1
+	}
+
+	return statements, nil
+}
+
+// newStatement creates the statement made of tokens (at least one) of query
+func newStatement(query string, tokens []Token) Statement {
+	statement := Statement{
+		Text:   query[tokens[0].Start:tokens[len(tokens)-1].End],
+		Tokens: tokens,
+	}
+
+	for _, token := range tokens {
+		if token.Is("(") || token.Kind == Punct && strings.HasPrefix(token.Text, "/*!") {
+			continue
+		}
+		if token.Kind == Word {
+			statement.Keyword = strings.ToUpper(token.Text)
+		}
+		break
+	}
+
+	return statement
+}
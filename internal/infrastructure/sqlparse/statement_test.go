@@ -0,0 +1,103 @@
+package sqlparse
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/alessandrolattao/asqli/internal/infrastructure/database/adapters"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  adapters.DriverType
+		query    string
+		keywords []string
+		texts    []string
+	}{
+		{
+			name:     "single statement with trailing semicolon",
+			dialect:  adapters.PostgreSQL,
+			query:    "SELECT 1;",
+			keywords: []string{"SELECT"},
+			texts:    []string{"SELECT 1"},
+		},
+		{
+			name:     "multiple statements",
+			dialect:  adapters.PostgreSQL,
+			query:    "select 1; UPDATE t SET a = 1;\n\nDELETE FROM t",
+			keywords: []string{"SELECT", "UPDATE", "DELETE"},
+			texts:    []string{"select 1", "UPDATE t SET a = 1", "DELETE FROM t"},
+		},
+		{
+			name:     "empty statements",
+			dialect:  adapters.PostgreSQL,
+			query:    " ; ;; -- nothing",
+			keywords: nil,
+			texts:    nil,
+		},
+		{
+			name:     "semicolons in strings and comments",
+			dialect:  adapters.PostgreSQL,
+			query:    "SELECT ';', $$;$$ /* ; */; -- ;\nSELECT 2",
+			keywords: []string{"SELECT", "SELECT"},
+			texts:    []string{"SELECT ';', $$;$$", "SELECT 2"},
+		},
+		{
+			name:     "comments around statements",
+			dialect:  adapters.MySQL,
+			query:    "/* first */ SELECT 1 # done",
+			keywords: []string{"SELECT"},
+			texts:    []string{"SELECT 1"},
+		},
+		{
+			name:     "parenthesized statement",
+			dialect:  adapters.SQLite,
+			query:    "(SELECT 1) UNION (SELECT 2)",
+			keywords: []string{"SELECT"},
+			texts:    []string{"(SELECT 1) UNION (SELECT 2)"},
+		},
+		{
+			name:     "WITH ... DELETE",
+			dialect:  adapters.PostgreSQL,
+			query:    "WITH old AS (SELECT id FROM t WHERE a < 0) DELETE FROM t WHERE id IN (SELECT id FROM old)",
+			keywords: []string{"WITH"},
+			texts:    []string{"WITH old AS (SELECT id FROM t WHERE a < 0) DELETE FROM t WHERE id IN (SELECT id FROM old)"},
+		},
+		{
+			name:     "MySQL executable comment",
+			dialect:  adapters.MySQL,
+			query:    "/*!40101 SET NAMES utf8 */; SELECT 1",
+			keywords: []string{"SET", "SELECT"},
+			texts:    []string{"/*!40101 SET NAMES utf8 */", "SELECT 1"},
+		},
+		{
+			name:     "statement not starting with a keyword",
+			dialect:  adapters.PostgreSQL,
+			query:    "'abc'",
+			keywords: []string{""},
+			texts:    []string{"'abc'"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := Parse(tt.query, tt.dialect)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			var keywords, texts []string
+			for _, s := range statements {
+				keywords = append(keywords, s.Keyword)
+				texts = append(texts, s.Text)
+			}
+			if !slices.Equal(keywords, tt.keywords) {
+				t.Errorf("Parse() keywords = %q, want %q", keywords, tt.keywords)
+			}
+			if !slices.Equal(texts, tt.texts) {
+				t.Errorf("Parse() texts = %q, want %q", texts, tt.texts)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@
 package cli
 
 import (
+	"github.com/alessandrolattao/asqli/internal/features/cost"
 	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
 	"github.com/alessandrolattao/asqli/internal/infrastructure/config"
 	"github.com/alessandrolattao/asqli/internal/infrastructure/database/adapters"
@@ -10,9 +11,11 @@ import (
 
 // App represents the CLI application
 type App struct {
-	dbConfig      adapters.Config
-	aiConfig      ai.Config
-	timeoutConfig config.TimeoutConfig
+	dbConfig         adapters.Config
+	aiConfig         ai.Config
+	timeoutConfig    config.TimeoutConfig
+	generationConfig config.GenerationConfig
+	costTracker      *cost.Tracker
 }
 
 // NewApp creates a new CLI application
@@ -20,18 +23,22 @@ func NewApp(
 	dbConfig adapters.Config,
 	aiConfig ai.Config,
 	timeoutConfig config.TimeoutConfig,
+	generationConfig config.GenerationConfig,
+	costTracker *cost.Tracker,
 ) *App {
 	return &App{
-		dbConfig:      dbConfig,
-		aiConfig:      aiConfig,
-		timeoutConfig: timeoutConfig,
+		dbConfig:         dbConfig,
+		aiConfig:         aiConfig,
+		timeoutConfig:    timeoutConfig,
+		generationConfig: generationConfig,
+		costTracker:      costTracker,
 	}
 }
 
 // Start begins the Bubble Tea interactive loop
 func (a *App) Start() error {
 	// Create Bubble Tea model
-	m := NewModel(a.dbConfig, a.aiConfig, a.timeoutConfig)
+	m := NewModel(a.dbConfig, a.aiConfig, a.timeoutConfig, a.generationConfig, a.costTracker)
 
 	// Create program WITH alternate screen for full UI rendering
 	p := tea.NewProgram(
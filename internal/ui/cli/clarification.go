@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// renderClarificationDialog renders the question the AI asked about an ambiguous prompt,
+// together with the prompt and the questions already answered for it
+func (m Model) renderClarificationDialog() string {
+	labelStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#FFB6C1")).
+		Bold(true)
+
+	contentStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#E0E0E0")).
+		Padding(0, 2)
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#61AFEF")).
+		Padding(1, 2).
+		Width(m.width - 10)
+
+	width := m.width - 18
+
+	var content strings.Builder
+
+	content.WriteString(labelStyle.Render("Your request:"))
+	content.WriteString("\n")
+	for _, line := range wrapText(m.currentPrompt, width) {
+		content.WriteString(contentStyle.Render(line))
+		content.WriteString("\n")
+	}
+
+	// Questions already answered for this prompt
+	for _, c := range m.clarifications {
+		content.WriteString("\n")
+		for _, line := range wrapText("? "+c.Question, width) {
+			content.WriteString(subtleStyle.Padding(0, 2).Render(line))
+			content.WriteString("\n")
+		}
+		for _, line := range wrapText("→ "+c.Answer, width) {
+			content.WriteString(contentStyle.Render(line))
+			content.WriteString("\n")
+		}
+	}
+
+	content.WriteString("\n")
+	content.WriteString(labelStyle.Render("The AI needs a clarification:"))
+	content.WriteString("\n")
+	for _, line := range wrapText(m.clarifyingQuestion, width) {
+		content.WriteString(questionStyle.Padding(0, 2).Render(line))
+		content.WriteString("\n")
+	}
+
+	content.WriteString("\n")
+	content.WriteString(subtleStyle.Render("Type your answer below and press Enter, or Esc to cancel"))
+
+	return boxStyle.Render(content.String())
+}
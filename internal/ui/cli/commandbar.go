@@ -16,10 +16,16 @@ type CommandBar struct {
 	textInput     textinput.Model
 	statusMessage string
 	generatedSQL  string
+	streaming     bool
+	activity      string
+	risks         []string
+	spend         string
+	readOnly      bool
+	impact        string
 }
 
 // NewCommandBar creates a new command bar component
-func NewCommandBar(width int, currentState state, sp spinner.Model, ti textinput.Model, statusMsg string, sql string) CommandBar {
+func NewCommandBar(width int, currentState state, sp spinner.Model, ti textinput.Model, statusMsg string, sql string, streaming bool, activity string, risks []string, spend string, readOnly bool, impact string) CommandBar {
 	return CommandBar{
 		width:         width,
 		state:         currentState,
@@ -27,6 +33,12 @@ func NewCommandBar(width int, currentState state, sp spinner.Model, ti textinput
 		textInput:     ti,
 		statusMessage: statusMsg,
 		generatedSQL:  sql,
+		streaming:     streaming,
+		activity:      activity,
+		risks:         risks,
+		spend:         spend,
+		readOnly:      readOnly,
+		impact:        impact,
 	}
 }
 
@@ -52,10 +64,18 @@ func (c CommandBar) View() string {
 		// Remove multiple spaces
 		displaySQL = strings.Join(strings.Fields(displaySQL), " ")
 
-		// Truncate SQL if too long
-		maxSQLWidth := c.width - 6 // Reserve space for "SQL: " label
-		if len(displaySQL) > maxSQLWidth {
-			displaySQL = displaySQL[:maxSQLWidth-3] + "..."
+		// Truncate SQL if too long, by runes and never below the width of the ellipsis
+		maxSQLWidth := max(c.width-6, 3) // Reserve space for "SQL: " label
+		sqlRunes := []rune(displaySQL)
+		if c.streaming {
+			// Keep the tail visible so the newest tokens are always on screen
+			maxSQLWidth = max(maxSQLWidth-1, 3) // Reserve space for the cursor
+			if len(sqlRunes) > maxSQLWidth {
+				displaySQL = "..." + string(sqlRunes[len(sqlRunes)-maxSQLWidth+3:])
+			}
+			displaySQL += "▌"
+		} else if len(sqlRunes) > maxSQLWidth {
+			displaySQL = string(sqlRunes[:maxSQLWidth-3]) + "..."
 		}
 
 		sqlLine = labelStyle.Render("SQL: ") + sqlStyle.Render(displaySQL)
@@ -80,20 +100,40 @@ func (c CommandBar) View() string {
 		case stateLoadingSchema:
 			statusLine = c.spinner.View() + " " + subtleStyle.Render("Loading schema")
 		case stateThinking:
-			statusLine = c.spinner.View() + " " + subtleStyle.Render("Thinking")
+			statusLine = c.spinner.View() + " " + subtleStyle.Render(c.activity)
 		case stateExecuting:
 			statusLine = c.spinner.View() + " " + subtleStyle.Render("Executing query")
+		case stateExplaining:
+			statusLine = c.spinner.View() + " " + subtleStyle.Render("Explaining query")
 		case stateConfirming:
-			statusLine = dangerStyle.Render("⚠ DANGEROUS QUERY - Proceed? (y/n)")
+			if c.impact != "" {
+				statusLine = dangerStyle.Render("⚠ DANGEROUS QUERY: " + strings.Join(c.risks, ", ") + " · " + c.impact + " - Commit? (y/n)")
+			} else {
+				// Queries that can't be dry run (DDL, writes in the WITH clause of a SELECT, ...)
+				statusLine = dangerStyle.Render("⚠ DANGEROUS QUERY: " + strings.Join(c.risks, ", ") + " · no preview - Proceed? (y/n)")
+			}
+		case stateClarifying:
+			statusLine = questionStyle.Render("? Answer the question above and press Enter • Esc to cancel")
 		case stateReady:
 			statusLine = subtleStyle.Render("Use # for raw SQL or ask me anything • Type 'exit' to quit")
+			if c.readOnly {
+				statusLine = questionStyle.Render("Read-only session") + subtleStyle.Render(" • ") + statusLine
+			}
 		default:
 			statusLine = ""
 		}
 	}
 
+	// Session AI spend, right-aligned on the status line when it fits
+	if c.spend != "" {
+		spendView := subtleStyle.Render(c.spend)
+		if gap := c.width - lipgloss.Width(statusLine) - lipgloss.Width(spendView); gap > 0 {
+			statusLine += strings.Repeat(" ", gap) + spendView
+		}
+	}
+
 	// Help line (6th line)
-	helpText := subtleStyle.Render("↑↓←→: table navigation • Ctrl+↑↓: history • Ctrl+r: history list • Ctrl+p: prompt info • Ctrl+c: copy as TSV • Esc: prompt clear • Ctrl+q: quit")
+	helpText := subtleStyle.Render("↑↓←→: table navigation • Ctrl+↑↓: history • Ctrl+r: history list • Ctrl+p: prompt info • Ctrl+x: explain SQL • Ctrl+t: answer mode • Ctrl+c: copy as TSV • Esc: prompt clear • Ctrl+q: quit")
 
 	return sqlLine + "\n" +
 		divider + "\n" +
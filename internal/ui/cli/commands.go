@@ -5,10 +5,15 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/alessandrolattao/asqli/internal/features/answer"
+	"github.com/alessandrolattao/asqli/internal/features/cost"
 	"github.com/alessandrolattao/asqli/internal/features/execution"
+	"github.com/alessandrolattao/asqli/internal/features/explore"
 	"github.com/alessandrolattao/asqli/internal/features/query"
 	"github.com/alessandrolattao/asqli/internal/features/schema"
+	"github.com/alessandrolattao/asqli/internal/features/undo"
 	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai/ollama"
 	"github.com/alessandrolattao/asqli/internal/infrastructure/config"
 	"github.com/alessandrolattao/asqli/internal/infrastructure/database"
 	"github.com/alessandrolattao/asqli/internal/infrastructure/database/adapters"
@@ -16,7 +21,7 @@ import (
 )
 
 // connectDatabaseCmd connects to the database asynchronously
-func connectDatabaseCmd(dbConfig adapters.Config, aiConfig ai.Config, timeoutConfig config.TimeoutConfig) tea.Cmd {
+func connectDatabaseCmd(dbConfig adapters.Config, aiConfig ai.Config, timeoutConfig config.TimeoutConfig, generationConfig config.GenerationConfig, costTracker *cost.Tracker) tea.Cmd {
 	return func() tea.Msg {
 		// Connect to database
 		dbConn, err := database.Open(dbConfig, timeoutConfig)
@@ -34,10 +39,39 @@ func connectDatabaseCmd(dbConfig adapters.Config, aiConfig ai.Config, timeoutCon
 			return connectionMsg{err: err}
 		}
 
+		// Read-only tools the provider can inspect the database with
+		var toolset ai.Toolset
+		if generationConfig.Explore {
+			toolset = explore.NewToolset(dbConn)
+		}
+
+		// Local embeddings match prompts with tables by meaning on large schemas
+		var embedder schema.Embedder
+		if generationConfig.EmbeddingModel != "" {
+			ollamaEmbedder, err := ollama.NewEmbedder("", generationConfig.EmbeddingModel)
+			if err != nil {
+				if closeErr := dbConn.Close(); closeErr != nil {
+					fmt.Fprintf(os.Stderr, "Warning: Failed to close database during cleanup: %v\n", closeErr)
+				}
+				return connectionMsg{err: err}
+			}
+			embedder = ollamaEmbedder
+		}
+
+		// Undo snapshots of the rows changed by updates and deletes
+		snapshots, err := undo.NewStore(dbConn, dbConfig)
+		if err != nil {
+			if closeErr := dbConn.Close(); closeErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: Failed to close database during cleanup: %v\n", closeErr)
+			}
+			return connectionMsg{err: err}
+		}
+
 		// Create services
-		schemaService := schema.NewService(dbConn)
-		queryService := query.NewService(aiProvider)
-		executionService := execution.NewService(dbConn)
+		schemaService := schema.NewService(dbConn, generationConfig, embedder)
+		queryService := query.NewService(aiProvider, costTracker, dbConn, toolset, schemaService, dbConn.DriverType, generationConfig)
+		executionService := execution.NewService(dbConn, snapshots)
+		answerService := answer.NewService(aiProvider, costTracker, dbConn.DriverType)
 
 		return connectionMsg{
 			dbConn:           dbConn,
@@ -45,6 +79,7 @@ func connectDatabaseCmd(dbConfig adapters.Config, aiConfig ai.Config, timeoutCon
 			schemaService:    schemaService,
 			queryService:     queryService,
 			executionService: executionService,
+			answerService:    answerService,
 			err:              nil,
 		}
 	}
@@ -61,23 +96,43 @@ func fetchSchemaCmd(s *schema.Service, timeoutConfig config.TimeoutConfig) tea.C
 	}
 }
 
-// generateSQLCmd generates SQL from natural language prompt asynchronously
-func generateSQLCmd(s *query.Service, timeoutConfig config.TimeoutConfig, prompt, schema string, queryHistory []QueryHistory, selectedColumn string, selectedValue any) tea.Cmd {
+// generateSQLCmd generates SQL from natural language prompt asynchronously.
+// Generation runs in a background goroutine that streams partial output as sqlChunkMsg
+// values over a channel, followed by a final sqlGeneratedMsg. Once the session context
+// is cancelled, nothing reads the channel anymore: the goroutine stops sending and
+// closes it, which unblocks any waiting reader with a nil message.
+func generateSQLCmd(sessionCtx context.Context, s *query.Service, timeoutConfig config.TimeoutConfig, req *query.Request) tea.Cmd {
 	return func() tea.Msg {
-		ctx, cancel := context.WithTimeout(context.Background(), timeoutConfig.AIGeneration)
-		defer cancel()
+		stream := make(chan tea.Msg, StreamBufferSize)
+
+		go func() {
+			defer close(stream)
 
-		// Convert QueryHistory to query.History
-		history := make([]query.History, len(queryHistory))
-		for i, qh := range queryHistory {
-			history[i] = query.History{
-				Prompt: qh.Prompt,
-				SQL:    qh.SQL,
+			ctx, cancel := context.WithTimeout(sessionCtx, timeoutConfig.AIGeneration)
+			defer cancel()
+
+			send := func(msg tea.Msg) {
+				select {
+				case stream <- msg:
+				case <-sessionCtx.Done():
+				}
 			}
-		}
+			onChunk := func(chunk string) {
+				send(sqlChunkMsg{chunk: chunk, stream: stream})
+			}
+
+			sql, err := s.GenerateStream(ctx, req, onChunk)
+			send(sqlGeneratedMsg{sql: sql, err: err})
+		}()
 
-		sql, err := s.Generate(ctx, prompt, schema, history, selectedColumn, selectedValue)
-		return sqlGeneratedMsg{sql: sql, err: err}
+		return <-stream
+	}
+}
+
+// waitForStreamCmd waits for the next message from a streaming generation
+func waitForStreamCmd(stream <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-stream
 	}
 }
 
@@ -91,3 +146,62 @@ func executeQueryCmd(s *execution.Service, timeoutConfig config.TimeoutConfig, q
 		return queryExecutedMsg{result: result, err: err}
 	}
 }
+
+// previewQueryCmd executes a data-modifying query in a transaction left open asynchronously
+func previewQueryCmd(s *execution.Service, timeoutConfig config.TimeoutConfig, query string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), timeoutConfig.DatabaseQuery)
+		defer cancel()
+
+		preview, err := s.Preview(ctx, query, PreviewSampleRows)
+		return queryPreviewedMsg{preview: preview, err: err}
+	}
+}
+
+// commitPreviewCmd applies the changes of a previewed query asynchronously
+func commitPreviewCmd(s *execution.Service, preview *execution.Preview) tea.Cmd {
+	return func() tea.Msg {
+		result, err := s.Commit(preview)
+		return queryExecutedMsg{result: result, err: err, undoable: preview.Undoable && err == nil}
+	}
+}
+
+// rollbackPreviewCmd discards the changes of a previewed query asynchronously
+func rollbackPreviewCmd(s *execution.Service, preview *execution.Preview) tea.Cmd {
+	return func() tea.Msg {
+		return previewRolledBackMsg{err: s.Rollback(preview)}
+	}
+}
+
+// undoCmd restores the rows changed by the last committed update or delete asynchronously
+func undoCmd(s *execution.Service, timeoutConfig config.TimeoutConfig) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), timeoutConfig.DatabaseQuery)
+		defer cancel()
+
+		restore, err := s.Undo(ctx)
+		return undoneMsg{restore: restore, err: err}
+	}
+}
+
+// explainSQLCmd asks the AI to explain a SQL query step by step asynchronously
+func explainSQLCmd(s *query.Service, timeoutConfig config.TimeoutConfig, sql, databaseType string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), timeoutConfig.AIGeneration)
+		defer cancel()
+
+		explanation, err := s.Explain(ctx, sql, databaseType)
+		return explanationMsg{explanation: explanation, err: err}
+	}
+}
+
+// answerResultCmd asks the AI for a plain-language answer to question from result asynchronously
+func answerResultCmd(s *answer.Service, timeoutConfig config.TimeoutConfig, question, query string, result *execution.Result) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), timeoutConfig.AIGeneration)
+		defer cancel()
+
+		a, err := s.Answer(ctx, question, query, result, AnswerSampleRows)
+		return answerMsg{result: result, answer: a, err: err}
+	}
+}
@@ -15,5 +15,15 @@ const (
 	MaxColumnWidth = 50
 
 	// MaxQueryHistory is the maximum number of queries to keep in history for AI context
-	MaxQueryHistory = 5
+	// (the query service summarizes the turns that don't fit in its token window)
+	MaxQueryHistory = 100
+
+	// AnswerSampleRows is the number of result rows sent to the AI in answer mode
+	AnswerSampleRows = 20
+
+	// PreviewSampleRows is the number of changed rows shown in the preview of a data-modifying query
+	PreviewSampleRows = 20
+
+	// StreamBufferSize is the number of streamed AI chunks buffered before the generator blocks
+	StreamBufferSize = 64
 )
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// renderExplainView renders the step-by-step explanation of a SQL query, together with
+// the query itself and the execution plan the explanation was based on (if any)
+func (m Model) renderExplainView() string {
+	if m.explanation == nil {
+		noExplanationMsg := subtleStyle.Render("No explanation available")
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, noExplanationMsg)
+	}
+
+	explanation := m.explanation
+
+	// Styles
+	titleStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#FFB6C1")).
+		Bold(true).
+		Padding(0, 1)
+
+	labelStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#FFB6C1")).
+		Bold(true)
+
+	contentStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#E0E0E0")).
+		Padding(0, 2)
+
+	sqlStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#98C379")).
+		Padding(0, 2)
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#FFB6C1")).
+		Padding(1, 2).
+		Width(m.width - 6)
+
+	// Build content
+	var content strings.Builder
+
+	content.WriteString(titleStyle.Render("Query Explanation"))
+	content.WriteString("\n\n")
+
+	// Explained SQL
+	content.WriteString(labelStyle.Render("SQL:"))
+	content.WriteString("\n")
+	for _, line := range strings.Split(explanation.Query, "\n") {
+		for _, wrapped := range wrapText(line, m.width-10) {
+			content.WriteString(sqlStyle.Render(wrapped))
+			content.WriteString("\n")
+		}
+	}
+
+	// Explanation, keeping the model's numbered steps on separate lines
+	content.WriteString("\n")
+	content.WriteString(labelStyle.Render("Explanation:"))
+	content.WriteString("\n")
+	for _, line := range strings.Split(explanation.Text, "\n") {
+		for _, wrapped := range wrapText(line, m.width-10) {
+			content.WriteString(contentStyle.Render(wrapped))
+			content.WriteString("\n")
+		}
+	}
+
+	// Provider, model and cost of the explanation
+	if explanation.Usage.Provider != "" {
+		details := fmt.Sprintf("Explained by %s", explanation.Usage.Provider)
+		if explanation.Usage.Model != "" {
+			details += fmt.Sprintf(" (%s)", explanation.Usage.Model)
+		}
+		if explanation.Plan != "" {
+			details += " using the database's execution plan"
+		}
+		if explanation.Usage.TotalTokens > 0 {
+			details += fmt.Sprintf(" • %d tokens", explanation.Usage.TotalTokens)
+		}
+		if explanation.Cost.Cost > 0 {
+			details += fmt.Sprintf(" • %s", formatUSD(explanation.Cost.Cost))
+		}
+		content.WriteString("\n")
+		content.WriteString(subtleStyle.Render(details))
+		content.WriteString("\n")
+	}
+
+	content.WriteString("\n")
+	content.WriteString(subtleStyle.Render("Press Esc to close"))
+
+	boxed := boxStyle.Render(content.String())
+
+	// Center the box
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, boxed)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/alessandrolattao/asqli/internal/features/query"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -55,6 +56,66 @@ func (m Model) renderInfoView() string {
 	content.WriteString(contentStyle.Render(lastQuery.Prompt))
 	content.WriteString("\n\n")
 
+	// Clarifying questions asked by the AI and the user's answers
+	if len(lastQuery.Clarifications) > 0 {
+		content.WriteString(labelStyle.Render("Clarifications:"))
+		content.WriteString("\n")
+		for _, c := range lastQuery.Clarifications {
+			for _, line := range wrapText("? "+c.Question, m.width-10) {
+				content.WriteString(contentStyle.Render(line))
+				content.WriteString("\n")
+			}
+			for _, line := range wrapText("→ "+c.Answer, m.width-10) {
+				content.WriteString(contentStyle.Render(line))
+				content.WriteString("\n")
+			}
+		}
+		content.WriteString("\n")
+	}
+
+	// How the request was cut down to fit the model's context window
+	if len(lastQuery.Notices) > 0 {
+		content.WriteString(labelStyle.Render("Context Window:"))
+		content.WriteString("\n")
+		for _, notice := range lastQuery.Notices {
+			for _, line := range wrapText("• "+notice, m.width-10) {
+				content.WriteString(contentStyle.Render(line))
+				content.WriteString("\n")
+			}
+		}
+		content.WriteString("\n")
+	}
+
+	// Tables sent with the prompt when the schema was cut down
+	if len(lastQuery.SchemaTables) > 0 {
+		content.WriteString(labelStyle.Render(fmt.Sprintf("Schema Tables (%d):", len(lastQuery.SchemaTables))))
+		content.WriteString("\n")
+		for _, line := range wrapText(strings.Join(lastQuery.SchemaTables, ", "), m.width-10) {
+			content.WriteString(contentStyle.Render(line))
+			content.WriteString("\n")
+		}
+		content.WriteString("\n")
+	}
+
+	// Read-only tools the model called to inspect the database before writing SQL
+	if len(lastQuery.ToolCalls) > 0 {
+		content.WriteString(labelStyle.Render(fmt.Sprintf("Exploration (%d):", len(lastQuery.ToolCalls))))
+		content.WriteString("\n")
+		for i, call := range lastQuery.ToolCalls {
+			for _, line := range wrapText(fmt.Sprintf("#%d %s %s", i+1, call.Name, call.Arguments), m.width-10) {
+				content.WriteString(contentStyle.Render(line))
+				content.WriteString("\n")
+			}
+			if call.Error != "" {
+				for _, line := range wrapText(call.Error, m.width-10) {
+					content.WriteString(errorStyle.Padding(0, 2).Render(line))
+					content.WriteString("\n")
+				}
+			}
+		}
+		content.WriteString("\n")
+	}
+
 	// Generated SQL
 	content.WriteString(labelStyle.Render("Generated SQL:"))
 	content.WriteString("\n")
@@ -66,14 +127,99 @@ func (m Model) renderInfoView() string {
 		content.WriteString("\n")
 	}
 
+	// Failed attempts sent back to the AI for repair (self-healing loop)
+	if len(lastQuery.FailedAttempts) > 0 {
+		content.WriteString("\n")
+		content.WriteString(labelStyle.Render(fmt.Sprintf("Failed Attempts (%d):", len(lastQuery.FailedAttempts))))
+		content.WriteString("\n")
+		for i, attempt := range lastQuery.FailedAttempts {
+			content.WriteString(contentStyle.Render(fmt.Sprintf("#%d", i+1)))
+			content.WriteString("\n")
+			for _, line := range wrapText(attempt.SQL, m.width-10) {
+				content.WriteString(sqlStyle.Render(line))
+				content.WriteString("\n")
+			}
+			for _, line := range wrapText(attempt.Error, m.width-10) {
+				content.WriteString(errorStyle.Padding(0, 2).Render(line))
+				content.WriteString("\n")
+			}
+		}
+	}
+
+	// Model explanation (structured output)
+	if lastQuery.Explanation != "" {
+		content.WriteString("\n")
+		content.WriteString(labelStyle.Render("Explanation:"))
+		content.WriteString("\n")
+		for _, line := range wrapText(lastQuery.Explanation, m.width-10) {
+			content.WriteString(contentStyle.Render(line))
+			content.WriteString("\n")
+		}
+	}
+
+	// Assumptions made by the model about ambiguous parts of the prompt
+	if len(lastQuery.Assumptions) > 0 {
+		content.WriteString("\n")
+		content.WriteString(labelStyle.Render("Assumptions:"))
+		content.WriteString("\n")
+		for _, assumption := range lastQuery.Assumptions {
+			for i, line := range wrapText(assumption, m.width-12) {
+				prefix := "• "
+				if i > 0 {
+					prefix = "  "
+				}
+				content.WriteString(contentStyle.Render(prefix + line))
+				content.WriteString("\n")
+			}
+		}
+	}
+
+	// Reasoning the model went through before answering (thinking and reasoning models)
+	if lastQuery.Reasoning != "" {
+		content.WriteString("\n")
+		content.WriteString(labelStyle.Render("Reasoning:"))
+		content.WriteString("\n")
+		for _, paragraph := range strings.Split(lastQuery.Reasoning, "\n") {
+			for _, line := range wrapText(paragraph, m.width-10) {
+				content.WriteString(contentStyle.Render(line))
+				content.WriteString("\n")
+			}
+		}
+	}
+
+	// Candidates the query was selected from (multi-candidate generation)
+	if len(lastQuery.Candidates) > 0 {
+		content.WriteString("\n")
+		content.WriteString(labelStyle.Render(fmt.Sprintf("Candidates (%d):", len(lastQuery.Candidates))))
+		content.WriteString("\n")
+		for i, candidate := range lastQuery.Candidates {
+			content.WriteString(contentStyle.Render(fmt.Sprintf("#%d %s", i+1, candidateSummary(candidate))))
+			content.WriteString("\n")
+			for _, line := range wrapText(candidate.Query, m.width-10) {
+				content.WriteString(sqlStyle.Render(line))
+				content.WriteString("\n")
+			}
+			if candidate.Err != nil {
+				for _, line := range wrapText(candidate.Err.Error(), m.width-10) {
+					content.WriteString(errorStyle.Padding(0, 2).Render(line))
+					content.WriteString("\n")
+				}
+			}
+		}
+	}
+
 	// Debug information
 	if lastQuery.Usage.Provider != "" {
 		content.WriteString("\n")
 		content.WriteString(labelStyle.Render("Debug Info:"))
 		content.WriteString("\n")
 
-		// Provider
-		content.WriteString(contentStyle.Render(fmt.Sprintf("Provider: %s", lastQuery.Usage.Provider)))
+		// Provider (and the providers that failed before it in a fallback chain)
+		provider := lastQuery.Usage.Provider
+		if len(lastQuery.Usage.FallbackFrom) > 0 {
+			provider += fmt.Sprintf(" (fallback after %s)", strings.Join(lastQuery.Usage.FallbackFrom, ", "))
+		}
+		content.WriteString(contentStyle.Render(fmt.Sprintf("Provider: %s", provider)))
 		content.WriteString("\n")
 
 		// Model
@@ -82,6 +228,17 @@ func (m Model) renderInfoView() string {
 			content.WriteString("\n")
 		}
 
+		// Self-reported confidence (0 means the model did not provide one),
+		// or agreement between candidates with multi-candidate generation
+		if lastQuery.Confidence > 0 {
+			confidence := fmt.Sprintf("Confidence: %.0f%%", lastQuery.Confidence*100)
+			if len(lastQuery.Candidates) > 0 {
+				confidence += fmt.Sprintf(" (candidate agreement over %d candidates)", len(lastQuery.Candidates))
+			}
+			content.WriteString(contentStyle.Render(confidence))
+			content.WriteString("\n")
+		}
+
 		// Token usage
 		if lastQuery.Usage.TotalTokens > 0 {
 			content.WriteString(contentStyle.Render(fmt.Sprintf("Tokens: %d (prompt: %d, response: %d)",
@@ -90,11 +247,49 @@ func (m Model) renderInfoView() string {
 				lastQuery.Usage.ResponseTokens)))
 			content.WriteString("\n")
 
-			// Show cached tokens if present
-			if lastQuery.Usage.CachedTokens > 0 {
-				content.WriteString(contentStyle.Render(fmt.Sprintf("Cached: %d tokens", lastQuery.Usage.CachedTokens)))
+			// Prompt cache usage: share of the prompt served from the provider's cache
+			if lastQuery.Usage.CachedTokens > 0 || lastQuery.Usage.CacheWriteTokens > 0 {
+				content.WriteString(contentStyle.Render(fmt.Sprintf("Cache: %d of %d prompt tokens read (%.0f%% hit ratio)",
+					lastQuery.Usage.CachedTokens,
+					lastQuery.Usage.PromptTokens,
+					cacheHitRatio(lastQuery.Usage.CachedTokens, lastQuery.Usage.PromptTokens)*100)))
+				content.WriteString("\n")
+			}
+			if lastQuery.Usage.CacheWriteTokens > 0 {
+				content.WriteString(contentStyle.Render(fmt.Sprintf("Cache writes: %d tokens", lastQuery.Usage.CacheWriteTokens)))
+				content.WriteString("\n")
+			}
+
+			// Cost of this query and of the whole session
+			if lastQuery.Cost > 0 {
+				content.WriteString(contentStyle.Render(fmt.Sprintf("Cost: %s", formatUSD(lastQuery.Cost))))
+				content.WriteString("\n")
+			}
+			if totals := m.costTracker.Totals(); totals.Requests > 0 {
+				session := fmt.Sprintf("Session cost: %s over %d AI requests", formatUSD(totals.Cost), totals.Requests)
+				if budget := m.costTracker.Budget(); budget > 0 {
+					session += fmt.Sprintf(" (budget %s)", formatUSD(budget))
+				}
+				if totals.UnpricedRequests > 0 {
+					session += fmt.Sprintf(", %d unpriced", totals.UnpricedRequests)
+				}
+				content.WriteString(contentStyle.Render(session))
 				content.WriteString("\n")
 			}
+
+			// Hit ratio across the queries kept in history
+			if len(m.queryHistory) > 1 {
+				var cached, prompt int
+				for _, qh := range m.queryHistory {
+					cached += qh.Usage.CachedTokens
+					prompt += qh.Usage.PromptTokens
+				}
+				if cached > 0 {
+					content.WriteString(contentStyle.Render(fmt.Sprintf("Cache (last %d queries): %.0f%% hit ratio",
+						len(m.queryHistory), cacheHitRatio(cached, prompt)*100)))
+					content.WriteString("\n")
+				}
+			}
 		}
 	}
 
@@ -106,3 +301,24 @@ func (m Model) renderInfoView() string {
 	// Center the box
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, boxed)
 }
+
+// cacheHitRatio returns the fraction (0.0-1.0) of prompt tokens read from the cache
+func cacheHitRatio(cachedTokens, promptTokens int) float64 {
+	if promptTokens == 0 {
+		return 0
+	}
+	return min(float64(cachedTokens)/float64(promptTokens), 1)
+}
+
+// candidateSummary describes whether a candidate was usable, its votes and its estimated cost
+func candidateSummary(candidate query.Candidate) string {
+	if candidate.Err != nil {
+		return "discarded"
+	}
+
+	summary := fmt.Sprintf("%d vote(s)", candidate.Votes)
+	if candidate.Plan != nil && candidate.Plan.HasCost {
+		summary += fmt.Sprintf(", estimated cost %.2f", candidate.Plan.Cost)
+	}
+	return summary
+}
@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"fmt"
+
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -17,8 +19,13 @@ func (m Model) renderMainScreen() string {
 		Height(resultsHeight).
 		Render(resultsArea)
 
-	// Render command bar
-	commandBar := NewCommandBar(m.width, m.state, m.spinner, m.textInput, m.statusMessage, m.generatedSQL)
+	// Render command bar (while thinking, show the SQL as it streams in)
+	sql := m.generatedSQL
+	streaming := m.state == stateThinking
+	if streaming {
+		sql = streamPreview(m.streamingSQL)
+	}
+	commandBar := NewCommandBar(m.width, m.state, m.spinner, m.textInput, m.statusMessage, sql, streaming, m.thinkingActivity(), m.risks, formatSpend(m.costTracker.Totals(), m.costTracker.Budget()), m.dbConfig.ReadOnly, m.previewImpact())
 	commandBarView := commandBar.View()
 
 	// Combine vertically - results area fills space, command bar at bottom
@@ -28,3 +35,14 @@ func (m Model) renderMainScreen() string {
 		commandBarView,
 	)
 }
+
+// thinkingActivity describes what the AI is doing while in stateThinking
+func (m Model) thinkingActivity() string {
+	if n := len(m.failedAttempts); n > 0 {
+		return fmt.Sprintf("Repairing query (attempt %d of %d)", n, m.generationConfig.MaxRepairAttempts)
+	}
+	if m.streamingSQL != "" {
+		return "Receiving SQL"
+	}
+	return "Thinking"
+}
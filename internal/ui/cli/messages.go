@@ -1,11 +1,14 @@
 package cli
 
 import (
+	"github.com/alessandrolattao/asqli/internal/features/answer"
 	"github.com/alessandrolattao/asqli/internal/features/execution"
 	"github.com/alessandrolattao/asqli/internal/features/query"
 	"github.com/alessandrolattao/asqli/internal/features/schema"
+	"github.com/alessandrolattao/asqli/internal/features/undo"
 	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
 	"github.com/alessandrolattao/asqli/internal/infrastructure/database"
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 // connectionMsg is sent when database connection completes
@@ -15,6 +18,7 @@ type connectionMsg struct {
 	schemaService    *schema.Service
 	queryService     *query.Service
 	executionService *execution.Service
+	answerService    *answer.Service
 	err              error
 }
 
@@ -24,6 +28,12 @@ type schemaMsg struct {
 	err    error
 }
 
+// sqlChunkMsg is sent for each partial piece of text streamed by the AI provider
+type sqlChunkMsg struct {
+	chunk  string
+	stream <-chan tea.Msg
+}
+
 // sqlGeneratedMsg is sent when SQL generation completes
 type sqlGeneratedMsg struct {
 	sql *query.SQL
@@ -32,6 +42,37 @@ type sqlGeneratedMsg struct {
 
 // queryExecutedMsg is sent when query execution completes
 type queryExecutedMsg struct {
-	result *execution.Result
+	result   *execution.Result
+	err      error
+	undoable bool // an undo snapshot of the changed rows was saved
+}
+
+// queryPreviewedMsg is sent when the dry run of a data-modifying query completes
+type queryPreviewedMsg struct {
+	preview *execution.Preview
+	err     error
+}
+
+// previewRolledBackMsg is sent when the changes of a previewed query have been discarded
+type previewRolledBackMsg struct {
+	err error
+}
+
+// undoneMsg is sent when the last undo snapshot has been restored
+type undoneMsg struct {
+	restore *undo.Restore
+	err     error
+}
+
+// explanationMsg is sent when the explanation of a SQL query completes
+type explanationMsg struct {
+	explanation *query.Explanation
+	err         error
+}
+
+// answerMsg is sent when a plain-language answer for the displayed result completes
+type answerMsg struct {
+	result *execution.Result // result the answer was requested for
+	answer *answer.Answer
 	err    error
 }
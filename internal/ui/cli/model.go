@@ -1,6 +1,10 @@
 package cli
 
 import (
+	"context"
+
+	"github.com/alessandrolattao/asqli/internal/features/answer"
+	"github.com/alessandrolattao/asqli/internal/features/cost"
 	"github.com/alessandrolattao/asqli/internal/features/execution"
 	"github.com/alessandrolattao/asqli/internal/features/query"
 	"github.com/alessandrolattao/asqli/internal/features/schema"
@@ -17,14 +21,19 @@ import (
 // Model represents the Bubble Tea model for the CLI application
 type Model struct {
 	// Configuration
-	dbConfig      adapters.Config
-	aiConfig      ai.Config
-	timeoutConfig config.TimeoutConfig
+	dbConfig         adapters.Config
+	aiConfig         ai.Config
+	timeoutConfig    config.TimeoutConfig
+	generationConfig config.GenerationConfig
+
+	// AI spend accounting shared by every AI request of the session
+	costTracker *cost.Tracker
 
 	// Services (initialized after connection)
 	queryService     *query.Service
 	executionService *execution.Service
 	schemaService    *schema.Service
+	answerService    *answer.Service
 
 	// Database connection (to close on exit)
 	dbConn     *database.Connection
@@ -42,12 +51,39 @@ type Model struct {
 	err           error
 	currentPrompt string
 	generatedSQL  string
-	currentUsage  ai.UsageMetadata
+	streamingSQL  string
+	currentSQL    *query.SQL // AI generation result for the current query (nil for raw SQL)
+	risks         []string   // Why the current query needs confirmation before running
+
+	// Dry run of the query awaiting confirmation, its transaction still open (nil when
+	// the query couldn't be previewed), and the table of its changed rows
+	preview      *execution.Preview
+	previewTable *Table
+
+	// Failed executions of AI-generated SQL for the current prompt (self-healing loop)
+	failedAttempts []query.Attempt
+
+	// Clarifying questions answered for the current prompt, and the one awaiting an answer
+	clarifications     []query.Clarification
+	clarifyingQuestion string
+
+	// AI cost of the current prompt, including repair attempts
+	promptCost float64
 
 	// Current result display
 	currentResult *execution.Result
 	currentError  error
 
+	// Answer mode: a plain-language answer shown above results of AI queries
+	answerMode     bool
+	resultQuestion string         // prompt that produced currentResult (empty for raw SQL)
+	answer         *answer.Answer // answer for currentResult (nil until received)
+	answerErr      error
+	answering      bool
+
+	// Explanation of a SQL query shown in the explain view
+	explanation *query.Explanation
+
 	// Status message
 	statusMessage string
 
@@ -61,6 +97,10 @@ type Model struct {
 	// Terminal dimensions
 	width  int
 	height int
+
+	// Session context, cancelled on quit so that background AI requests stop
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // NewModel creates a new Bubble Tea model with configuration
@@ -68,6 +108,8 @@ func NewModel(
 	dbConfig adapters.Config,
 	aiConfig ai.Config,
 	timeoutConfig config.TimeoutConfig,
+	generationConfig config.GenerationConfig,
+	costTracker *cost.Tracker,
 ) Model {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
@@ -88,23 +130,29 @@ func NewModel(
 	historyList.SetFilteringEnabled(false)
 	historyList.Styles.Title = logoStyle
 
+	ctx, cancel := context.WithCancel(context.Background())
+
 	return Model{
-		dbConfig:      dbConfig,
-		aiConfig:      aiConfig,
-		timeoutConfig: timeoutConfig,
-		state:         stateConnecting,
-		spinner:       s,
-		textInput:     ti,
-		list:          historyList,
-		history:       loadHistory(),
-		historyIndex:  -1,
+		dbConfig:         dbConfig,
+		aiConfig:         aiConfig,
+		timeoutConfig:    timeoutConfig,
+		generationConfig: generationConfig,
+		costTracker:      costTracker,
+		state:            stateConnecting,
+		spinner:          s,
+		textInput:        ti,
+		list:             historyList,
+		history:          loadHistory(),
+		historyIndex:     -1,
+		ctx:              ctx,
+		cancel:           cancel,
 	}
 }
 
 // Init initializes the Bubble Tea model
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(
-		connectDatabaseCmd(m.dbConfig, m.aiConfig, m.timeoutConfig),
+		connectDatabaseCmd(m.dbConfig, m.aiConfig, m.timeoutConfig, m.generationConfig, m.costTracker),
 		m.spinner.Tick,
 	)
 }
@@ -1,10 +1,24 @@
 package cli
 
-import "github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+import (
+	"github.com/alessandrolattao/asqli/internal/features/query"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+)
 
 // QueryHistory represents a completed query with its prompt, SQL, and debug information
 type QueryHistory struct {
-	Prompt string           // User's natural language prompt
-	SQL    string           // Generated/executed SQL query
-	Usage  ai.UsageMetadata // Usage metadata (tokens, model, provider, etc.)
+	Prompt         string                // User's natural language prompt
+	Clarifications []query.Clarification // Clarifying questions answered before the SQL (AI queries only)
+	SQL            string                // Generated/executed SQL query
+	Explanation    string                // Model's explanation of the query (AI queries only)
+	Confidence     float64               // Model's self-reported confidence (AI queries only)
+	Assumptions    []string              // Model's assumptions about the prompt (AI queries only)
+	Reasoning      string                // Model's thinking before answering, when reported (AI queries only)
+	FailedAttempts []query.Attempt       // Generated SQL that failed to execute, in order (AI queries only)
+	Candidates     []query.Candidate     // Candidate queries the SQL was selected from (multi-candidate generation only)
+	SchemaTables   []string              // Tables sent to the AI with the prompt (when the schema was cut down only)
+	Notices        []string              // How the request was cut down to fit the context window (AI queries only)
+	ToolCalls      []ai.ToolCall         // Tools the model called to inspect the database (exploration only)
+	Usage          ai.UsageMetadata      // Usage metadata (tokens, model, provider, etc.)
+	Cost           float64               // AI cost in USD, including repair attempts (AI queries only)
 }
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -11,9 +12,25 @@ func (m Model) renderResultsArea(height int) string {
 	// Create padding style
 	paddingStyle := lipgloss.NewStyle().Padding(1, 2)
 
+	// Clarifying question takes over the results area until it is answered
+	if m.state == stateClarifying {
+		return paddingStyle.Render(m.renderClarificationDialog())
+	}
+
+	// Changes of a previewed query take over the results area until confirmed or cancelled
+	if m.state == stateConfirming && m.preview != nil {
+		return paddingStyle.Render(m.renderPreview())
+	}
+
+	// Plain-language answer above the results (answer mode)
+	answerView := m.renderAnswer()
+
 	if m.table != nil {
 		// Show table with navigation and padding
 		tableView := m.table.View()
+		if answerView != "" {
+			tableView = answerView + "\n\n" + tableView
+		}
 		return paddingStyle.Render(tableView)
 	}
 
@@ -40,11 +57,109 @@ func (m Model) renderResultsArea(height int) string {
 		successStyle := lipgloss.NewStyle().
 			Foreground(lipgloss.Color("82")).
 			Bold(true)
+		affected := int64(len(m.currentResult.Rows))
+		if m.currentResult.RowsAffected > 0 {
+			affected = m.currentResult.RowsAffected
+		}
 		msg := successStyle.Render("✓ Query executed successfully") + "\n" +
-			subtleStyle.Render(fmt.Sprintf("(%d rows affected)", len(m.currentResult.Rows)))
+			subtleStyle.Render(fmt.Sprintf("(%d rows affected)", affected))
+		if answerView != "" {
+			msg = answerView + "\n\n" + msg
+		}
 		return paddingStyle.Render(msg)
 	}
 
 	// Empty state - show welcome screen with logo
 	return m.renderWelcomeScreen(height)
 }
+
+// renderAnswer renders the plain-language answer for the displayed result,
+// or an empty string when there is none to show
+func (m Model) renderAnswer() string {
+	if !m.answerMode || m.resultQuestion == "" {
+		return ""
+	}
+
+	width := max(m.width-TablePaddingHorizontal, 1)
+	answerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#E0E0E0")).
+		Width(width)
+
+	switch {
+	case m.answering:
+		return subtleStyle.Render("Writing answer...")
+	case m.answerErr != nil:
+		return errorStyle.Width(width).Render("✗ Answer Error: " + m.answerErr.Error())
+	case m.answer != nil:
+		return answerStyle.Render(m.answer.Text)
+	default:
+		return ""
+	}
+}
+
+// renderPreview renders the impact of the previewed query awaiting confirmation:
+// how many rows it changed and a sample of them
+func (m Model) renderPreview() string {
+	view := dangerStyle.Render("Dry run: "+m.previewImpact()+", not committed yet") + "\n"
+	if m.preview.Undo != "" {
+		view += subtleStyle.Render(strings.ToUpper(m.preview.Undo[:1])+m.preview.Undo[1:]) + "\n"
+	}
+
+	sample := m.preview.Sample
+	if m.previewTable == nil {
+		return view + subtleStyle.Render("Press y to commit, n to roll back")
+	}
+
+	caption := "Changed rows, as the query left them"
+	if m.preview.Before {
+		caption = "Rows the query changes, before the change"
+	}
+	if m.preview.RowsAffected > int64(len(sample.Rows)) {
+		caption += fmt.Sprintf(" (first %d)", len(sample.Rows))
+	}
+	if m.preview.Approximate {
+		caption += ", possibly not the same rows: LIMIT without ORDER BY"
+	}
+	return view + subtleStyle.Render(caption) + "\n\n" + m.previewTable.View()
+}
+
+// previewImpact describes how many rows the previewed query changed, or an empty
+// string when no query was previewed
+func (m Model) previewImpact() string {
+	switch {
+	case m.preview == nil:
+		return ""
+	case m.preview.RowsAffected < 0:
+		return "unknown number of rows affected"
+	case m.preview.RowsAffected == 1:
+		return "1 row affected"
+	default:
+		return fmt.Sprintf("%d rows affected", m.preview.RowsAffected)
+	}
+}
+
+// previewTableHeight returns the height available to the table of a preview,
+// below its lines of description and a blank line
+func (m Model) previewTableHeight() int {
+	return max(m.height-CommandBarHeight-TablePaddingVertical-4, 1)
+}
+
+// tableHeight returns the height available to the results table,
+// leaving room for the command bar, padding and the answer (if any)
+func (m Model) tableHeight() int {
+	height := m.height - CommandBarHeight - TablePaddingVertical
+	if answerView := m.renderAnswer(); answerView != "" {
+		height -= lipgloss.Height(answerView) + 1
+	}
+	return max(height, 1)
+}
+
+// resizeTable fits the results table to the space currently available
+func (m Model) resizeTable() {
+	if m.table != nil {
+		m.table.SetSize(m.width-TablePaddingHorizontal, m.tableHeight())
+	}
+	if m.previewTable != nil {
+		m.previewTable.SetSize(m.width-TablePaddingHorizontal, m.previewTableHeight())
+	}
+}
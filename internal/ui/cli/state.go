@@ -24,9 +24,18 @@ const (
 	// stateConfirming indicates the app is waiting for user confirmation of a dangerous query
 	stateConfirming
 
+	// stateClarifying indicates the app is waiting for the user to answer a clarifying question
+	stateClarifying
+
 	// stateHistory indicates the app is displaying query history
 	stateHistory
 
 	// stateInfo indicates the app is displaying information about the last query
 	stateInfo
+
+	// stateExplaining indicates the AI is explaining a SQL query
+	stateExplaining
+
+	// stateExplain indicates the app is displaying the explanation of a SQL query
+	stateExplain
 )
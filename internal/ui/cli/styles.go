@@ -61,6 +61,11 @@ var (
 			Foreground(lipgloss.Color("#E87580")).
 			Bold(true)
 
+	// Question style for clarifying questions asked by the AI
+	questionStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#61AFEF")).
+			Bold(true)
+
 	// Error style for error messages
 	errorStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#E06C75")).
@@ -1,12 +1,18 @@
 package cli
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/alessandrolattao/asqli/internal/features/execution"
 	"github.com/alessandrolattao/asqli/internal/features/query"
+	"github.com/alessandrolattao/asqli/internal/features/undo"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/database"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
@@ -20,7 +26,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case spinner.TickMsg:
 		// Update spinner only when loading
-		if m.state == stateConnecting || m.state == stateLoadingSchema || m.state == stateThinking || m.state == stateExecuting {
+		if m.state == stateConnecting || m.state == stateLoadingSchema || m.state == stateThinking || m.state == stateExecuting || m.state == stateExplaining {
 			m.spinner, cmd = m.spinner.Update(msg)
 			return m, cmd
 		}
@@ -78,6 +84,19 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		// Handle explain view separately
+		if m.state == stateExplain {
+			switch msg.String() {
+			case "esc":
+				// Exit explain view
+				m.state = stateReady
+				return m, nil
+			default:
+				// Ignore all other keys in explain view
+				return m, nil
+			}
+		}
+
 		switch msg.String() {
 		case "ctrl+q":
 			// Save history before quitting (best effort, don't block quit)
@@ -85,6 +104,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Log to stderr since we're quitting anyway
 				fmt.Fprintf(os.Stderr, "Warning: Failed to save history: %v\n", err)
 			}
+			// Discard the changes of a previewed query awaiting confirmation
+			if m.preview != nil {
+				if err := m.executionService.Rollback(m.preview); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				}
+			}
 			// Clean up resources before quitting (best effort)
 			if m.dbConn != nil {
 				if err := m.dbConn.Close(); err != nil {
@@ -96,6 +121,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					fmt.Fprintf(os.Stderr, "Warning: Failed to close AI provider: %v\n", err)
 				}
 			}
+			m.cancel()
 			return m, tea.Quit
 
 		case "ctrl+c":
@@ -122,6 +148,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 
+		case "ctrl+x":
+			// Explain SQL step by step (only when ready)
+			if m.state == stateReady {
+				return m.explainSQL()
+			}
+
+		case "ctrl+t":
+			// Toggle answer mode (only when ready)
+			if m.state == stateReady {
+				return m.toggleAnswerMode()
+			}
+
 		case "ctrl+up":
 			// Navigate history up (more recent)
 			if m.state == stateReady {
@@ -140,6 +178,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.table.MoveUp()
 				return m, nil
 			}
+			// Navigate the changed rows of a previewed query
+			if m.state == stateConfirming && m.previewTable != nil {
+				m.previewTable.MoveUp()
+				return m, nil
+			}
 
 		case "down":
 			// Navigate table down when result is shown
@@ -147,6 +190,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.table.MoveDown()
 				return m, nil
 			}
+			// Navigate the changed rows of a previewed query
+			if m.state == stateConfirming && m.previewTable != nil {
+				m.previewTable.MoveDown()
+				return m, nil
+			}
 
 		case "left":
 			// Navigate table left when result is shown
@@ -154,6 +202,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.table.MoveLeft()
 				return m, nil
 			}
+			// Navigate the changed rows of a previewed query
+			if m.state == stateConfirming && m.previewTable != nil {
+				m.previewTable.MoveLeft()
+				return m, nil
+			}
 
 		case "right":
 			// Navigate table right when result is shown
@@ -161,6 +214,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.table.MoveRight()
 				return m, nil
 			}
+			// Navigate the changed rows of a previewed query
+			if m.state == stateConfirming && m.previewTable != nil {
+				m.previewTable.MoveRight()
+				return m, nil
+			}
 
 		case "esc":
 			// Clear input field when ready
@@ -169,18 +227,29 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.historyIndex = -1
 				return m, nil
 			}
-			// Cancel confirmation
-			if m.state == stateConfirming {
+			// Give up on a prompt the AI asked to clarify
+			if m.state == stateClarifying {
 				m.state = stateReady
-				m.generatedSQL = ""
+				m.textInput.SetValue("")
+				m.clarifications = nil
+				m.clarifyingQuestion = ""
+				m.statusMessage = "Clarification cancelled"
 				return m, nil
 			}
+			// Cancel confirmation
+			if m.state == stateConfirming {
+				return m.handleConfirmNo()
+			}
 
 		case "enter":
 			// Submit query when ready
 			if m.state == stateReady {
 				return m.handleSubmit()
 			}
+			// Continue generation with the answer to a clarifying question
+			if m.state == stateClarifying {
+				return m.handleClarificationAnswer()
+			}
 
 		case "y":
 			// Confirm dangerous query
@@ -191,9 +260,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "n":
 			// Cancel dangerous query
 			if m.state == stateConfirming {
-				m.state = stateReady
-				m.generatedSQL = ""
-				return m, nil
+				return m.handleConfirmNo()
 			}
 		}
 
@@ -205,11 +272,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.list.SetHeight(msg.Height - TablePaddingVertical)
 
 		// Update table size if exists
-		if m.table != nil {
-			tableHeight := m.height - CommandBarHeight - TablePaddingVertical
-			tableWidth := m.width - TablePaddingHorizontal
-			m.table.SetSize(tableWidth, tableHeight)
-		}
+		m.resizeTable()
 
 		return m, nil
 
@@ -224,6 +287,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.schemaService = msg.schemaService
 		m.queryService = msg.queryService
 		m.executionService = msg.executionService
+		m.answerService = msg.answerService
 		m.state = stateLoadingSchema
 		// Now fetch schema
 		return m, tea.Batch(
@@ -251,7 +315,35 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.state = stateReady
 		return m, nil
 
+	case sqlChunkMsg:
+		// Accumulate streamed output for live display and keep listening.
+		// A reset means the provider's output was abandoned for another provider's
+		if m.state == stateThinking {
+			if msg.chunk == ai.StreamReset {
+				m.streamingSQL = ""
+			} else {
+				m.streamingSQL += msg.chunk
+			}
+		}
+		return m, waitForStreamCmd(msg.stream)
+
 	case sqlGeneratedMsg:
+		m.streamingSQL = ""
+
+		// Self-healing: SQL naming tables or columns the schema lacks is sent back
+		// to the provider with the diagnostic, before it reaches the database
+		var schemaErr *query.SchemaError
+		if errors.As(msg.err, &schemaErr) && len(m.failedAttempts) < m.generationConfig.MaxRepairAttempts {
+			m.failedAttempts = append(m.failedAttempts, query.Attempt{
+				SQL:   schemaErr.Query,
+				Error: schemaErr.Error(),
+			})
+			return m, tea.Batch(
+				generateSQLCmd(m.ctx, m.queryService, m.timeoutConfig, m.newQueryRequest(m.currentPrompt)),
+				m.spinner.Tick,
+			)
+		}
+
 		if msg.err != nil {
 			m.err = msg.err
 			m.currentError = nil
@@ -259,24 +351,36 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.table = nil
 			m.statusMessage = "✗ " + msg.err.Error()
 
-			// Extract query from ValidationError if available
+			// Extract query from ValidationError or SchemaError if available
 			var validationErr *query.ValidationError
 			if errors.As(msg.err, &validationErr) {
 				m.generatedSQL = validationErr.Query
+			} else if schemaErr != nil {
+				m.generatedSQL = schemaErr.Query
 			}
 
 			m.state = stateReady
 			return m, nil
 		}
 
+		// The AI asked a question about an ambiguous prompt: wait for the user's answer
+		if msg.sql.Clarification != "" {
+			m.promptCost += msg.sql.Cost.Cost
+			m.generatedSQL = ""
+			m.clarifyingQuestion = msg.sql.Clarification
+			m.textInput.SetValue("")
+			m.state = stateClarifying
+			return m, nil
+		}
+
 		m.generatedSQL = msg.sql.Query
-		m.currentUsage = msg.sql.Usage
+		m.currentSQL = msg.sql
+		m.promptCost += msg.sql.Cost.Cost
 		m.err = nil // Clear any previous generation errors
 
-		// Check if query is dangerous
-		if m.queryService.IsDangerous(m.generatedSQL) {
-			m.state = stateConfirming
-			return m, nil
+		// Dangerous queries are previewed, then wait for confirmation
+		if m.risks = m.queryService.Risks(m.generatedSQL); len(m.risks) > 0 {
+			return m.previewQuery()
 		}
 
 		// Execute directly if not dangerous
@@ -287,6 +391,20 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		)
 
 	case queryExecutedMsg:
+		// Self-healing: send failing AI-generated SQL back to the provider together with the database error
+		if msg.err != nil && repairable(msg.err) && m.currentSQL != nil && len(m.failedAttempts) < m.generationConfig.MaxRepairAttempts {
+			m.failedAttempts = append(m.failedAttempts, query.Attempt{
+				SQL:   m.generatedSQL,
+				Error: msg.err.Error(),
+			})
+			m.streamingSQL = ""
+			m.state = stateThinking
+			return m, tea.Batch(
+				generateSQLCmd(m.ctx, m.queryService, m.timeoutConfig, m.newQueryRequest(m.currentPrompt)),
+				m.spinner.Tick,
+			)
+		}
+
 		// Store for history (avoid consecutive duplicates)
 		if m.currentPrompt != "" {
 			// Only add if it's different from the last entry
@@ -301,11 +419,33 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Store complete query history (prompt + SQL + usage) for AI context and debug
 		if m.currentPrompt != "" && m.generatedSQL != "" {
-			m.queryHistory = append(m.queryHistory, QueryHistory{
+			entry := QueryHistory{
 				Prompt: m.currentPrompt,
 				SQL:    m.generatedSQL,
-				Usage:  m.currentUsage,
-			})
+			}
+			if m.currentSQL != nil {
+				entry.Clarifications = m.clarifications
+				entry.Explanation = m.currentSQL.Explanation
+				entry.Confidence = m.currentSQL.Confidence
+				entry.Assumptions = m.currentSQL.Assumptions
+				entry.Reasoning = m.currentSQL.Reasoning
+				entry.Candidates = m.currentSQL.Candidates
+				entry.SchemaTables = m.currentSQL.SchemaTables
+				entry.Notices = m.currentSQL.Notices
+				entry.ToolCalls = m.currentSQL.ToolCalls
+				entry.Usage = m.currentSQL.Usage
+				entry.Cost = m.promptCost
+
+				// Keep every failed attempt (including the final one) for the info view
+				entry.FailedAttempts = m.failedAttempts
+				if msg.err != nil {
+					entry.FailedAttempts = append(entry.FailedAttempts, query.Attempt{
+						SQL:   m.generatedSQL,
+						Error: msg.err.Error(),
+					})
+				}
+			}
+			m.queryHistory = append(m.queryHistory, entry)
 			// Keep only last N queries for context
 			if len(m.queryHistory) > MaxQueryHistory {
 				m.queryHistory = m.queryHistory[len(m.queryHistory)-MaxQueryHistory:]
@@ -317,34 +457,123 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.currentError = msg.err
 		m.err = nil // Clear any previous generation errors
 
+		// Answers are given for results of AI queries only
+		m.resultQuestion = ""
+		if m.currentSQL != nil && msg.err == nil {
+			m.resultQuestion = m.currentPrompt
+		}
+		m.answer = nil
+		m.answerErr = nil
+		m.answering = false
+
 		// Set status message based on result
 		if msg.err != nil {
 			m.statusMessage = "✗ " + msg.err.Error()
 		} else if msg.result != nil {
 			m.statusMessage = fmt.Sprintf("✓ Query executed successfully (%d rows)", len(msg.result.Rows))
+			if msg.result.RowsAffected > 0 {
+				m.statusMessage = fmt.Sprintf("✓ Committed (%d rows affected)", msg.result.RowsAffected)
+			}
+			if msg.undoable {
+				m.statusMessage += " · type 'undo' to restore the changed rows"
+			}
+			if m.currentSQL != nil && len(m.currentSQL.Notices) > 0 {
+				m.statusMessage += " · to fit the context window: " + strings.Join(m.currentSQL.Notices, ", ")
+			}
 		}
 
 		// Create table if result has rows
 		if msg.result != nil && len(msg.result.Rows) > 0 {
-			// Reserve space for command bar and padding
-			tableHeight := m.height - CommandBarHeight - TablePaddingVertical
-			tableWidth := m.width - TablePaddingHorizontal
-			m.table = NewTable(msg.result, tableWidth, tableHeight)
+			m.table = NewTable(msg.result, m.width-TablePaddingHorizontal, m.tableHeight())
 		} else {
 			m.table = nil
 		}
 
 		// Clear current state (keep generatedSQL to display in command bar)
 		m.currentPrompt = ""
+		m.failedAttempts = nil
 		m.err = nil
 		m.historyIndex = -1
 		m.state = stateReady
 
+		// Answer mode: ask for a plain-language answer in the background
+		cmd = m.requestAnswer()
+		m.resizeTable()
+
+		return m, cmd
+
+	case queryPreviewedMsg:
+		// Queries that can't be dry run (e.g. DDL) are confirmed without a preview
+		if errors.Is(msg.err, database.ErrNoDryRun) {
+			m.state = stateConfirming
+			return m, nil
+		}
+		// A failing dry run changed nothing: handle it like a failing execution
+		if msg.err != nil {
+			return m.Update(queryExecutedMsg{err: msg.err})
+		}
+
+		m.preview = msg.preview
+		m.previewTable = nil
+		if msg.preview.Sample != nil && len(msg.preview.Sample.Rows) > 0 {
+			m.previewTable = NewTable(msg.preview.Sample, m.width-TablePaddingHorizontal, m.previewTableHeight())
+		}
+		m.state = stateConfirming
+		return m, nil
+
+	case undoneMsg:
+		m.state = stateReady
+		switch {
+		case errors.Is(msg.err, undo.ErrNothingToUndo):
+			m.statusMessage = "Nothing to undo"
+		case msg.err != nil:
+			m.statusMessage = "✗ " + msg.err.Error()
+		default:
+			m.statusMessage = fmt.Sprintf("✓ Undone: restored %d rows of %s", msg.restore.Rows, msg.restore.Table)
+		}
+		return m, nil
+
+	case previewRolledBackMsg:
+		if msg.err != nil {
+			m.statusMessage = "✗ " + msg.err.Error()
+		} else {
+			m.statusMessage = "Rolled back: nothing was changed"
+		}
+		return m, nil
+
+	case explanationMsg:
+		if msg.err != nil {
+			m.state = stateReady
+			m.statusMessage = "✗ Explain Error: " + msg.err.Error()
+			return m, nil
+		}
+
+		m.explanation = msg.explanation
+		m.state = stateExplain
+		return m, nil
+
+	case answerMsg:
+		// Ignore answers for a result that is no longer displayed
+		if msg.result != m.currentResult {
+			return m, nil
+		}
+
+		m.answering = false
+		m.answer = msg.answer
+		m.answerErr = msg.err
+		if msg.answer != nil {
+			// The info view reports the AI cost of the query, answer included
+			if len(m.queryHistory) > 0 {
+				m.queryHistory[len(m.queryHistory)-1].Cost += msg.answer.Cost.Cost
+			}
+		}
+		m.resizeTable()
+
 		return m, nil
 	}
 
-	// Update text input when ready
-	if m.state == stateReady {
+	// Update text input when ready or answering a clarifying question
+	if m.state == stateReady || m.state == stateClarifying {
 		m.textInput, cmd = m.textInput.Update(msg)
 		return m, cmd
 	}
@@ -376,6 +605,46 @@ func (m Model) navigateHistory(direction int) (Model, tea.Cmd) {
 	return m, nil
 }
 
+// explainSQL asks the AI to explain the SQL to explain (see sqlToExplain) and shows
+// the explanation in the explain view once received
+func (m Model) explainSQL() (Model, tea.Cmd) {
+	sql := m.sqlToExplain()
+	if sql == "" {
+		m.statusMessage = "Nothing to explain: type # followed by SQL, or run a query first"
+		return m, nil
+	}
+
+	m.state = stateExplaining
+	return m, tea.Batch(
+		explainSQLCmd(m.queryService, m.timeoutConfig, sql, string(m.dbConfig.DriverType)),
+		m.spinner.Tick,
+	)
+}
+
+// sqlToExplain returns the SQL the explain view is about: the raw SQL in the input
+// (# prefix, typed or recalled from history), the SQL generated for a prompt in the input
+// that was recalled from history, or the last query when the input is empty
+func (m Model) sqlToExplain() string {
+	input := strings.TrimSpace(m.textInput.Value())
+	if sql, ok := strings.CutPrefix(input, "#"); ok {
+		return strings.TrimSpace(sql)
+	}
+
+	if input == "" {
+		if len(m.queryHistory) == 0 {
+			return ""
+		}
+		return m.queryHistory[len(m.queryHistory)-1].SQL
+	}
+
+	for i := len(m.queryHistory) - 1; i >= 0; i-- {
+		if m.queryHistory[i].Prompt == input {
+			return m.queryHistory[i].SQL
+		}
+	}
+	return ""
+}
+
 // openHistoryView opens the history list view
 func (m Model) openHistoryView() (Model, tea.Cmd) {
 	// Convert history slice to list items (reverse order - most recent first)
@@ -415,22 +684,37 @@ func (m Model) handleSubmit() (Model, tea.Cmd) {
 				fmt.Fprintf(os.Stderr, "Warning: Failed to close AI provider: %v\n", err)
 			}
 		}
+		m.cancel()
 		return m, tea.Quit
 	}
 
+	// Restore the rows changed by the last committed update or delete
+	if query == "undo" {
+		m.textInput.SetValue("")
+		m.historyIndex = -1
+		m.state = stateExecuting
+		return m, tea.Batch(
+			undoCmd(m.executionService, m.timeoutConfig),
+			m.spinner.Tick,
+		)
+	}
+
 	// Clear input
 	m.textInput.SetValue("")
 	m.historyIndex = -1
+	m.failedAttempts = nil
+	m.clarifications = nil
+	m.promptCost = 0
 
 	// Check for raw SQL (# prefix)
 	if strings.HasPrefix(query, "#") {
 		m.generatedSQL = strings.TrimSpace(strings.TrimPrefix(query, "#"))
 		m.currentPrompt = query
+		m.currentSQL = nil
 
-		// Check if dangerous
-		if m.queryService.IsDangerous(m.generatedSQL) {
-			m.state = stateConfirming
-			return m, nil
+		// Preview if dangerous, then wait for confirmation
+		if m.risks = m.queryService.Risks(m.generatedSQL); len(m.risks) > 0 {
+			return m.previewQuery()
 		}
 
 		// Execute directly
@@ -443,27 +727,145 @@ func (m Model) handleSubmit() (Model, tea.Cmd) {
 
 	// Generate SQL with AI
 	m.currentPrompt = query
+	m.streamingSQL = ""
+	m.state = stateThinking
+
+	return m, tea.Batch(
+		generateSQLCmd(m.ctx, m.queryService, m.timeoutConfig, m.newQueryRequest(query)),
+		m.spinner.Tick,
+	)
+}
+
+// handleClarificationAnswer records the user's answer to the clarifying question and
+// generates SQL for the current prompt again, with every answer so far in context
+func (m Model) handleClarificationAnswer() (Model, tea.Cmd) {
+	answer := strings.TrimSpace(m.textInput.Value())
+	if answer == "" {
+		return m, nil
+	}
+
+	m.clarifications = append(m.clarifications, query.Clarification{
+		Question: m.clarifyingQuestion,
+		Answer:   answer,
+	})
+	m.clarifyingQuestion = ""
+	m.textInput.SetValue("")
+	m.streamingSQL = ""
 	m.state = stateThinking
 
+	return m, tea.Batch(
+		generateSQLCmd(m.ctx, m.queryService, m.timeoutConfig, m.newQueryRequest(m.currentPrompt)),
+		m.spinner.Tick,
+	)
+}
+
+// newQueryRequest builds a SQL generation request for prompt from the current session state
+func (m Model) newQueryRequest(prompt string) *query.Request {
+	// Convert QueryHistory to query.History
+	history := make([]query.History, len(m.queryHistory))
+	for i, qh := range m.queryHistory {
+		history[i] = query.History{
+			Prompt:         qh.Prompt,
+			Clarifications: qh.Clarifications,
+			SQL:            qh.SQL,
+		}
+	}
+
+	req := &query.Request{
+		Prompt:         prompt,
+		Schema:         m.schema,
+		History:        history,
+		FailedAttempts: m.failedAttempts,
+		Clarifications: m.clarifications,
+		ReadOnly:       m.dbConfig.ReadOnly,
+	}
+
 	// Get selected column and value if table exists
-	var selectedColumn string
-	var selectedValue any
 	if m.table != nil {
-		selectedColumn = m.table.GetSelectedColumn()
-		selectedValue = m.table.GetSelectedValue()
+		req.SelectedColumn = m.table.GetSelectedColumn()
+		req.SelectedValue = m.table.GetSelectedValue()
 	}
 
+	// Describe the result currently on screen for follow-up prompts
+	req.LastResult = m.currentResult.Digest(m.generationConfig.ResultSampleRows)
+
+	return req
+}
+
+// repairable reports whether a failing query is worth sending back to the AI: errors of
+// the database running it, not refusals of the session policy (read-only), cancellations
+// or failed commits, which a rewritten query must not get around or can't fix
+func repairable(err error) bool {
+	return !errors.Is(err, database.ErrReadOnly) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, execution.ErrCommitFailed) &&
+		!errors.Is(err, sql.ErrTxDone)
+}
+
+// previewQuery dry runs the dangerous query about to be confirmed, so that the
+// confirmation shows how many and which rows it changes
+func (m Model) previewQuery() (Model, tea.Cmd) {
+	m.state = stateExecuting
 	return m, tea.Batch(
-		generateSQLCmd(m.queryService, m.timeoutConfig, query, m.schema, m.queryHistory, selectedColumn, selectedValue),
+		previewQueryCmd(m.executionService, m.timeoutConfig, m.generatedSQL),
 		m.spinner.Tick,
 	)
 }
 
-// handleConfirmYes proceeds with dangerous query execution
+// handleConfirmYes proceeds with dangerous query execution, or commits the changes
+// of its preview
 func (m Model) handleConfirmYes() (Model, tea.Cmd) {
 	m.state = stateExecuting
+	if preview := m.preview; preview != nil {
+		m.preview, m.previewTable = nil, nil
+		return m, tea.Batch(
+			commitPreviewCmd(m.executionService, preview),
+			m.spinner.Tick,
+		)
+	}
 	return m, tea.Batch(
 		executeQueryCmd(m.executionService, m.timeoutConfig, m.generatedSQL),
 		m.spinner.Tick,
 	)
 }
+
+// handleConfirmNo cancels the dangerous query, rolling back the changes of its preview
+func (m Model) handleConfirmNo() (Model, tea.Cmd) {
+	m.state = stateReady
+	m.generatedSQL = ""
+	if preview := m.preview; preview != nil {
+		m.preview, m.previewTable = nil, nil
+		return m, rollbackPreviewCmd(m.executionService, preview)
+	}
+	return m, nil
+}
+
+// toggleAnswerMode switches answer mode on or off. Turning it on answers the result
+// currently displayed, if it came from an AI query.
+func (m Model) toggleAnswerMode() (Model, tea.Cmd) {
+	m.answerMode = !m.answerMode
+
+	var cmd tea.Cmd
+	if m.answerMode {
+		m.statusMessage = "Answer mode on: results of AI queries are summarized in plain language"
+		if m.answer == nil && m.answerErr == nil {
+			cmd = m.requestAnswer()
+		}
+	} else {
+		m.statusMessage = "Answer mode off"
+	}
+	m.resizeTable()
+
+	return m, cmd
+}
+
+// requestAnswer marks the displayed result as being answered and returns the command
+// asking the AI for the answer, or nil when answer mode is off or there is nothing to answer
+func (m *Model) requestAnswer() tea.Cmd {
+	if !m.answerMode || m.answering || m.resultQuestion == "" || m.currentResult == nil {
+		return nil
+	}
+
+	m.answering = true
+	return answerResultCmd(m.answerService, m.timeoutConfig, m.resultQuestion, m.generatedSQL, m.currentResult)
+}
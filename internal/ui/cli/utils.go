@@ -1,5 +1,13 @@
 package cli
 
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alessandrolattao/asqli/internal/features/cost"
+	"github.com/alessandrolattao/asqli/internal/infrastructure/ai"
+)
+
 // wrapText wraps text to fit within the given width, attempting to break at natural boundaries.
 // It prefers breaking at spaces, commas, or parentheses to maintain SQL readability.
 func wrapText(text string, width int) []string {
@@ -28,3 +36,35 @@ func wrapText(text string, width int) []string {
 
 	return lines
 }
+
+// streamPreview prepares partially streamed AI output for display.
+// Providers stream a JSON object, so only the (partial) "sql" field is shown.
+func streamPreview(partial string) string {
+	return strings.TrimSpace(ai.PartialSQL(partial))
+}
+
+// formatUSD formats a dollar amount, keeping sub-cent amounts readable
+func formatUSD(amount float64) string {
+	if amount > 0 && amount < 0.01 {
+		return fmt.Sprintf("$%.4f", amount)
+	}
+	return fmt.Sprintf("$%.2f", amount)
+}
+
+// formatSpend formats the session AI spend for the command bar (e.g. "AI: $0.0123 / $5.00").
+// It returns "" before the first AI request when no budget is set.
+func formatSpend(totals cost.Totals, budget float64) string {
+	if totals.Requests == 0 && budget == 0 {
+		return ""
+	}
+
+	spend := "AI: " + formatUSD(totals.Cost)
+	if budget > 0 {
+		spend += " / " + formatUSD(budget)
+	}
+	if totals.UnpricedRequests > 0 {
+		spend += "+" // Some requests used models missing from the price table
+	}
+
+	return spend
+}
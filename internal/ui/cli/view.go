@@ -12,6 +12,11 @@ func (m Model) View() string {
 		return m.renderInfoView()
 	}
 
+	// Explain view takes over entire screen
+	if m.state == stateExplain {
+		return m.renderExplainView()
+	}
+
 	// Loading screen (connecting or loading schema)
 	if m.state == stateConnecting || m.state == stateLoadingSchema {
 		return m.renderLoadingScreen()